@@ -0,0 +1,40 @@
+package chromago
+
+import (
+	"net/http"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// authRoundTripper sets credentials on every request, so that tokens can be refreshed over the lifetime of the client.
+type authRoundTripper struct {
+	next          http.RoundTripper
+	authenticator types.RequestAuthenticator
+}
+
+func (a *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the request
+	req = req.Clone(req.Context())
+	if err := a.authenticator.AuthenticateRequest(req); err != nil {
+		return nil, err
+	}
+	resp, err := a.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		// the token may have been revoked before it expired, fetch a new one for the next request
+		if invalidator, ok := a.authenticator.(interface{ Invalidate() }); ok {
+			invalidator.Invalidate()
+		}
+	}
+	return resp, err
+}
+
+// withAuthenticator returns a copy of the HTTP client that authenticates every request.
+func withAuthenticator(client *http.Client, authenticator types.RequestAuthenticator) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	authenticated := *client
+	authenticated.Transport = &authRoundTripper{next: next, authenticator: authenticator}
+	return &authenticated
+}
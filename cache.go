@@ -0,0 +1,257 @@
+package chromago
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	openapiclient "github.com/szirtesitidom/chroma-go/swagger"
+)
+
+// WithResultCache enables the result cache (see Collection.EnableResultCache) on all collections returned by the client.
+// Each collection has its own cache.
+func WithResultCache(ttl time.Duration, maxEntries int) ClientOption {
+	return func(c *Client) error {
+		if err := validateResultCache(ttl, maxEntries); err != nil {
+			return err
+		}
+		c.resultCacheTTL = ttl
+		c.resultCacheSize = maxEntries
+		return nil
+	}
+}
+
+// EnableResultCache caches the results of QueryWithOptions and GetWithOptions (and Query and Get) for ttl, keeping at most
+// maxEntries results. Results are keyed by the request sent to the server: the query embeddings or IDs, where and
+// where_document filters, number of results, limit, offset and include. Query texts are still embedded, the embeddings are
+// part of the key. Concurrent identical requests are sent once. Add, Upsert, Modify and Delete on this Collection clear the
+// cache; writes by other clients or other Collection values of the same collection are only seen once entries expire.
+func (c *Collection) EnableResultCache(ttl time.Duration, maxEntries int) error {
+	if err := validateResultCache(ttl, maxEntries); err != nil {
+		return err
+	}
+	c.resultCache = newResultCache(ttl, maxEntries)
+	return nil
+}
+
+// ClearResultCache removes all cached results of the collection.
+func (c *Collection) ClearResultCache() {
+	if c.resultCache != nil {
+		c.resultCache.invalidate()
+	}
+}
+
+func validateResultCache(ttl time.Duration, maxEntries int) error {
+	if ttl <= 0 {
+		return fmt.Errorf("result cache TTL must be positive")
+	}
+	if maxEntries < 1 {
+		return fmt.Errorf("result cache must have at least one entry")
+	}
+	return nil
+}
+
+type resultCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// resultCache is a LRU cache of encoded results with expiry and single-flight loading. Results loaded before an
+// invalidation are not stored.
+type resultCache struct {
+	ttl        time.Duration
+	maxEntries int
+	group      singleflight.Group
+
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List // most recently used first
+	generation uint64
+}
+
+func newResultCache(ttl time.Duration, maxEntries int) *resultCache {
+	return &resultCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (r *resultCache) get(key string) ([]byte, uint64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	element, ok := r.entries[key]
+	if !ok {
+		return nil, r.generation, false
+	}
+	entry := element.Value.(*resultCacheEntry)
+	if time.Now().After(entry.expires) {
+		r.lru.Remove(element)
+		delete(r.entries, key)
+		return nil, r.generation, false
+	}
+	r.lru.MoveToFront(element)
+	return entry.value, r.generation, true
+}
+
+// put stores the value unless the cache was invalidated since generation.
+func (r *resultCache) put(key string, value []byte, generation uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if generation != r.generation {
+		return
+	}
+	if element, ok := r.entries[key]; ok {
+		r.lru.Remove(element)
+	}
+	r.entries[key] = r.lru.PushFront(&resultCacheEntry{key: key, value: value, expires: time.Now().Add(r.ttl)})
+	for r.lru.Len() > r.maxEntries {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.entries, oldest.Value.(*resultCacheEntry).key)
+	}
+}
+
+func (r *resultCache) invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	r.entries = make(map[string]*list.Element)
+	r.lru.Init()
+}
+
+// do decodes the cached result of the request into out, or loads it with fetch. Identical concurrent requests share one
+// fetch, which is not canceled if one of the callers gives up.
+func (r *resultCache) do(ctx context.Context, operation string, request interface{}, out interface{}, fetch func(ctx context.Context) (interface{}, error)) error {
+	encodedRequest, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(append([]byte(operation+"\n"), encodedRequest...))
+	key := hex.EncodeToString(sum[:])
+	value, generation, ok := r.get(key)
+	if !ok {
+		shared := context.WithoutCancel(ctx)
+		ch := r.group.DoChan(strconv.FormatUint(generation, 10)+"/"+key, func() (interface{}, error) {
+			result, err := fetch(shared)
+			if err != nil {
+				return nil, err
+			}
+			value, err := json.Marshal(result)
+			if err != nil {
+				return nil, err
+			}
+			r.put(key, value, generation)
+			return value, nil
+		})
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case res := <-ch:
+			if res.Err != nil {
+				return res.Err
+			}
+			value = res.Val.([]byte)
+		}
+	}
+	// every caller decodes its own copy, callers may modify the results
+	return json.Unmarshal(value, out)
+}
+
+// normalizedInclude returns the include values in a stable order.
+func normalizedInclude(include []openapiclient.IncludeInner) []openapiclient.IncludeInner {
+	normalized := slices.Clone(include)
+	slices.SortFunc(normalized, func(a, b openapiclient.IncludeInner) int {
+		return compareStringPointers(a.String, b.String)
+	})
+	return normalized
+}
+
+func compareStringPointers(a, b *string) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case *a < *b:
+		return -1
+	case *a > *b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// nilIfEmpty returns nil for empty filters, the request encodes nil and empty filters differently.
+func nilIfEmpty(filter map[string]interface{}) map[string]interface{} {
+	if len(filter) == 0 {
+		return nil
+	}
+	return filter
+}
+
+// queryResult sends the query request, through the result cache if it is enabled.
+func (c *Collection) queryResult(ctx context.Context, request openapiclient.QueryEmbedding) (*openapiclient.QueryResult, error) {
+	fetch := func(ctx context.Context) (*openapiclient.QueryResult, error) {
+		ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
+		defer cancel()
+		qr, _, err := c.ApiClient.DefaultApi.GetNearestNeighbors(c.scoped(ctx), c.ID).QueryEmbedding(request).Execute()
+		return qr, err
+	}
+	if c.resultCache == nil {
+		return fetch(ctx)
+	}
+	key := request
+	key.Where, key.WhereDocument, key.Include = nilIfEmpty(request.Where), nilIfEmpty(request.WhereDocument), normalizedInclude(request.Include)
+	var result openapiclient.QueryResult
+	err := c.resultCache.do(ctx, "query", key, &result, func(ctx context.Context) (interface{}, error) {
+		return fetch(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// getResult sends the get request, through the result cache if it is enabled.
+func (c *Collection) getResult(ctx context.Context, request openapiclient.GetEmbedding) (*openapiclient.GetResult, error) {
+	fetch := func(ctx context.Context) (*openapiclient.GetResult, error) {
+		ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
+		defer cancel()
+		cd, _, err := c.ApiClient.DefaultApi.Get(c.scoped(ctx), c.ID).GetEmbedding(request).Execute()
+		return cd, err
+	}
+	if c.resultCache == nil {
+		return fetch(ctx)
+	}
+	key := request
+	key.Where, key.WhereDocument, key.Include = nilIfEmpty(request.Where), nilIfEmpty(request.WhereDocument), normalizedInclude(request.Include)
+	var result openapiclient.GetResult
+	err := c.resultCache.do(ctx, "get", key, &result, func(ctx context.Context) (interface{}, error) {
+		return fetch(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// invalidateResultCache clears the result cache after a write of the collection.
+func (c *Collection) invalidateResultCache() {
+	if c.resultCache != nil {
+		c.resultCache.invalidate()
+	}
+}
@@ -0,0 +1,166 @@
+package chromago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// ErrUnsupportedByServer is returned by operations that use a feature the Chroma server does not support. The operations
+// fail client-side, before the request is sent. Use errors.As with *UnsupportedFeatureError for the feature.
+var ErrUnsupportedByServer = errors.New("not supported by the Chroma server")
+
+// Feature is a Chroma server feature whose support depends on the server version or configuration.
+type Feature string
+
+const (
+	// FeatureMultiTenancy is the support of tenants and databases.
+	FeatureMultiTenancy Feature = "tenants and databases"
+	// FeatureWhereDocumentNotContains is the $not_contains operator of where_document filters.
+	FeatureWhereDocumentNotContains Feature = "$not_contains in where_document"
+	// FeatureIncludeURIs is the storage of record URIs, returned with include uris.
+	FeatureIncludeURIs Feature = "record URIs"
+	// FeatureMaxBatchSize is the max_batch_size reported by the pre-flight checks of the server.
+	FeatureMaxBatchSize Feature = "max batch size"
+	// FeatureV2API is the tenant and database scoped /api/v2 REST API.
+	FeatureV2API Feature = "v2 API"
+	// FeatureCollectionConfiguration is the collection configuration, set with collection.WithConfiguration.
+	FeatureCollectionConfiguration Feature = "collection configuration"
+)
+
+// featureVersions are the server versions supporting the features that depend on the server version.
+var featureVersions = map[Feature]string{
+	FeatureMultiTenancy:             ">=0.4.15",
+	FeatureIncludeURIs:              ">=0.4.16",
+	FeatureWhereDocumentNotContains: ">=0.4.18",
+	FeatureCollectionConfiguration:  ">=0.5.1",
+	FeatureV2API:                    v2MinServerVersion,
+}
+
+// UnsupportedFeatureError is the error of an operation using a feature the server does not support. It matches
+// ErrUnsupportedByServer with errors.Is.
+type UnsupportedFeatureError struct {
+	Feature       Feature
+	ServerVersion string
+	// Requires is the server version constraint of the feature, empty if it depends on the server configuration.
+	Requires string
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	if e.Requires == "" {
+		return fmt.Sprintf("%s is not supported by Chroma server version %s", e.Feature, e.ServerVersion)
+	}
+	return fmt.Sprintf("%s is not supported by Chroma server version %s, requires %s", e.Feature, e.ServerVersion, e.Requires)
+}
+
+func (e *UnsupportedFeatureError) Is(target error) bool {
+	return target == ErrUnsupportedByServer
+}
+
+// Capabilities are the features supported by a Chroma server, derived from its version and pre-flight checks.
+type Capabilities struct {
+	ServerVersion semver.Version
+	// ServerAPI is the REST API used by the client.
+	ServerAPI ServerAPIVersion
+	// MaxBatchSize is the maximum number of records of a write, zero if the server does not report it.
+	MaxBatchSize int
+	features     map[Feature]bool
+}
+
+func newCapabilities(version semver.Version, serverAPI ServerAPIVersion, preFlightConfig map[string]interface{}) *Capabilities {
+	caps := &Capabilities{ServerVersion: version, ServerAPI: serverAPI, features: make(map[Feature]bool)}
+	for feature, constraint := range featureVersions {
+		c, _ := semver.NewConstraint(constraint)
+		caps.features[feature] = c.Check(&version)
+	}
+	if maxBatchSize, ok := preFlightConfig["max_batch_size"].(float64); ok {
+		caps.MaxBatchSize = int(maxBatchSize)
+	}
+	caps.features[FeatureMaxBatchSize] = caps.MaxBatchSize > 0
+	return caps
+}
+
+// Supports reports if the server supports the feature.
+func (c *Capabilities) Supports(feature Feature) bool {
+	return c.features[feature]
+}
+
+// require returns an *UnsupportedFeatureError if the server does not support the feature. Unknown capabilities, e.g. of
+// collections not returned by a client, support all features and leave the checks to the server.
+func (c *Capabilities) require(feature Feature) error {
+	if c == nil || c.Supports(feature) {
+		return nil
+	}
+	return &UnsupportedFeatureError{Feature: feature, ServerVersion: c.ServerVersion.String(), Requires: featureVersions[feature]}
+}
+
+// checkBatchSize returns an error wrapping ErrUnsupportedByServer if the write exceeds the max batch size of the server.
+func (c *Capabilities) checkBatchSize(records int) error {
+	if c == nil || c.MaxBatchSize == 0 || records <= c.MaxBatchSize {
+		return nil
+	}
+	return fmt.Errorf("%w: %d records exceed the max batch size %d", ErrUnsupportedByServer, records, c.MaxBatchSize)
+}
+
+// Capabilities returns the features supported by the server. It runs the pre-flight checks if no operation ran them yet.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	if err := c.preFlightChecks(ctx); err != nil {
+		return nil, err
+	}
+	return c.capabilities.Load(), nil
+}
+
+// requireFeature returns an *UnsupportedFeatureError if the server does not support the feature. Before the pre-flight checks
+// the capabilities are unknown and all features are allowed.
+func (c *Client) requireFeature(feature Feature) error {
+	return c.capabilities.Load().require(feature)
+}
+
+// usesNotContains reports if the where_document filter uses $not_contains, also in nested $and and $or filters.
+func usesNotContains(filter interface{}) bool {
+	switch f := filter.(type) {
+	case map[string]interface{}:
+		for operator, value := range f {
+			if operator == "$not_contains" || usesNotContains(value) {
+				return true
+			}
+		}
+	case []map[string]interface{}:
+		for _, value := range f {
+			if usesNotContains(value) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, value := range f {
+			if usesNotContains(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkWhereDocument returns an error if the where_document filter uses operators the server does not support.
+func (c *Collection) checkWhereDocument(whereDocument map[string]interface{}) error {
+	if usesNotContains(whereDocument) {
+		return c.capabilities.require(FeatureWhereDocumentNotContains)
+	}
+	return nil
+}
+
+// checkRequest returns an error if the include values or the where_document filter of a get or query use features the
+// server does not support.
+func (c *Collection) checkRequest(include []types.QueryEnum, whereDocument map[string]interface{}) error {
+	if slices.Contains(include, types.IURIs) {
+		if err := c.capabilities.require(FeatureIncludeURIs); err != nil {
+			return err
+		}
+	}
+	return c.checkWhereDocument(whereDocument)
+}
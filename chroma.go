@@ -5,11 +5,17 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/Masterminds/semver" //nolint:gci
 	"github.com/szirtesitidom/chroma-go/collection"
@@ -42,18 +48,49 @@ func APIEmbeddingsToEmbeddings(embeddings []openapiclient.EmbeddingsInner) []*ty
 	return result
 }
 
-// Client represents the ChromaDB Client
+func apiEmbeddingsMatrixToEmbeddings(embeddings [][]openapiclient.EmbeddingsInner) [][]*types.Embedding {
+	if embeddings == nil {
+		return nil
+	}
+	result := make([][]*types.Embedding, len(embeddings))
+	for i, v := range embeddings {
+		result[i] = APIEmbeddingsToEmbeddings(v)
+	}
+	return result
+}
+
+// Client represents the ChromaDB Client. The client is safe for concurrent use; use SetTenant and SetDatabase instead of
+// modifying Tenant and Database while the client is in use, and Close to release its connections.
 type Client struct {
 	ApiClient          *openapiclient.APIClient //nolint
 	Tenant             string
 	Database           string
 	APIVersion         semver.Version
+	scopeMu            sync.RWMutex // guards Tenant and Database
+	preFlightMu        sync.Mutex   // serializes the pre-flight checks and guards APIVersion and preFlightConfig
 	preFlightConfig    map[string]interface{}
-	preFlightCompleted bool
+	preFlightCompleted atomic.Bool
+	capabilities       atomic.Pointer[Capabilities] // set by the pre-flight checks
+	closed             atomic.Bool
 	apiConfiguration   *openapiclient.Configuration
 	httpTransport      *http.Transport
+	netDialer          *net.Dialer
 	userHTTPClient     *http.Client
+	authenticator      types.RequestAuthenticator
+	serverAPI          ServerAPIVersion
+	timeouts           Timeouts
+	middlewares        []Middleware
+	debug              bool
+	logger             *slog.Logger
+	endpointURLs       []string
+	healthInterval     time.Duration
+	failoverCooldown   time.Duration
+	endpoints          *endpointPool
+	resultCacheTTL     time.Duration
+	resultCacheSize    int
+	useV2              atomic.Bool
 	BasePath           string
+	dimensionGuard     bool
 }
 
 type ClientOption func(p *Client) error
@@ -88,15 +125,23 @@ func WithDatabase(database string) ClientOption {
 	}
 }
 
+// WithDebug logs every request and response at info level with the logger of WithLogger, or slog.Default() if none is set.
+// Documents are redacted, see LoggingMiddleware for more control over the logged requests.
 func WithDebug(debug bool) ClientOption {
 	return func(c *Client) error {
-		if c.apiConfiguration == nil {
-			c.apiConfiguration = openapiclient.NewConfiguration()
-		}
-		c.apiConfiguration.Debug = debug
+		c.debug = debug
+		return nil
+	}
+}
+
+// WithDimensionGuard enables the dimension guard (see Collection.EnableDimensionGuard) on all collections returned by the client.
+func WithDimensionGuard() ClientOption {
+	return func(c *Client) error {
+		c.dimensionGuard = true
 		return nil
 	}
 }
+
 func WithDefaultHeaders(headers map[string]string) ClientOption {
 	return func(c *Client) error {
 		if c.apiConfiguration == nil {
@@ -107,11 +152,20 @@ func WithDefaultHeaders(headers map[string]string) ClientOption {
 	}
 }
 
+// WithAuth sets the credentials of the client. Providers implementing types.RequestAuthenticator, such as the built-in and the
+// refreshing providers, authenticate every request, other providers set static headers once.
 func WithAuth(provider types.CredentialsProvider) ClientOption {
 	return func(c *Client) error {
 		if c == nil {
 			return fmt.Errorf("client is nil")
 		}
+		if provider == nil {
+			return fmt.Errorf("credentials provider cannot be nil")
+		}
+		if authenticator, ok := provider.(types.RequestAuthenticator); ok {
+			c.authenticator = authenticator
+			return nil
+		}
 		if c.apiConfiguration == nil {
 			return fmt.Errorf("api configuration is nil")
 		}
@@ -157,20 +211,76 @@ func WithSSLCert(certPath string) ClientOption {
 // WithInsecure disables SSL certificate verification. This option is not recommended for production use. The option is mutually exclusive with WithHttpClient.
 func WithInsecure() ClientOption {
 	return func(c *Client) error {
+		c.tlsConfig().InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the client. The configuration is cloned, TLS options after it modify the clone.
+// The option is mutually exclusive with WithHTTPClient.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) error {
+		if config == nil {
+			return fmt.Errorf("TLS config cannot be nil")
+		}
 		if c.httpTransport == nil {
 			c.httpTransport = &http.Transport{}
 		}
-		if c.httpTransport.TLSClientConfig == nil {
-			c.httpTransport.TLSClientConfig = &tls.Config{
-				InsecureSkipVerify: true,
+		c.httpTransport.TLSClientConfig = config.Clone()
+		return nil
+	}
+}
+
+// WithClientCertificate presents the certificate to servers requiring mutual TLS. The certificate and key must be PEM files.
+// Rotated files are reloaded for new connections without restarting the process. The option is mutually exclusive with WithHTTPClient.
+func WithClientCertificate(certPath string, keyPath string) ClientOption {
+	return func(c *Client) error {
+		reloader, err := newCertificateReloader(certPath, keyPath)
+		if err != nil {
+			return err
+		}
+		c.tlsConfig().GetClientCertificate = reloader.GetClientCertificate
+		return nil
+	}
+}
+
+// WithMinTLSVersion sets the minimum TLS version, e.g. tls.VersionTLS13. The option is mutually exclusive with WithHTTPClient.
+func WithMinTLSVersion(version uint16) ClientOption {
+	return func(c *Client) error {
+		if version < tls.VersionTLS12 || version > tls.VersionTLS13 {
+			return fmt.Errorf("unsupported minimum TLS version %s", tls.VersionName(version))
+		}
+		c.tlsConfig().MinVersion = version
+		return nil
+	}
+}
+
+// WithCipherSuites restricts the TLS 1.0-1.2 cipher suites, e.g. tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384. TLS 1.3 cipher suites
+// are not configurable. Insecure cipher suites (see tls.InsecureCipherSuites) are rejected. The option is mutually exclusive with WithHTTPClient.
+func WithCipherSuites(suites ...uint16) ClientOption {
+	return func(c *Client) error {
+		if len(suites) == 0 {
+			return fmt.Errorf("at least one cipher suite is required")
+		}
+		for _, suite := range suites {
+			if !slices.ContainsFunc(tls.CipherSuites(), func(s *tls.CipherSuite) bool { return s.ID == suite }) {
+				return fmt.Errorf("unsupported or insecure cipher suite %s", tls.CipherSuiteName(suite))
 			}
-		} else {
-			c.httpTransport.TLSClientConfig.InsecureSkipVerify = true
 		}
+		c.tlsConfig().CipherSuites = suites
 		return nil
 	}
 }
 
+// tlsConfig returns the TLS configuration of the transport, creating it if needed.
+func (c *Client) tlsConfig() *tls.Config {
+	t := c.transport()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	return t.TLSClientConfig
+}
+
 // WithHTTPClient sets a custom http.Client for the client. The option is mutually exclusive with WithSSLCert and WithIgnoreSSLCert.
 func WithHTTPClient(client *http.Client) ClientOption {
 	return func(c *Client) error {
@@ -198,6 +308,9 @@ func NewClient(options ...ClientOption) (*Client, error) {
 		apiConfiguration: openapiclient.NewConfiguration(),
 		httpTransport:    &http.Transport{TLSClientConfig: &tls.Config{}},
 		BasePath:         "http://localhost:8000",
+		timeouts:         defaultTimeouts(),
+		healthInterval:   DefaultHealthCheckInterval,
+		failoverCooldown: DefaultFailoverCooldown,
 	}
 
 	err := applyOptions(c, options...)
@@ -217,23 +330,80 @@ func NewClient(options ...ClientOption) (*Client, error) {
 			Transport: c.httpTransport,
 		}
 	}
+	if c.authenticator != nil {
+		c.apiConfiguration.HTTPClient = withAuthenticator(c.apiConfiguration.HTTPClient, c.authenticator)
+	}
+	if len(c.endpointURLs) > 0 {
+		c.endpoints, err = newEndpointPool(c)
+		if err != nil {
+			return nil, err
+		}
+		c.apiConfiguration.HTTPClient = withEndpoints(c.apiConfiguration.HTTPClient, c.endpoints)
+	}
+	middlewares := c.middlewares
+	if c.debug {
+		logger := c.logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		middlewares = append(slices.Clone(middlewares), LoggingMiddleware(logger, WithLogLevel(slog.LevelInfo), WithLogBodies()))
+	}
+	if len(middlewares) > 0 {
+		c.apiConfiguration.HTTPClient = withMiddlewares(c.apiConfiguration.HTTPClient, middlewares)
+	}
+	c.apiConfiguration.HTTPClient = withV2Routes(c.apiConfiguration.HTTPClient, c)
+	c.apiConfiguration.HTTPClient = withClosedCheck(c.apiConfiguration.HTTPClient, c)
 	c.ApiClient = openapiclient.NewAPIClient(c.apiConfiguration)
 	return c, nil
 }
 
 func (c *Client) SetTenant(tenant string) {
+	c.scopeMu.Lock()
+	defer c.scopeMu.Unlock()
 	c.Tenant = tenant
 }
 
 func (c *Client) SetDatabase(database string) {
+	c.scopeMu.Lock()
+	defer c.scopeMu.Unlock()
 	c.Database = database
 }
 
+// tenantAndDatabase returns the current default tenant and database of the client.
+func (c *Client) tenantAndDatabase() (string, string) {
+	c.scopeMu.RLock()
+	defer c.scopeMu.RUnlock()
+	return c.Tenant, c.Database
+}
+
+// preFlightChecks runs the pre-flight checks once. Concurrent callers wait for the running checks, failed checks are retried
+// by the next caller.
 func (c *Client) preFlightChecks(ctx context.Context) error {
-	if c.preFlightCompleted {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	if c.preFlightCompleted.Load() {
+		return nil
+	}
+	c.preFlightMu.Lock()
+	defer c.preFlightMu.Unlock()
+	if c.preFlightCompleted.Load() {
 		return nil
 	}
-	_version, _, err := c.ApiClient.DefaultApi.Version(ctx).Execute()
+	ctx = c.clientOperation(ctx, OperationPreFlightChecks, "")
+	err := c.runPreFlightChecks(ctx)
+	if err != nil {
+		c.log().WarnContext(ctx, "pre-flight checks failed", "base_path", c.BasePath, "error", err)
+		return err
+	}
+	c.log().DebugContext(ctx, "pre-flight checks completed", "base_path", c.BasePath, "server_version", c.APIVersion.String(), "v2", c.useV2.Load())
+	c.preFlightCompleted.Store(true)
+	return nil
+}
+
+// runPreFlightChecks detects the server version and API and checks the tenant and database.
+func (c *Client) runPreFlightChecks(ctx context.Context) error {
+	_version, err := c.selectServerAPI(ctx)
 	if err != nil {
 		return err
 	}
@@ -241,17 +411,17 @@ func (c *Client) preFlightChecks(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
 	c.APIVersion = *version
-	multiTenantAPIVersion, _ := semver.NewConstraint(">=0.4.15")
-
-	if multiTenantAPIVersion.Check(&c.APIVersion) {
-		_, err := c.GetTenant(ctx, c.Tenant)
+	caps := newCapabilities(c.APIVersion, c.ServerAPIVersion(), nil)
+	tenant, database := c.tenantAndDatabase()
+	if caps.Supports(FeatureMultiTenancy) {
+		_, err := c.GetTenant(ctx, tenant)
 		if err != nil {
 			return err
 		}
-		_, err = c.GetDatabase(ctx, c.Database, &c.Tenant)
+		_, err = c.GetDatabase(ctx, database, &tenant)
 		if err != nil {
 			return err
 		}
@@ -260,33 +430,39 @@ func (c *Client) preFlightChecks(ctx context.Context) error {
 			return err
 		}
 		c.preFlightConfig = preFlightCfg
+		caps = newCapabilities(c.APIVersion, c.ServerAPIVersion(), preFlightCfg)
+	} else if tenant != types.DefaultTenant || database != types.DefaultDatabase {
+		return caps.require(FeatureMultiTenancy)
 	}
-
-	c.preFlightCompleted = true
+	c.capabilities.Store(caps)
 	return nil
 }
 
 func (c *Client) GetCollection(ctx context.Context, collectionName string, embeddingFunction types.EmbeddingFunction) (*Collection, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.clientOperation(ctx, OperationGetCollection, collectionName)
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
 	err := c.preFlightChecks(ctx)
 	if err != nil {
 		return nil, err
 	}
-	tenantName := types.DefaultTenant
-	databaseName := types.DefaultDatabase
-	col, httpResp, err := c.ApiClient.DefaultApi.GetCollection(ctx, collectionName).Tenant(c.Tenant).Database(c.Database).Execute()
+	tenantName, databaseName := c.tenantAndDatabase()
+	col, httpResp, err := c.ApiClient.DefaultApi.GetCollection(ctx, collectionName).Tenant(tenantName).Database(databaseName).Execute()
 	if err != nil {
 		return nil, err
 	}
 	if httpResp.StatusCode != 200 {
 		return nil, fmt.Errorf("error getting collection: %v", httpResp)
 	}
-	return NewCollection(c.ApiClient, col.Id, col.Name, getMetadataFromAPI(col.Metadata), embeddingFunction, tenantName, databaseName), nil
+	collection := NewCollection(c.ApiClient, col.Id, col.Name, getMetadataFromAPI(col.Metadata), embeddingFunction, tenantName, databaseName)
+	collection.Configuration = col.ConfigurationJson
+	c.configureCollection(collection)
+	return collection, nil
 }
 
 func (c *Client) Heartbeat(ctx context.Context) (map[string]float32, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.clientOperation(ctx, OperationHeartbeat, "")
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
 	resp, _, err := c.ApiClient.DefaultApi.Heartbeat(ctx).Execute()
 	return resp, err
@@ -304,14 +480,22 @@ func GetStringTypeOfEmbeddingFunction(ef types.EmbeddingFunction) string {
 }
 
 func (c *Client) CreateTenant(ctx context.Context, tenantName string) (*openapiclient.Tenant, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	if err := c.requireFeature(FeatureMultiTenancy); err != nil {
+		return nil, err
+	}
+	ctx = withOperation(ctx, Operation{Type: OperationCreateTenant, Tenant: tenantName})
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Write)
 	defer cancel()
 	resp, _, err := c.ApiClient.DefaultApi.CreateTenant(ctx).CreateTenant(openapiclient.CreateTenant{Name: tenantName}).Execute()
 	return resp, err
 }
 
 func (c *Client) GetTenant(ctx context.Context, tenantName string) (*openapiclient.Tenant, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	if err := c.requireFeature(FeatureMultiTenancy); err != nil {
+		return nil, err
+	}
+	ctx = withOperation(ctx, Operation{Type: OperationGetTenant, Tenant: tenantName})
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
 	resp, _, err := c.ApiClient.DefaultApi.GetTenant(ctx, tenantName).Execute()
 	return resp, err
@@ -319,9 +503,14 @@ func (c *Client) GetTenant(ctx context.Context, tenantName string) (*openapiclie
 
 func (c *Client) CreateDatabase(ctx context.Context, databaseName string, tenantName *string) (*openapiclient.Database, error) {
 	if tenantName == nil {
-		tenantName = &c.Tenant
+		tenant, _ := c.tenantAndDatabase()
+		tenantName = &tenant
 	}
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	if err := c.requireFeature(FeatureMultiTenancy); err != nil {
+		return nil, err
+	}
+	ctx = withOperation(ctx, Operation{Type: OperationCreateDatabase, Tenant: *tenantName, Database: databaseName})
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Write)
 	defer cancel()
 	resp, _, err := c.ApiClient.DefaultApi.CreateDatabase(ctx).Tenant(*tenantName).CreateDatabase(openapiclient.CreateDatabase{Name: databaseName}).Execute()
 	return resp, err
@@ -329,9 +518,14 @@ func (c *Client) CreateDatabase(ctx context.Context, databaseName string, tenant
 
 func (c *Client) GetDatabase(ctx context.Context, databaseName string, tenantName *string) (*openapiclient.Database, error) {
 	if tenantName == nil {
-		tenantName = &c.Tenant
+		tenant, _ := c.tenantAndDatabase()
+		tenantName = &tenant
+	}
+	if err := c.requireFeature(FeatureMultiTenancy); err != nil {
+		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = withOperation(ctx, Operation{Type: OperationGetDatabase, Tenant: *tenantName, Database: databaseName})
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
 	resp, _, err := c.ApiClient.DefaultApi.GetDatabase(ctx, databaseName).Tenant(*tenantName).Execute()
 	return resp, err
@@ -350,12 +544,22 @@ func copyMap(originalMap map[string]interface{}) map[string]interface{} {
 }
 
 func (c *Client) CreateCollection(ctx context.Context, collectionName string, metadata map[string]interface{}, createOrGet bool, embeddingFunction types.EmbeddingFunction, distanceFunction types.DistanceFunction) (*Collection, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	return c.createCollection(ctx, collectionName, metadata, nil, createOrGet, embeddingFunction, distanceFunction)
+}
+
+func (c *Client) createCollection(ctx context.Context, collectionName string, metadata map[string]interface{}, configuration map[string]interface{}, createOrGet bool, embeddingFunction types.EmbeddingFunction, distanceFunction types.DistanceFunction) (*Collection, error) {
+	ctx = c.clientOperation(ctx, OperationCreateCollection, collectionName)
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Write)
 	defer cancel()
 	err := c.preFlightChecks(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if len(configuration) > 0 {
+		if err := c.requireFeature(FeatureCollectionConfiguration); err != nil {
+			return nil, err
+		}
+	}
 	var _metadata = copyMap(metadata)
 	if metadata["embedding_function"] == nil && embeddingFunction != nil {
 		_metadata["embedding_function"] = GetStringTypeOfEmbeddingFunction(embeddingFunction)
@@ -366,16 +570,21 @@ func (c *Client) CreateCollection(ctx context.Context, collectionName string, me
 		_metadata[types.HNSWSpace] = strings.ToLower(string(distanceFunction))
 	}
 	col := openapiclient.CreateCollection{
-		Name:        collectionName,
-		GetOrCreate: &createOrGet,
-		Metadata:    _metadata,
+		Name:          collectionName,
+		GetOrCreate:   &createOrGet,
+		Metadata:      _metadata,
+		Configuration: configuration,
 	}
-	resp, _, err := c.ApiClient.DefaultApi.CreateCollection(ctx).CreateCollection(col).Execute()
+	tenant, database := c.tenantAndDatabase()
+	resp, _, err := c.ApiClient.DefaultApi.CreateCollection(ctx).Tenant(tenant).Database(database).CreateCollection(col).Execute()
 	if err != nil {
 		return nil, err
 	}
 	mtd := resp.Metadata
-	return NewCollection(c.ApiClient, resp.Id, resp.Name, getMetadataFromAPI(mtd), embeddingFunction, c.Tenant, c.Database), nil
+	collection := NewCollection(c.ApiClient, resp.Id, resp.Name, getMetadataFromAPI(mtd), embeddingFunction, tenant, database)
+	collection.Configuration = resp.ConfigurationJson
+	c.configureCollection(collection)
+	return collection, nil
 }
 
 func (c *Client) NewCollection(ctx context.Context, name string, options ...collection.Option) (*Collection, error) {
@@ -399,79 +608,88 @@ func (c *Client) NewCollection(ctx context.Context, name string, options ...coll
 			return nil, derr
 		}
 	}
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
-	defer cancel()
-	return c.CreateCollection(ctx, b.Name, b.Metadata, b.CreateIfNotExist, b.EmbeddingFunction, distanceFunction)
+	return c.createCollection(ctx, b.Name, b.Metadata, b.Configuration, b.CreateIfNotExist, b.EmbeddingFunction, distanceFunction)
 }
 
 func (c *Client) DeleteCollection(ctx context.Context, collectionName string) (*Collection, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.clientOperation(ctx, OperationDeleteCollection, collectionName)
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Write)
 	defer cancel()
 	err := c.preFlightChecks(ctx)
 	if err != nil {
 		return nil, err
 	}
-	col, _, gcerr := c.ApiClient.DefaultApi.GetCollection(ctx, collectionName).Execute()
+	tenant, database := c.tenantAndDatabase()
+	col, _, gcerr := c.ApiClient.DefaultApi.GetCollection(ctx, collectionName).Tenant(tenant).Database(database).Execute()
 	if gcerr != nil {
 		return nil, gcerr
 	}
-	deletedCol, _, err := c.ApiClient.DefaultApi.DeleteCollection(ctx, collectionName).Execute()
+	deletedCol, _, err := c.ApiClient.DefaultApi.DeleteCollection(ctx, collectionName).Tenant(tenant).Database(database).Execute()
 	if err != nil {
 		return nil, err
 	}
 	if deletedCol == nil {
-		return NewCollection(c.ApiClient, col.Id, col.Name, getMetadataFromAPI(col.Metadata), nil, c.Tenant, c.Database), nil
+		return c.configureCollection(NewCollection(c.ApiClient, col.Id, col.Name, getMetadataFromAPI(col.Metadata), nil, tenant, database)), nil
 	} else {
-		return NewCollection(c.ApiClient, deletedCol.Id, deletedCol.Name, getMetadataFromAPI(deletedCol.Metadata), nil, c.Tenant, c.Database), nil
+		return c.configureCollection(NewCollection(c.ApiClient, deletedCol.Id, deletedCol.Name, getMetadataFromAPI(deletedCol.Metadata), nil, tenant, database)), nil
 	}
 }
 
 func (c *Client) Reset(ctx context.Context) (bool, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.clientOperation(ctx, OperationReset, "")
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Write)
 	defer cancel()
 	resp, _, err := c.ApiClient.DefaultApi.Reset(ctx).Execute()
 	return resp, err
 }
 
 func (c *Client) ListCollections(ctx context.Context) ([]*Collection, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.clientOperation(ctx, OperationListCollections, "")
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
 	err := c.preFlightChecks(ctx)
 	if err != nil {
 		return nil, err
 	}
-	req := c.ApiClient.DefaultApi.ListCollections(ctx)
+	tenant, database := c.tenantAndDatabase()
+	req := c.ApiClient.DefaultApi.ListCollections(ctx).Tenant(tenant).Database(database)
 	resp, _, err := req.Execute()
 	if err != nil {
 		return nil, err
 	}
 	collections := make([]*Collection, len(resp))
 	for i, col := range resp {
-		collections[i] = NewCollection(c.ApiClient, col.Id, col.Name, getMetadataFromAPI(col.Metadata), nil, c.Tenant, c.Database)
+		collections[i] = NewCollection(c.ApiClient, col.Id, col.Name, getMetadataFromAPI(col.Metadata), nil, tenant, database)
+		collections[i].Configuration = col.ConfigurationJson
+		c.configureCollection(collections[i])
 	}
 	return collections, nil
 }
 
 func (c *Client) CountCollections(ctx context.Context) (int32, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.clientOperation(ctx, OperationCountCollections, "")
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
 	err := c.preFlightChecks(ctx)
 	if err != nil {
 		return -1, err
 	}
-	resp, _, err := c.ApiClient.DefaultApi.CountCollections(ctx).Tenant(c.Tenant).Database(c.Database).Execute()
+	tenant, database := c.tenantAndDatabase()
+	resp, _, err := c.ApiClient.DefaultApi.CountCollections(ctx).Tenant(tenant).Database(database).Execute()
 	return resp, err
 }
 
 func (c *Client) PreflightChecks(ctx context.Context) (map[string]interface{}, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.clientOperation(ctx, OperationPreFlightChecks, "")
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
 	resp, _, err := c.ApiClient.DefaultApi.PreFlightChecks(ctx).Execute()
 	return resp, err
 }
 
 func (c *Client) Version(ctx context.Context) (string, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.clientOperation(ctx, OperationVersion, "")
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
 	resp, _, err := c.ApiClient.DefaultApi.Version(ctx).Execute()
 	version := strings.ReplaceAll(resp, `"`, "")
@@ -483,19 +701,46 @@ type GetResults struct {
 	Documents  []string
 	Metadatas  []map[string]interface{}
 	Embeddings []*types.Embedding
+	Uris       []string
 }
 
+// Collection is a collection of a Chroma server. The record operations (add, upsert, modify, delete, get, query and count)
+// are safe for concurrent use. Update and the dimension guard replace Metadata, do not access the field while they may run.
 type Collection struct {
 	Name              string
 	EmbeddingFunction types.EmbeddingFunction
 	ApiClient         *openapiclient.APIClient //nolint
 	Metadata          map[string]interface{}
-	ID                string
-	Tenant            string
-	Database          string
+	// Configuration is the collection configuration reported by servers supporting it.
+	Configuration  map[string]interface{}
+	ID             string
+	Tenant         string
+	Database       string
+	DataLoader     types.DataLoader // resolves record URIs for image embedding functions
+	dimensionGuard bool
+	timeouts       Timeouts
+	resultCache    *resultCache
+	capabilities   *Capabilities // of the server, nil if unknown
+	logger         *slog.Logger
+	metadataMu     sync.RWMutex // guards Metadata
+	dimensionMu    sync.Mutex   // serializes recording the dimension
+}
+
+// embed calls the embedding function with the embedding timeout.
+func (c *Collection) embed(ctx context.Context, embed func(ctx context.Context) ([]*types.Embedding, error)) ([]*types.Embedding, error) {
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Embedding)
+	defer cancel()
+	return embed(ctx)
+}
+
+// scoped adds the tenant and database of the collection to the context for the v2 API routes.
+func (c *Collection) scoped(ctx context.Context) context.Context {
+	return withScope(ctx, c.Tenant, c.Database)
 }
 
 func (c *Collection) String() string {
+	c.metadataMu.RLock()
+	defer c.metadataMu.RUnlock()
 	return fmt.Sprintf("Collection{ Name: %s, ID: %s, Tenant: %s, Database: %s, Metadata: %v }",
 		c.Name, c.ID, c.Tenant, c.Database, c.Metadata)
 }
@@ -513,96 +758,145 @@ func NewCollection(apiClient *openapiclient.APIClient, id string, name string, m
 		ID:                id,
 		Tenant:            tenant,
 		Database:          database,
+		timeouts:          defaultTimeouts(),
 	}
 }
 
-func (c *Collection) Add(ctx context.Context, embeddings []*types.Embedding, metadatas []map[string]interface{}, documents []string, ids []string) (*Collection, error) {
-	var _embeddings []openapiclient.EmbeddingsInner
-
-	if len(ids) != len(documents) && len(documents) != len(metadatas) {
-		return c, fmt.Errorf("ids and embeddings must have the same length")
-	}
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
-	defer cancel()
-	if len(embeddings) == 0 {
-		embds, embErr := c.EmbeddingFunction.EmbedDocuments(ctx, documents)
-		if embErr != nil {
-			return c, embErr
-		}
-		_embeddings = types.ToAPIEmbeddings(embds)
-	} else {
-		_embeddings = types.ToAPIEmbeddings(embeddings)
+// configureCollection applies the client settings to a collection returned by the client.
+func (c *Client) configureCollection(collection *Collection) *Collection {
+	collection.dimensionGuard = c.dimensionGuard
+	collection.timeouts = c.timeouts
+	collection.capabilities = c.capabilities.Load()
+	collection.logger = c.log()
+	if c.resultCacheTTL > 0 {
+		collection.resultCache = newResultCache(c.resultCacheTTL, c.resultCacheSize)
 	}
+	return collection
+}
 
-	if len(ids) == 0 {
-		return c, fmt.Errorf("ids cannot be empty")
-	}
-	var addEmbedding = openapiclient.AddEmbedding{
-		Embeddings: _embeddings,
-		Metadatas:  metadatas,
-		Documents:  documents,
-		Ids:        ids,
-	}
-	_, _, err := c.ApiClient.DefaultApi.Add(ctx, c.ID).AddEmbedding(addEmbedding).Execute()
-	if err != nil {
-		return c, err
-	}
-	return c, nil
+func (c *Collection) Add(ctx context.Context, embeddings []*types.Embedding, metadatas []map[string]interface{}, documents []string, ids []string) (*Collection, error) {
+	return c.addOrUpsert(ctx, false, embeddings, metadatas, documents, nil, ids)
+}
+
+// AddWithURIs adds records that reference their data by URI. Records without embeddings or documents are embedded from the
+// data loaded by the collection's DataLoader, which requires an ImageEmbeddingFunction.
+func (c *Collection) AddWithURIs(ctx context.Context, embeddings []*types.Embedding, metadatas []map[string]interface{}, documents []string, uris []string, ids []string) (*Collection, error) {
+	return c.addOrUpsert(ctx, false, embeddings, metadatas, documents, uris, ids)
 }
 
 func (c *Collection) AddRecords(ctx context.Context, recordSet *types.RecordSet) (*Collection, error) {
-	return c.Add(ctx, recordSet.GetEmbeddings(), recordSet.GetMetadatas(), recordSet.GetDocuments(), recordSet.GetIDs())
+	return c.addOrUpsert(ctx, false, recordSet.GetEmbeddings(), recordSet.GetMetadatas(), recordSet.GetDocuments(), recordSetURIs(recordSet), recordSet.GetIDs())
 }
 
 func (c *Collection) Upsert(ctx context.Context, embeddings []*types.Embedding, metadatas []map[string]interface{}, documents []string, ids []string) (*Collection, error) {
+	return c.addOrUpsert(ctx, true, embeddings, metadatas, documents, nil, ids)
+}
+
+// UpsertWithURIs is the upsert counterpart of AddWithURIs.
+func (c *Collection) UpsertWithURIs(ctx context.Context, embeddings []*types.Embedding, metadatas []map[string]interface{}, documents []string, uris []string, ids []string) (*Collection, error) {
+	return c.addOrUpsert(ctx, true, embeddings, metadatas, documents, uris, ids)
+}
+
+func (c *Collection) UpsertRecords(ctx context.Context, recordSet *types.RecordSet) (*Collection, error) {
+	return c.addOrUpsert(ctx, true, recordSet.GetEmbeddings(), recordSet.GetMetadatas(), recordSet.GetDocuments(), recordSetURIs(recordSet), recordSet.GetIDs())
+}
+
+// recordSetURIs returns the URIs of the record set, or nil if none of the records has a URI.
+func recordSetURIs(recordSet *types.RecordSet) []string {
+	uris := recordSet.GetURIs()
+	for _, uri := range uris {
+		if uri != "" {
+			return uris
+		}
+	}
+	return nil
+}
+
+func (c *Collection) addOrUpsert(ctx context.Context, upsert bool, embeddings []*types.Embedding, metadatas []map[string]interface{}, documents []string, uris []string, ids []string) (*Collection, error) {
 	var _embeddings []openapiclient.EmbeddingsInner
 
 	if len(ids) != len(documents) && len(documents) != len(metadatas) {
 		return c, fmt.Errorf("ids and embeddings must have the same length")
 	}
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
-	defer cancel()
+	if len(uris) > 0 && len(uris) != len(ids) {
+		return c, fmt.Errorf("ids and uris must have the same length")
+	}
+	if len(uris) > 0 {
+		if err := c.capabilities.require(FeatureIncludeURIs); err != nil {
+			return c, err
+		}
+	}
+	if err := c.capabilities.checkBatchSize(len(ids)); err != nil {
+		return c, err
+	}
+	if upsert {
+		ctx = c.operation(ctx, OperationUpsert, len(ids))
+	} else {
+		ctx = c.operation(ctx, OperationAdd, len(ids))
+	}
 	if len(embeddings) == 0 {
-		embds, embErr := c.EmbeddingFunction.EmbedDocuments(ctx, documents)
+		embds, embErr := c.embed(ctx, func(ctx context.Context) ([]*types.Embedding, error) {
+			if len(uris) > 0 {
+				return types.EmbedDocumentsOrURIs(ctx, c.EmbeddingFunction, c.DataLoader, documents, uris)
+			}
+			return c.EmbeddingFunction.EmbedDocuments(ctx, documents)
+		})
 		if embErr != nil {
 			return c, embErr
 		}
-		_embeddings = types.ToAPIEmbeddings(embds)
-	} else {
-		_embeddings = types.ToAPIEmbeddings(embeddings)
+		embeddings = embds
 	}
+	dim, err := c.checkDimension(embeddings)
+	if err != nil {
+		return c, err
+	}
+	_embeddings = types.ToAPIEmbeddings(embeddings)
+
 	if len(ids) == 0 {
 		return c, fmt.Errorf("ids cannot be empty")
 	}
-
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Write)
+	defer cancel()
 	var addEmbedding = openapiclient.AddEmbedding{
 		Embeddings: _embeddings,
 		Metadatas:  metadatas,
 		Documents:  documents,
+		Uris:       uris,
 		Ids:        ids,
 	}
-
-	_, _, err := c.ApiClient.DefaultApi.Upsert(ctx, c.ID).AddEmbedding(addEmbedding).Execute()
-
+	defer c.invalidateResultCache()
+	if upsert {
+		_, _, err = c.ApiClient.DefaultApi.Upsert(c.scoped(ctx), c.ID).AddEmbedding(addEmbedding).Execute()
+	} else {
+		_, _, err = c.ApiClient.DefaultApi.Add(c.scoped(ctx), c.ID).AddEmbedding(addEmbedding).Execute()
+	}
 	if err != nil {
 		return c, err
 	}
+	c.recordDimension(ctx, dim)
 	return c, nil
 }
 
 func (c *Collection) Modify(ctx context.Context, embeddings []*types.Embedding, metadatas []map[string]interface{}, documents []string, ids []string) (*Collection, error) {
+	if err := c.capabilities.checkBatchSize(len(ids)); err != nil {
+		return c, err
+	}
+	ctx = c.operation(ctx, OperationUpdate, len(ids))
 	var _embeddings []openapiclient.EmbeddingsInner
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
-	defer cancel()
 	if len(embeddings) == 0 {
-		embds, embErr := c.EmbeddingFunction.EmbedDocuments(ctx, documents)
+		embds, embErr := c.embed(ctx, func(ctx context.Context) ([]*types.Embedding, error) {
+			return c.EmbeddingFunction.EmbedDocuments(ctx, documents)
+		})
 		if embErr != nil {
 			return c, embErr
 		}
-		_embeddings = types.ToAPIEmbeddings(embds)
-	} else {
-		_embeddings = types.ToAPIEmbeddings(embeddings)
+		embeddings = embds
 	}
+	_, err := c.checkDimension(embeddings)
+	if err != nil {
+		return c, err
+	}
+	_embeddings = types.ToAPIEmbeddings(embeddings)
 
 	var updateEmbedding = openapiclient.UpdateEmbedding{
 		Embeddings: _embeddings,
@@ -611,7 +905,10 @@ func (c *Collection) Modify(ctx context.Context, embeddings []*types.Embedding,
 		Ids:        ids,
 	}
 
-	_, _, err := c.ApiClient.DefaultApi.Update(ctx, c.ID).UpdateEmbedding(updateEmbedding).Execute()
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Write)
+	defer cancel()
+	defer c.invalidateResultCache()
+	_, _, err = c.ApiClient.DefaultApi.Update(c.scoped(ctx), c.ID).UpdateEmbedding(updateEmbedding).Execute()
 
 	if err != nil {
 		return c, err
@@ -630,6 +927,9 @@ func (c *Collection) GetWithOptions(ctx context.Context, options ...types.Collec
 	if query.Include == nil {
 		query.Include = []types.QueryEnum{types.IDocuments, types.IMetadatas}
 	}
+	if err := c.checkRequest(query.Include, query.WhereDocument); err != nil {
+		return nil, err
+	}
 	inc := make([]openapiclient.IncludeInner, len(query.Include))
 	for i, v := range query.Include {
 		_v := string(v)
@@ -637,16 +937,15 @@ func (c *Collection) GetWithOptions(ctx context.Context, options ...types.Collec
 			String: &_v,
 		}
 	}
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
-	defer cancel()
-	cd, _, err := c.ApiClient.DefaultApi.Get(ctx, c.ID).GetEmbedding(openapiclient.GetEmbedding{
+	ctx = c.operation(ctx, OperationGet, len(query.Ids))
+	cd, err := c.getResult(ctx, openapiclient.GetEmbedding{
 		Ids:           query.Ids,
 		Where:         query.Where,
 		WhereDocument: query.WhereDocument,
 		Include:       inc,
 		Limit:         &query.Limit,
 		Offset:        &query.Offset,
-	}).Execute()
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -656,6 +955,7 @@ func (c *Collection) GetWithOptions(ctx context.Context, options ...types.Collec
 		Documents:  cd.Documents,
 		Metadatas:  cd.Metadatas,
 		Embeddings: APIEmbeddingsToEmbeddings(cd.Embeddings),
+		Uris:       cd.Uris,
 	}
 	return results, nil
 }
@@ -669,9 +969,15 @@ type QueryResults struct {
 	Ids                           [][]string                 `json:"ids,omitempty"`
 	Metadatas                     [][]map[string]interface{} `json:"metadatas,omitempty"`
 	Distances                     [][]float32                `json:"distances,omitempty"`
+	Embeddings                    [][]*types.Embedding       `json:"embeddings,omitempty"`
+	Scores                        [][]float32                `json:"scores,omitempty"`           // fused scores of hybrid search, higher is better
+	VectorDistances               [][]*float32               `json:"vector_distances,omitempty"` // distances of hybrid search, nil for keyword only matches
+	Uris                          [][]string                 `json:"uris,omitempty"`
 	QueryTexts                    []string
 	QueryEmbeddings               []*types.Embedding
 	QueryTextsGeneratedEmbeddings []*types.Embedding // the generated embeddings from the query texts
+	QueryURIs                     []string
+	QueryURIsGeneratedEmbeddings  []*types.Embedding // the generated embeddings from the data of the query URIs
 }
 
 func getMetadataFromAPI(metadata *map[string]openapiclient.Metadata) *map[string]interface{} {
@@ -713,6 +1019,25 @@ func (c *Collection) QueryWithOptions(ctx context.Context, queryOptions ...types
 	if len(b.Include) == 0 {
 		localInclude = []types.QueryEnum{types.IDocuments, types.IMetadatas, types.IDistances}
 	}
+	var nResults = b.NResults
+	var keepEmbeddings bool
+	if b.MMRFetchK > 0 {
+		if b.NResults < 1 {
+			return nil, fmt.Errorf("nResults must be set when using MMR")
+		}
+		// MMR needs the embeddings and distances of all candidates
+		keepEmbeddings = slices.Contains(localInclude, types.IEmbeddings)
+		localInclude = slices.Clone(localInclude)
+		for _, inc := range []types.QueryEnum{types.IEmbeddings, types.IDistances} {
+			if !slices.Contains(localInclude, inc) {
+				localInclude = append(localInclude, inc)
+			}
+		}
+		nResults = max(b.MMRFetchK, b.NResults)
+	}
+	if err := c.checkRequest(localInclude, b.WhereDocument); err != nil {
+		return nil, err
+	}
 	_includes := make([]openapiclient.IncludeInner, len(localInclude))
 	for i, v := range localInclude {
 		_v := string(v)
@@ -720,25 +1045,36 @@ func (c *Collection) QueryWithOptions(ctx context.Context, queryOptions ...types
 			String: &_v,
 		}
 	}
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
-	defer cancel()
+	ctx = c.operation(ctx, OperationQuery, len(b.QueryTexts)+len(b.QueryEmbeddings)+len(b.QueryURIs))
 	if len(b.QueryEmbeddings) == 0 && c.EmbeddingFunction == nil {
 		return nil, fmt.Errorf("embedding function is not set. Please configure the embedding function when you get or create the collection, or provide the query embeddings")
 	}
-	embds, embErr := c.EmbeddingFunction.EmbedDocuments(ctx, b.QueryTexts)
+	embds, embErr := c.embed(ctx, func(ctx context.Context) ([]*types.Embedding, error) {
+		return c.EmbeddingFunction.EmbedDocuments(ctx, b.QueryTexts)
+	})
+	if embErr != nil {
+		return nil, embErr
+	}
+	uriEmbds, embErr := c.embed(ctx, func(ctx context.Context) ([]*types.Embedding, error) {
+		return types.EmbedURIs(ctx, c.EmbeddingFunction, c.DataLoader, b.QueryURIs)
+	})
 	if embErr != nil {
 		return nil, embErr
 	}
+	if _, err := c.checkDimension(append(append(append([]*types.Embedding{}, b.QueryEmbeddings...), embds...), uriEmbds...)); err != nil {
+		return nil, err
+	}
 	var queryEmbeds = make([]openapiclient.EmbeddingsInner, 0)
 	queryEmbeds = append(queryEmbeds, types.ToAPIEmbeddings(b.QueryEmbeddings)...)
 	queryEmbeds = append(queryEmbeds, types.ToAPIEmbeddings(embds)...)
-	qr, _, err := c.ApiClient.DefaultApi.GetNearestNeighbors(ctx, c.ID).QueryEmbedding(openapiclient.QueryEmbedding{
+	queryEmbeds = append(queryEmbeds, types.ToAPIEmbeddings(uriEmbds)...)
+	qr, err := c.queryResult(ctx, openapiclient.QueryEmbedding{
 		Where:           b.Where,
 		WhereDocument:   b.WhereDocument,
-		NResults:        &b.NResults,
+		NResults:        &nResults,
 		Include:         _includes,
 		QueryEmbeddings: queryEmbeds,
-	}).Execute()
+	})
 
 	if err != nil {
 		return nil, err
@@ -749,16 +1085,26 @@ func (c *Collection) QueryWithOptions(ctx context.Context, queryOptions ...types
 		Ids:                           qr.Ids,
 		Metadatas:                     qr.Metadatas,
 		Distances:                     qr.Distances,
+		Embeddings:                    apiEmbeddingsMatrixToEmbeddings(qr.Embeddings),
 		QueryTexts:                    b.QueryTexts,
 		QueryEmbeddings:               b.QueryEmbeddings,
 		QueryTextsGeneratedEmbeddings: embds,
+		Uris:                          qr.Uris,
+		QueryURIs:                     b.QueryURIs,
+		QueryURIsGeneratedEmbeddings:  uriEmbds,
+	}
+	if b.MMRFetchK > 0 {
+		if err := c.applyMMR(&qresults, b.NResults, b.MMRLambda, keepEmbeddings); err != nil {
+			return nil, err
+		}
 	}
 	return &qresults, nil
 }
 func (c *Collection) Count(ctx context.Context) (int32, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.operation(ctx, OperationCount, 0)
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Read)
 	defer cancel()
-	req := c.ApiClient.DefaultApi.Count(ctx, c.ID)
+	req := c.ApiClient.DefaultApi.Count(c.scoped(ctx), c.ID)
 	cd, _, err := req.Execute()
 
 	if err != nil {
@@ -769,25 +1115,41 @@ func (c *Collection) Count(ctx context.Context) (int32, error) {
 }
 
 func (c *Collection) Update(ctx context.Context, newName string, newMetadata *map[string]interface{}) (*Collection, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	ctx = c.operation(ctx, OperationUpdateCollection, 0)
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Write)
 	defer cancel()
+	c.dimensionMu.Lock()
+	defer c.dimensionMu.Unlock()
 	_newMetadata := make(map[string]interface{})
 	if newMetadata != nil {
-		_newMetadata = *newMetadata
+		_newMetadata = copyMap(*newMetadata)
+	}
+	// the server replaces the whole metadata, keep the recorded embedding dimension unless a new one is set
+	if dim, ok := c.Dimension(); ok {
+		if _, set := _newMetadata[types.EmbeddingDimension]; !set {
+			_newMetadata[types.EmbeddingDimension] = dim
+		}
 	}
-	_, _, err := c.ApiClient.DefaultApi.UpdateCollection(ctx, c.ID).UpdateCollection(openapiclient.UpdateCollection{NewName: &newName, NewMetadata: _newMetadata}).Execute()
+	_, _, err := c.ApiClient.DefaultApi.UpdateCollection(c.scoped(ctx), c.ID).UpdateCollection(openapiclient.UpdateCollection{NewName: &newName, NewMetadata: _newMetadata}).Execute()
 	if err != nil {
 		return c, err
 	}
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
 	c.Name = newName
 	c.Metadata = _newMetadata
 	return c, nil
 }
 
 func (c *Collection) Delete(ctx context.Context, ids []string, where map[string]interface{}, whereDocuments map[string]interface{}) ([]string, error) {
-	ctx, cancel := context.WithTimeout(ctx, types.DefaultTimeout)
+	if err := c.checkWhereDocument(whereDocuments); err != nil {
+		return nil, err
+	}
+	ctx = c.operation(ctx, OperationDelete, len(ids))
+	ctx, cancel := withOperationTimeout(ctx, c.timeouts.Write)
 	defer cancel()
-	dr, _, err := c.ApiClient.DefaultApi.Delete(ctx, c.ID).DeleteEmbedding(openapiclient.DeleteEmbedding{Where: where, WhereDocument: whereDocuments, Ids: ids}).Execute()
+	defer c.invalidateResultCache()
+	dr, _, err := c.ApiClient.DefaultApi.Delete(c.scoped(ctx), c.ID).DeleteEmbedding(openapiclient.DeleteEmbedding{Where: where, WhereDocument: whereDocuments, Ids: ids}).Execute()
 	if err != nil {
 		return nil, err
 	}
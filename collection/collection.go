@@ -12,6 +12,7 @@ type Builder struct {
 	Database          string
 	Name              string
 	Metadata          map[string]interface{}
+	Configuration     map[string]interface{}
 	CreateIfNotExist  bool
 	EmbeddingFunction types.EmbeddingFunction
 	IDGenerator       types.IDGenerator
@@ -141,6 +142,20 @@ func WithMetadata(key string, value interface{}) Option {
 	}
 }
 
+// WithConfiguration adds collection configuration, e.g. {"hnsw": {"space": "cosine", "ef_search": 100}} for Chroma servers
+// supporting collection configuration. If a key already exists, the value is overwritten.
+func WithConfiguration(configuration map[string]interface{}) Option {
+	return func(b *Builder) error {
+		if b.Configuration == nil {
+			b.Configuration = make(map[string]interface{})
+		}
+		for k, v := range configuration {
+			b.Configuration[k] = v
+		}
+		return nil
+	}
+}
+
 func WithTenant(tenant string) Option {
 	return func(c *Builder) error {
 		if tenant == "" {
@@ -160,3 +175,14 @@ func WithDatabase(database string) Option {
 		return nil
 	}
 }
+
+// WithEmbeddingDimension records the expected embedding dimension in the collection metadata. Embeddings of a different dimension are
+// rejected client-side.
+func WithEmbeddingDimension(dimension int32) Option {
+	return func(b *Builder) error {
+		if dimension < 1 {
+			return fmt.Errorf("dimension must be greater than 0")
+		}
+		return WithMetadata(types.EmbeddingDimension, dimension)(b)
+	}
+}
@@ -114,4 +114,11 @@ func TestCollectionBuilder(t *testing.T) {
 		require.NoError(t, err, "Unexpected error: %v", err)
 		require.Equal(t, int32(10), b.Metadata[types.HNSWSearchEF])
 	})
+	t.Run("With Embedding Dimension", func(t *testing.T) {
+		b := &Builder{}
+		err := WithEmbeddingDimension(384)(b)
+		require.NoError(t, err, "Unexpected error: %v", err)
+		require.Equal(t, int32(384), b.Metadata[types.EmbeddingDimension])
+		require.Error(t, WithEmbeddingDimension(0)(b))
+	})
 }
@@ -0,0 +1,82 @@
+package chromago
+
+import (
+	"context"
+	"fmt"
+
+	openapiclient "github.com/szirtesitidom/chroma-go/swagger"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// EnableDimensionGuard makes the collection record the embedding dimension in its metadata (types.EmbeddingDimension) on the first insert.
+// Collections that have a recorded dimension always reject embeddings of a different dimension before calling the API.
+func (c *Collection) EnableDimensionGuard() *Collection {
+	c.dimensionGuard = true
+	return c
+}
+
+// Dimension returns the embedding dimension recorded in the collection metadata.
+func (c *Collection) Dimension() (int, bool) {
+	v, ok := toFloat64(c.metadataValue(types.EmbeddingDimension))
+	if !ok || v < 1 {
+		return 0, false
+	}
+	return int(v), true
+}
+
+// checkDimension validates that the embeddings have the same dimension as each other and as the dimension recorded in the collection metadata.
+// It returns the dimension of the embeddings, or 0 if there are none.
+func (c *Collection) checkDimension(embeddings []*types.Embedding) (int, error) {
+	if len(embeddings) == 0 {
+		return 0, nil
+	}
+	if err := types.CheckDimensions(embeddings...); err != nil {
+		return 0, fmt.Errorf("collection %s: %w", c.Name, err)
+	}
+	dim := embeddings[0].Len()
+	if expected, ok := c.Dimension(); ok && dim != expected {
+		return 0, fmt.Errorf("collection %s: %w", c.Name, &types.DimensionMismatchError{Expected: expected, Actual: dim})
+	}
+	return dim, nil
+}
+
+// metadataValue returns the value of the key in the collection metadata.
+func (c *Collection) metadataValue(key string) interface{} {
+	c.metadataMu.RLock()
+	defer c.metadataMu.RUnlock()
+	return c.Metadata[key]
+}
+
+// recordDimension stores the dimension in the collection metadata if the dimension guard is enabled and no dimension is recorded yet.
+// It is called after successful writes, so a failure is only logged and the dimension is recorded by the next write.
+func (c *Collection) recordDimension(ctx context.Context, dim int) {
+	if !c.dimensionGuard || dim == 0 {
+		return
+	}
+	c.dimensionMu.Lock()
+	defer c.dimensionMu.Unlock()
+	if _, ok := c.Dimension(); ok {
+		return
+	}
+	// the server replaces the whole metadata, so the recorded dimension is merged into the current metadata
+	c.metadataMu.RLock()
+	metadata := copyMap(c.Metadata)
+	c.metadataMu.RUnlock()
+	metadata[types.EmbeddingDimension] = dim
+	ctx = c.operation(ctx, OperationUpdateCollection, 0)
+	update := openapiclient.UpdateCollection{NewMetadata: metadata}
+	_, _, err := c.ApiClient.DefaultApi.UpdateCollection(c.scoped(ctx), c.ID).UpdateCollection(update).Execute()
+	if err != nil {
+		logger := c.logger
+		if logger == nil {
+			logger = types.DiscardLogger()
+		}
+		logger.WarnContext(ctx, "failed to record embedding dimension", "collection", c.Name, "dimension", dim, "error", err)
+		return
+	}
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	metadata = copyMap(c.Metadata)
+	metadata[types.EmbeddingDimension] = dim
+	c.Metadata = metadata
+}
@@ -0,0 +1,347 @@
+package chromago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultHealthCheckInterval is the interval of the background heartbeats of a multi-endpoint client.
+	DefaultHealthCheckInterval = 10 * time.Second
+	// DefaultFailoverCooldown is how long a failed endpoint of a multi-endpoint client is skipped.
+	DefaultFailoverCooldown = 30 * time.Second
+)
+
+// WithEndpoints enables the multi-endpoint mode: reads are sent round-robin to the healthy endpoints and writes only to the
+// primary, the first base URL. Endpoints failing with connection errors, or failing their background heartbeat, are skipped
+// by reads for the failover cooldown. The option replaces WithBasePath. Call Close to stop the heartbeats.
+func WithEndpoints(baseURLs ...string) ClientOption {
+	return func(c *Client) error {
+		if len(baseURLs) == 0 {
+			return fmt.Errorf("at least one endpoint is required")
+		}
+		for _, baseURL := range baseURLs {
+			if _, err := url.ParseRequestURI(baseURL); err != nil {
+				return fmt.Errorf("invalid endpoint URL %q: %s", baseURL, err)
+			}
+		}
+		c.endpointURLs = append([]string(nil), baseURLs...)
+		c.BasePath = baseURLs[0]
+		return nil
+	}
+}
+
+// WithHealthCheckInterval sets the interval of the heartbeats of the endpoints of WithEndpoints, DefaultHealthCheckInterval by
+// default. A heartbeat fails if the endpoint cannot be reached or answers with a 5xx status within the interval.
+func WithHealthCheckInterval(interval time.Duration) ClientOption {
+	return func(c *Client) error {
+		if interval <= 0 {
+			return fmt.Errorf("health check interval must be positive")
+		}
+		c.healthInterval = interval
+		return nil
+	}
+}
+
+// WithFailoverCooldown sets how long an endpoint of WithEndpoints is skipped after a connection error or a failed heartbeat,
+// DefaultFailoverCooldown by default. Zero retries failed endpoints right away.
+func WithFailoverCooldown(cooldown time.Duration) ClientOption {
+	return func(c *Client) error {
+		if cooldown < 0 {
+			return fmt.Errorf("failover cooldown must not be negative")
+		}
+		c.failoverCooldown = cooldown
+		return nil
+	}
+}
+
+// EndpointHealth is the health state of an endpoint of a multi-endpoint client.
+type EndpointHealth struct {
+	BasePath string
+	Primary  bool
+	// Healthy is false during the failover cooldown after a connection error or a failed heartbeat.
+	Healthy bool
+	// LastHeartbeat is the time of the last heartbeat, successful or not. It is zero before the first heartbeat.
+	LastHeartbeat time.Time
+	// LastError is the error of the last failed request or heartbeat, nil once a heartbeat succeeds again.
+	LastError error
+	// RetryAt is the end of the failover cooldown of an unhealthy endpoint.
+	RetryAt time.Time
+}
+
+// EndpointHealth returns the health state of the endpoints of WithEndpoints, the primary first, e.g. for readiness probes. It
+// returns nil for clients with a single base path.
+func (c *Client) EndpointHealth() []EndpointHealth {
+	if c.endpoints == nil {
+		return nil
+	}
+	health := make([]EndpointHealth, len(c.endpoints.endpoints))
+	now := time.Now()
+	for i, e := range c.endpoints.endpoints {
+		health[i] = e.health(now)
+	}
+	return health
+}
+
+type endpoint struct {
+	baseURL *url.URL
+	primary bool
+
+	mu            sync.Mutex
+	lastHeartbeat time.Time
+	lastError     error
+	downUntil     time.Time
+}
+
+func (e *endpoint) health(now time.Time) EndpointHealth {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	h := EndpointHealth{
+		BasePath:      e.baseURL.String(),
+		Primary:       e.primary,
+		Healthy:       !now.Before(e.downUntil),
+		LastHeartbeat: e.lastHeartbeat,
+		LastError:     e.lastError,
+	}
+	if !h.Healthy {
+		h.RetryAt = e.downUntil
+	}
+	return h
+}
+
+func (e *endpoint) healthy(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !now.Before(e.downUntil)
+}
+
+// resolve returns the URL of the request on the endpoint, u is relative to base.
+func (e *endpoint) resolve(base *url.URL, u *url.URL) *url.URL {
+	resolved := *u
+	resolved.Scheme = e.baseURL.Scheme
+	resolved.Host = e.baseURL.Host
+	resolved.Path = strings.TrimSuffix(e.baseURL.Path, "/") + strings.TrimPrefix(u.Path, strings.TrimSuffix(base.Path, "/"))
+	if u.RawPath != "" {
+		resolved.RawPath = strings.TrimSuffix(e.baseURL.EscapedPath(), "/") + strings.TrimPrefix(u.RawPath, strings.TrimSuffix(base.EscapedPath(), "/"))
+	}
+	return &resolved
+}
+
+// endpointPool routes the requests of a multi-endpoint client and checks the health of its endpoints.
+type endpointPool struct {
+	client    *Client
+	base      *url.URL
+	endpoints []*endpoint
+	next      atomic.Uint64
+	interval  time.Duration
+	cooldown  time.Duration
+	transport http.RoundTripper // sends requests without routing, used for heartbeats
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func newEndpointPool(c *Client) (*endpointPool, error) {
+	base, err := url.Parse(c.BasePath)
+	if err != nil {
+		return nil, err
+	}
+	p := &endpointPool{
+		client:   c,
+		base:     base,
+		interval: c.healthInterval,
+		cooldown: c.failoverCooldown,
+	}
+	for i, baseURL := range c.endpointURLs {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		p.endpoints = append(p.endpoints, &endpoint{baseURL: u, primary: i == 0})
+	}
+	return p, nil
+}
+
+// start starts the heartbeats, sent with the HTTP client.
+func (p *endpointPool) start(client *http.Client) {
+	p.transport = client.Transport
+	if p.transport == nil {
+		p.transport = http.DefaultTransport
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.checkAll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stop stops the heartbeats and waits for running heartbeats.
+func (p *endpointPool) stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+func (p *endpointPool) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			p.check(ctx, e)
+		}(e)
+	}
+	wg.Wait()
+}
+
+// check sends a heartbeat to the endpoint. Any response below 500 means that the server is up, e.g. the v1 heartbeat of a
+// server that only has the v2 API answers 410.
+func (p *endpointPool) check(ctx context.Context, e *endpoint) {
+	ctx, cancel := context.WithTimeout(ctx, p.interval)
+	defer cancel()
+	path := "/api/v1/heartbeat"
+	if p.client.useV2.Load() {
+		path = "/api/v2/heartbeat"
+	}
+	u := e.resolve(p.base, p.base.JoinPath(path))
+	req, err := http.NewRequestWithContext(withOperation(ctx, Operation{Type: OperationHeartbeat}), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return
+	}
+	resp, err := p.transport.RoundTrip(req)
+	if err == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			err = fmt.Errorf("heartbeat failed with status %s", resp.Status)
+		}
+	}
+	if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		return // stopped
+	}
+	e.mu.Lock()
+	e.lastHeartbeat = time.Now()
+	recovered := err == nil && e.lastError != nil
+	if err == nil {
+		e.lastError = nil
+	}
+	e.mu.Unlock()
+	if err != nil {
+		p.markDown(ctx, e, err)
+	} else if recovered {
+		p.client.log().InfoContext(ctx, "endpoint reachable again", "base_path", e.baseURL.String())
+	}
+}
+
+// markDown skips the endpoint for the failover cooldown.
+func (p *endpointPool) markDown(ctx context.Context, e *endpoint, err error) {
+	now := time.Now()
+	e.mu.Lock()
+	wasHealthy := !now.Before(e.downUntil)
+	e.lastError = err
+	e.downUntil = now.Add(p.cooldown)
+	retryAt := e.downUntil
+	e.mu.Unlock()
+	if wasHealthy {
+		p.client.log().WarnContext(ctx, "endpoint unavailable", "base_path", e.baseURL.String(), "retry_at", retryAt, "error", err)
+	}
+}
+
+// candidates returns the endpoints to try, in order: reads start at the next healthy endpoint in round-robin order and try
+// unhealthy endpoints last, writes only go to the primary.
+func (p *endpointPool) candidates(read bool) []*endpoint {
+	if !read {
+		return p.endpoints[:1]
+	}
+	now := time.Now()
+	n := len(p.endpoints)
+	start := int(p.next.Add(1)-1) % n
+	healthy := make([]*endpoint, 0, n)
+	var unhealthy []*endpoint
+	for i := 0; i < n; i++ {
+		e := p.endpoints[(start+i)%n]
+		if e.healthy(now) {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// RoundTrip sends the request to the first endpoint of the candidates that can be reached. Reads are retried on the next
+// endpoint after any connection error. Writes are only sent to the primary and fail with its connection error.
+func (p *endpointPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation, _ := OperationFromContext(req.Context())
+	read := isReadOperation(operation.Type)
+	var lastErr error
+	for i, e := range p.candidates(read) {
+		attempt := req.Clone(req.Context())
+		attempt.URL = e.resolve(p.base, req.URL)
+		attempt.Host = ""
+		if i > 0 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, lastErr
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, lastErr
+			}
+			attempt.Body = body
+		}
+		resp, err := p.transport.RoundTrip(attempt)
+		if err == nil {
+			return resp, nil
+		}
+		if req.Context().Err() != nil || !isConnectionError(err) {
+			return nil, err
+		}
+		p.markDown(req.Context(), e, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// isReadOperation reports if the operation does not modify data and can be sent to any endpoint.
+func isReadOperation(operationType OperationType) bool {
+	switch operationType {
+	case OperationHeartbeat, OperationVersion, OperationGetIdentity, OperationGetTenant, OperationGetDatabase,
+		OperationGetCollection, OperationListCollections, OperationCountCollections, OperationGet, OperationQuery, OperationCount:
+		return true
+	default:
+		return false
+	}
+}
+
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withEndpoints returns a copy of the HTTP client that routes requests to the endpoints of the pool and starts the heartbeats.
+func withEndpoints(client *http.Client, p *endpointPool) *http.Client {
+	p.start(client)
+	routed := *client
+	routed.Transport = p
+	return &routed
+}
@@ -14,6 +14,7 @@ require (
 	github.com/testcontainers/testcontainers-go v0.29.1
 	github.com/testcontainers/testcontainers-go/modules/chroma v0.29.1
 	github.com/testcontainers/testcontainers-go/modules/ollama v0.29.1
+	golang.org/x/sync v0.7.0
 	google.golang.org/api v0.178.0
 )
 
@@ -85,7 +86,6 @@ require (
 	golang.org/x/mod v0.16.0 // indirect
 	golang.org/x/net v0.25.0 // indirect
 	golang.org/x/oauth2 v0.20.0 // indirect
-	golang.org/x/sync v0.7.0 // indirect
 	golang.org/x/sys v0.20.0 // indirect
 	golang.org/x/text v0.15.0 // indirect
 	golang.org/x/time v0.5.0 // indirect
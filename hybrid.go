@@ -0,0 +1,345 @@
+package chromago
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/szirtesitidom/chroma-go/pkg/bm25"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+type FusionMethod string
+
+const (
+	// FusionAlpha combines min-max normalized vector and BM25 scores as alpha*vector + (1-alpha)*bm25.
+	FusionAlpha FusionMethod = "alpha"
+	// FusionRRF combines the vector and BM25 rankings with reciprocal rank fusion.
+	FusionRRF FusionMethod = "rrf"
+
+	DefaultHybridAlpha = 0.5
+	DefaultRRFK        = 60
+)
+
+type hybridSearch struct {
+	queryOptions  []types.CollectionQueryOption
+	fetchK        int32
+	candidatePool int32
+	fusion        FusionMethod
+	alpha         float64
+	rrfK          int
+	bm25Options   []bm25.Option
+	sparseEf      types.SparseEmbeddingFunction
+}
+
+type HybridSearchOption func(*hybridSearch) error
+
+// WithHybridQueryOptions sets additional options for the vector query, e.g. where and where document filters or include.
+// The same where filters are applied to the candidate pool. Query embeddings are not supported.
+func WithHybridQueryOptions(options ...types.CollectionQueryOption) HybridSearchOption {
+	return func(h *hybridSearch) error {
+		h.queryOptions = append(h.queryOptions, options...)
+		return nil
+	}
+}
+
+// WithHybridFetchK sets the number of vector results fetched per query before fusion. Defaults to 4 * nResults.
+func WithHybridFetchK(fetchK int32) HybridSearchOption {
+	return func(h *hybridSearch) error {
+		if fetchK < 1 {
+			return fmt.Errorf("fetchK must be greater than 0")
+		}
+		h.fetchK = fetchK
+		return nil
+	}
+}
+
+// WithHybridCandidatePool scores up to limit documents returned by Get (with the same where filters) in addition to the vector results,
+// so that keyword matches outside the vector top-k can be returned. Candidates found only by keyword search have no distance, see
+// QueryResults.VectorDistances.
+func WithHybridCandidatePool(limit int32) HybridSearchOption {
+	return func(h *hybridSearch) error {
+		if limit < 1 {
+			return fmt.Errorf("candidate pool limit must be greater than 0")
+		}
+		h.candidatePool = limit
+		return nil
+	}
+}
+
+// WithHybridAlpha fuses the scores with FusionAlpha. An alpha of 1 is a pure vector search, 0 a pure keyword search.
+func WithHybridAlpha(alpha float64) HybridSearchOption {
+	return func(h *hybridSearch) error {
+		if alpha < 0 || alpha > 1 {
+			return fmt.Errorf("alpha must be between 0 and 1")
+		}
+		h.fusion = FusionAlpha
+		h.alpha = alpha
+		return nil
+	}
+}
+
+// WithHybridRRF fuses the rankings with FusionRRF using the given k constant (60 is a common choice).
+func WithHybridRRF(k int) HybridSearchOption {
+	return func(h *hybridSearch) error {
+		if k < 1 {
+			return fmt.Errorf("rrf k must be greater than 0")
+		}
+		h.fusion = FusionRRF
+		h.rrfK = k
+		return nil
+	}
+}
+
+// WithHybridBM25Options sets the options of the BM25 index built over the candidates.
+func WithHybridBM25Options(options ...bm25.Option) HybridSearchOption {
+	return func(h *hybridSearch) error {
+		h.bm25Options = append(h.bm25Options, options...)
+		return nil
+	}
+}
+
+// WithHybridSparseEmbeddingFunction scores the candidates with the dot product of sparse query and document embeddings instead of
+// a BM25 index built over the candidates, e.g. with a bm25.SparseEncoder trained on the whole collection.
+func WithHybridSparseEmbeddingFunction(ef types.SparseEmbeddingFunction) HybridSearchOption {
+	return func(h *hybridSearch) error {
+		if ef == nil {
+			return fmt.Errorf("sparse embedding function must not be nil")
+		}
+		h.sparseEf = ef
+		return nil
+	}
+}
+
+// hybridCandidate is a document that is scored during fusion.
+type hybridCandidate struct {
+	id        string
+	document  string
+	metadata  map[string]interface{}
+	embedding *types.Embedding
+	distance  *float32 // nil for candidates found only by keyword search
+	score     float64
+}
+
+// HybridSearch runs a vector query for each query text, scores the results (and optionally a wider candidate pool) with a BM25 index
+// built over the candidate documents and fuses both rankings. The fused score is returned in QueryResults.Scores, higher is better.
+// The distances are returned in QueryResults.VectorDistances, and in QueryResults.Distances for the queries whose results all have
+// a distance.
+func (c *Collection) HybridSearch(ctx context.Context, queryTexts []string, nResults int32, options ...HybridSearchOption) (*QueryResults, error) {
+	if len(queryTexts) == 0 {
+		return nil, fmt.Errorf("queryTexts must not be empty")
+	}
+	if nResults < 1 {
+		return nil, fmt.Errorf("nResults must be greater than 0")
+	}
+	h := &hybridSearch{
+		fusion: FusionAlpha,
+		alpha:  DefaultHybridAlpha,
+		rrfK:   DefaultRRFK,
+	}
+	for _, opt := range options {
+		if err := opt(h); err != nil {
+			return nil, err
+		}
+	}
+	if h.fetchK == 0 {
+		h.fetchK = 4 * nResults
+	}
+	if h.fetchK < nResults {
+		h.fetchK = nResults
+	}
+	b := &types.CollectionQueryBuilder{}
+	for _, opt := range h.queryOptions {
+		if err := opt(b); err != nil {
+			return nil, err
+		}
+	}
+	if len(b.QueryEmbeddings) > 0 || len(b.QueryTexts) > 0 {
+		return nil, fmt.Errorf("query texts and embeddings must be passed as the queryTexts argument of hybrid search")
+	}
+	include := []types.QueryEnum{types.IDocuments, types.IMetadatas, types.IDistances}
+	withEmbeddings := false
+	for _, inc := range b.Include {
+		if inc == types.IEmbeddings {
+			withEmbeddings = true
+			include = append(include, types.IEmbeddings)
+		}
+	}
+	queryOptions := append(append([]types.CollectionQueryOption{}, h.queryOptions...),
+		types.WithQueryTexts(queryTexts),
+		types.WithNResults(h.fetchK),
+		types.WithInclude(include...),
+	)
+	qr, err := c.QueryWithOptions(ctx, queryOptions...)
+	if err != nil {
+		return nil, err
+	}
+	var pool *GetResults
+	if h.candidatePool > 0 {
+		getInclude := []types.QueryEnum{types.IDocuments, types.IMetadatas}
+		if withEmbeddings {
+			getInclude = append(getInclude, types.IEmbeddings)
+		}
+		getOptions := []types.CollectionQueryOption{types.WithLimit(h.candidatePool), types.WithInclude(getInclude...)}
+		if len(b.Where) > 0 {
+			getOptions = append(getOptions, types.WithWhereMap(b.Where))
+		}
+		if len(b.WhereDocument) > 0 {
+			getOptions = append(getOptions, types.WithWhereDocumentMap(b.WhereDocument))
+		}
+		pool, err = c.GetWithOptions(ctx, getOptions...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := &QueryResults{
+		Ids:                           make([][]string, len(queryTexts)),
+		Documents:                     make([][]string, len(queryTexts)),
+		Metadatas:                     make([][]map[string]interface{}, len(queryTexts)),
+		Distances:                     make([][]float32, len(queryTexts)),
+		VectorDistances:               make([][]*float32, len(queryTexts)),
+		Scores:                        make([][]float32, len(queryTexts)),
+		QueryTexts:                    qr.QueryTexts,
+		QueryTextsGeneratedEmbeddings: qr.QueryTextsGeneratedEmbeddings,
+	}
+	if withEmbeddings {
+		results.Embeddings = make([][]*types.Embedding, len(queryTexts))
+	}
+	for q, queryText := range queryTexts {
+		candidates := hybridCandidates(qr, q, pool)
+		if err := h.fuse(ctx, queryText, candidates); err != nil {
+			return nil, err
+		}
+		if len(candidates) > int(nResults) {
+			candidates = candidates[:nResults]
+		}
+		for _, candidate := range candidates {
+			results.Ids[q] = append(results.Ids[q], candidate.id)
+			results.Documents[q] = append(results.Documents[q], candidate.document)
+			results.Metadatas[q] = append(results.Metadatas[q], candidate.metadata)
+			results.VectorDistances[q] = append(results.VectorDistances[q], candidate.distance)
+			results.Scores[q] = append(results.Scores[q], float32(candidate.score))
+			if withEmbeddings {
+				results.Embeddings[q] = append(results.Embeddings[q], candidate.embedding)
+			}
+		}
+		// Distances cannot represent a missing distance, so it is only set if all results of the query have one
+		distances := make([]float32, 0, len(candidates))
+		for _, candidate := range candidates {
+			if candidate.distance == nil {
+				distances = nil
+				break
+			}
+			distances = append(distances, *candidate.distance)
+		}
+		results.Distances[q] = distances
+	}
+	return results, nil
+}
+
+// hybridCandidates returns the vector results of the query at queryIndex in distance order, followed by the pool documents that were not
+// among the vector results.
+func hybridCandidates(qr *QueryResults, queryIndex int, pool *GetResults) []*hybridCandidate {
+	candidates := make([]*hybridCandidate, 0)
+	seen := make(map[string]bool)
+	for _, row := range qr.Rows(queryIndex) {
+		seen[row.ID] = true
+		candidates = append(candidates, &hybridCandidate{id: row.ID, document: row.Document, metadata: row.Metadata, embedding: row.Embedding, distance: row.Distance})
+	}
+	if pool != nil {
+		for _, row := range pool.Rows() {
+			if seen[row.ID] {
+				continue
+			}
+			seen[row.ID] = true
+			candidates = append(candidates, &hybridCandidate{id: row.ID, document: row.Document, metadata: row.Metadata, embedding: row.Embedding})
+		}
+	}
+	return candidates
+}
+
+// fuse scores the candidates and sorts them by descending fused score.
+func (h *hybridSearch) fuse(ctx context.Context, queryText string, candidates []*hybridCandidate) error {
+	keywordScores, err := h.keywordScores(ctx, queryText, candidates)
+	if err != nil {
+		return err
+	}
+	switch h.fusion {
+	case FusionRRF:
+		// vector candidates are already in distance order
+		for rank, candidate := range candidates {
+			if candidate.distance != nil {
+				candidate.score = 1 / float64(h.rrfK+rank+1)
+			}
+		}
+		order := make([]int, len(candidates))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return keywordScores[order[a]] > keywordScores[order[b]] })
+		for rank, i := range order {
+			if keywordScores[i] <= 0 {
+				break
+			}
+			candidates[i].score += 1 / float64(h.rrfK+rank+1)
+		}
+	default:
+		minDistance, maxDistance := math.Inf(1), math.Inf(-1)
+		maxKeyword := 0.0
+		for i, candidate := range candidates {
+			if candidate.distance != nil {
+				minDistance = math.Min(minDistance, float64(*candidate.distance))
+				maxDistance = math.Max(maxDistance, float64(*candidate.distance))
+			}
+			maxKeyword = math.Max(maxKeyword, keywordScores[i])
+		}
+		for i, candidate := range candidates {
+			var vectorScore, keywordScore float64
+			if candidate.distance != nil {
+				vectorScore = 1
+				if maxDistance > minDistance {
+					vectorScore = (maxDistance - float64(*candidate.distance)) / (maxDistance - minDistance)
+				}
+			}
+			if maxKeyword > 0 {
+				keywordScore = keywordScores[i] / maxKeyword
+			}
+			candidate.score = h.alpha*vectorScore + (1-h.alpha)*keywordScore
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+	return nil
+}
+
+// keywordScores returns the keyword score of each candidate for the query.
+func (h *hybridSearch) keywordScores(ctx context.Context, queryText string, candidates []*hybridCandidate) ([]float64, error) {
+	documents := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		documents[i] = candidate.document
+	}
+	if h.sparseEf == nil {
+		index, err := bm25.NewIndex(documents, h.bm25Options...)
+		if err != nil {
+			return nil, err
+		}
+		return index.Score(queryText), nil
+	}
+	query, err := h.sparseEf.EmbedQuerySparse(ctx, queryText)
+	if err != nil {
+		return nil, err
+	}
+	embeddings, err := h.sparseEf.EmbedDocumentsSparse(ctx, documents)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(documents) {
+		return nil, fmt.Errorf("sparse embedding function returned %d embeddings for %d documents", len(embeddings), len(documents))
+	}
+	index, err := types.NewSparseIndex(embeddings)
+	if err != nil {
+		return nil, err
+	}
+	return index.Scores(query), nil
+}
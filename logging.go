@@ -0,0 +1,198 @@
+package chromago
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// DefaultRedactedFields are the JSON fields replaced in the bodies logged by LoggingMiddleware, unless WithRedactedFields is used.
+var DefaultRedactedFields = []string{"documents", "where_document"}
+
+// DefaultMaxLoggedBodySize is the maximum number of bytes of a body logged by LoggingMiddleware.
+const DefaultMaxLoggedBodySize = 4096
+
+const redactedValue = "[REDACTED]"
+
+// WithLogger sets the logger of client events, e.g. the pre-flight checks, and of WithDebug. Nothing is logged by default.
+// Use LoggingMiddleware to log every request.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// log returns the logger of the client, or a logger that drops all records.
+func (c *Client) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return types.DiscardLogger()
+}
+
+type loggingMiddleware struct {
+	logger      *slog.Logger
+	level       slog.Level
+	logBodies   bool
+	maxBodySize int
+	redacted    map[string]bool
+}
+
+type LoggingOption func(*loggingMiddleware)
+
+// WithLogLevel sets the level of successful requests, slog.LevelDebug by default. Failed requests are logged with at least
+// slog.LevelWarn, transport errors with slog.LevelError.
+func WithLogLevel(level slog.Level) LoggingOption {
+	return func(l *loggingMiddleware) {
+		l.level = level
+	}
+}
+
+// WithLogBodies logs the request and response bodies, with the redacted fields replaced.
+func WithLogBodies() LoggingOption {
+	return func(l *loggingMiddleware) {
+		l.logBodies = true
+	}
+}
+
+// WithRedactedFields replaces DefaultRedactedFields, e.g. to redact metadatas in addition to documents.
+func WithRedactedFields(fields ...string) LoggingOption {
+	return func(l *loggingMiddleware) {
+		l.redacted = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			l.redacted[field] = true
+		}
+	}
+}
+
+// WithMaxLoggedBodySize truncates logged bodies to size bytes, DefaultMaxLoggedBodySize by default. Zero logs whole bodies.
+func WithMaxLoggedBodySize(size int) LoggingOption {
+	return func(l *loggingMiddleware) {
+		l.maxBodySize = max(size, 0)
+	}
+}
+
+// LoggingMiddleware logs every request with method, path, status, duration, the operation and the request ID (see
+// RequestIDMiddleware). Credentials are never logged. Bodies are only logged with WithLogBodies.
+func LoggingMiddleware(logger *slog.Logger, options ...LoggingOption) Middleware {
+	l := &loggingMiddleware{
+		logger:      logger,
+		level:       slog.LevelDebug,
+		maxBodySize: DefaultMaxLoggedBodySize,
+	}
+	WithRedactedFields(DefaultRedactedFields...)(l)
+	for _, option := range options {
+		option(l)
+	}
+	if l.logger == nil {
+		l.logger = slog.Default()
+	}
+	return l.middleware
+}
+
+func (l *loggingMiddleware) middleware(next Handler) Handler {
+	return func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		logBodies := l.logBodies && l.logger.Enabled(ctx, l.level)
+		var requestBody []byte
+		if logBodies && req.Body != nil && req.Body != http.NoBody {
+			var err error
+			if requestBody, err = io.ReadAll(req.Body); err != nil {
+				return nil, err
+			}
+			_ = req.Body.Close()
+			req.Body = io.NopCloser(bytes.NewReader(requestBody))
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(requestBody)), nil
+			}
+		}
+		start := time.Now()
+		resp, err := next(req)
+		attrs := []slog.Attr{
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.Path),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if operation, ok := OperationFromContext(ctx); ok {
+			attrs = append(attrs, slog.Group("operation",
+				slog.String("type", string(operation.Type)),
+				slog.String("collection", operation.Collection),
+				slog.String("collection_id", operation.CollectionID),
+				slog.String("tenant", operation.Tenant),
+				slog.String("database", operation.Database),
+				slog.Int("records", operation.Records),
+			))
+		}
+		if requestID := RequestIDFromContext(ctx); requestID != "" {
+			attrs = append(attrs, slog.String("request_id", requestID))
+		}
+		level := l.level
+		if logBodies && len(requestBody) > 0 {
+			attrs = append(attrs, slog.String("request_body", l.redact(requestBody)))
+		}
+		if err != nil {
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("error", err.Error()))
+		} else {
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			if resp.StatusCode >= http.StatusBadRequest {
+				level = max(level, slog.LevelWarn)
+			}
+			if logBodies && resp.Body != nil {
+				responseBody, readErr := io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+				if readErr != nil {
+					return resp, readErr
+				}
+				attrs = append(attrs, slog.String("response_body", l.redact(responseBody)))
+			}
+		}
+		l.logger.LogAttrs(ctx, level, "chroma request", attrs...)
+		return resp, err
+	}
+}
+
+// redact returns the body with the values of the redacted fields replaced, truncated to the maximum body size.
+func (l *loggingMiddleware) redact(body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	text := string(body)
+	if err := decoder.Decode(&value); err == nil {
+		if redacted, err := json.Marshal(l.redactValue(value)); err == nil {
+			text = string(redacted)
+		}
+	}
+	if l.maxBodySize > 0 && len(text) > l.maxBodySize {
+		text = strings.ToValidUTF8(text[:l.maxBodySize], "") + "...(truncated)"
+	}
+	return text
+}
+
+func (l *loggingMiddleware) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if l.redacted[key] {
+				if field != nil {
+					v[key] = redactedValue
+				}
+				continue
+			}
+			v[key] = l.redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = l.redactValue(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,164 @@
+package chromago
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Handler sends a request to the Chroma server.
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the handler of the client requests, e.g. to add headers, log or audit requests. The logical operation of
+// a request is available with OperationFromContext(req.Context()). Middlewares may modify the request, it is a copy of the
+// request of the caller, and must close the response body if they do not return the response.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware adds middlewares to the client. The first middleware sees the request first and the response last.
+// Middlewares see the request as sent to the server, but without the credentials added by WithAuth.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(c *Client) error {
+		for _, m := range middlewares {
+			if m == nil {
+				return fmt.Errorf("middleware cannot be nil")
+			}
+		}
+		c.middlewares = append(c.middlewares, middlewares...)
+		return nil
+	}
+}
+
+// middlewareRoundTripper sends requests through the middleware chain.
+type middlewareRoundTripper struct {
+	handler Handler
+}
+
+func (m *middlewareRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the request
+	return m.handler(req.Clone(req.Context()))
+}
+
+// withMiddlewares returns a copy of the HTTP client that sends requests through the middlewares.
+func withMiddlewares(client *http.Client, middlewares []Middleware) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	handler := Handler(next.RoundTrip)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	wrapped := *client
+	wrapped.Transport = &middlewareRoundTripper{handler: handler}
+	return &wrapped
+}
+
+// OperationType is the type of a logical client operation.
+type OperationType string
+
+const (
+	OperationHeartbeat        OperationType = "heartbeat"
+	OperationVersion          OperationType = "version"
+	OperationPreFlightChecks  OperationType = "pre_flight_checks"
+	OperationReset            OperationType = "reset"
+	OperationGetIdentity      OperationType = "get_identity"
+	OperationCreateTenant     OperationType = "create_tenant"
+	OperationGetTenant        OperationType = "get_tenant"
+	OperationCreateDatabase   OperationType = "create_database"
+	OperationGetDatabase      OperationType = "get_database"
+	OperationCreateCollection OperationType = "create_collection"
+	OperationGetCollection    OperationType = "get_collection"
+	OperationDeleteCollection OperationType = "delete_collection"
+	OperationListCollections  OperationType = "list_collections"
+	OperationCountCollections OperationType = "count_collections"
+	OperationUpdateCollection OperationType = "update_collection"
+	OperationAdd              OperationType = "add"
+	OperationUpsert           OperationType = "upsert"
+	OperationUpdate           OperationType = "update"
+	OperationGet              OperationType = "get"
+	OperationQuery            OperationType = "query"
+	OperationCount            OperationType = "count"
+	OperationDelete           OperationType = "delete"
+)
+
+// Operation describes the logical client operation of a request.
+type Operation struct {
+	Type OperationType
+	// Collection and CollectionID are set for collection operations. For operations on collections by name only Collection is set.
+	Collection   string
+	CollectionID string
+	Tenant       string
+	Database     string
+	// Records is the number of records of add, upsert, update, get (by ID) and delete (by ID) operations, and the number of
+	// queries of query operations.
+	Records int
+}
+
+type operationContextKey struct{}
+
+// withOperation adds the operation to the context of the requests of the operation.
+func withOperation(ctx context.Context, operation Operation) context.Context {
+	return context.WithValue(ctx, operationContextKey{}, operation)
+}
+
+// OperationFromContext returns the logical operation of a request.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	operation, ok := ctx.Value(operationContextKey{}).(Operation)
+	return operation, ok
+}
+
+// clientOperation returns the context with a client operation using the current tenant and database.
+func (c *Client) clientOperation(ctx context.Context, operationType OperationType, collection string) context.Context {
+	tenant, database := c.tenantAndDatabase()
+	return withOperation(ctx, Operation{Type: operationType, Collection: collection, Tenant: tenant, Database: database})
+}
+
+// operation returns the context with an operation of the collection.
+func (c *Collection) operation(ctx context.Context, operationType OperationType, records int) context.Context {
+	return withOperation(ctx, Operation{
+		Type:         operationType,
+		Collection:   c.Name,
+		CollectionID: c.ID,
+		Tenant:       c.Tenant,
+		Database:     c.Database,
+		Records:      records,
+	})
+}
+
+// DefaultRequestIDHeader is the header of RequestIDMiddleware.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+type requestIDContextKey struct{}
+
+// ContextWithRequestID sets the request ID sent by RequestIDMiddleware for the requests of operations called with the context.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID of the context, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// RequestIDMiddleware sends the request ID of the context (see ContextWithRequestID) in the header, DefaultRequestIDHeader if
+// header is empty. Requests without a request ID get a random one. The request ID is added to the request context, so
+// middlewares after this one, e.g. LoggingMiddleware, can log it.
+func RequestIDMiddleware(header string) Middleware {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			requestID := RequestIDFromContext(req.Context())
+			if requestID == "" {
+				requestID = uuid.NewString()
+				req = req.WithContext(ContextWithRequestID(req.Context(), requestID))
+			}
+			req.Header.Set(header, requestID)
+			return next(req)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package chromago
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// distanceFunction returns the distance function of the collection as stored in its hnsw:space metadata. Defaults to L2.
+func (c *Collection) distanceFunction() (types.DistanceFunction, error) {
+	space := c.metadataValue(types.HNSWSpace)
+	if space == nil {
+		return types.L2, nil
+	}
+	if df, ok := space.(types.DistanceFunction); ok {
+		return types.ToDistanceFunction(string(df))
+	}
+	str, ok := space.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid distance function: %v", space)
+	}
+	return types.ToDistanceFunction(str)
+}
+
+// selectMMR returns the indices of up to k candidates selected by maximal marginal relevance. relevance holds the distance of each candidate
+// to the query (lower is more relevant). Similarities are the negated distances.
+func selectMMR(df types.DistanceFunction, relevance []float32, candidates []*types.Embedding, k int, lambda float32) ([]int, error) {
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+	selected := make([]int, 0, k)
+	used := make([]bool, len(candidates))
+	// maxSimilarity holds the highest similarity of each candidate to any of the selected candidates
+	maxSimilarity := make([]float64, len(candidates))
+	for i := range maxSimilarity {
+		maxSimilarity[i] = math.Inf(-1)
+	}
+	for len(selected) < k {
+		best, bestScore := -1, math.Inf(-1)
+		for i := range candidates {
+			if used[i] {
+				continue
+			}
+			score := -float64(lambda) * float64(relevance[i])
+			if len(selected) > 0 {
+				score -= (1 - float64(lambda)) * maxSimilarity[i]
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		used[best] = true
+		selected = append(selected, best)
+		for i := range candidates {
+			if used[i] {
+				continue
+			}
+			d, err := candidates[i].Distance(candidates[best], df)
+			if err != nil {
+				return nil, err
+			}
+			maxSimilarity[i] = math.Max(maxSimilarity[i], -d)
+		}
+	}
+	return selected, nil
+}
+
+// applyMMR reduces each query's results to nResults diverse results. Embeddings are dropped from the results unless keepEmbeddings is set.
+func (c *Collection) applyMMR(results *QueryResults, nResults int32, lambda float32, keepEmbeddings bool) error {
+	df, err := c.distanceFunction()
+	if err != nil {
+		return err
+	}
+	for q := range results.Ids {
+		embeddings := valueAt(results.Embeddings, q)
+		distances := valueAt(results.Distances, q)
+		if len(embeddings) != len(results.Ids[q]) || len(distances) != len(results.Ids[q]) {
+			return fmt.Errorf("mmr requires embeddings and distances for all results")
+		}
+		if err := types.CheckDimensions(embeddings...); err != nil {
+			return fmt.Errorf("mmr requires embeddings for all results: %w", err)
+		}
+		selected, err := selectMMR(df, distances, embeddings, int(nResults), lambda)
+		if err != nil {
+			return err
+		}
+		results.Ids[q] = pick(results.Ids[q], selected)
+		results.Distances[q] = pick(distances, selected)
+		results.Embeddings[q] = pick(embeddings, selected)
+		if q < len(results.Documents) {
+			results.Documents[q] = pick(results.Documents[q], selected)
+		}
+		if q < len(results.Metadatas) {
+			results.Metadatas[q] = pick(results.Metadatas[q], selected)
+		}
+		if q < len(results.Uris) {
+			results.Uris[q] = pick(results.Uris[q], selected)
+		}
+	}
+	if !keepEmbeddings {
+		results.Embeddings = nil
+	}
+	return nil
+}
+
+// pick returns the elements of s at the given indices. Indices out of range yield zero values.
+func pick[E any](s []E, indices []int) []E {
+	if s == nil {
+		return nil
+	}
+	out := make([]E, len(indices))
+	for i, idx := range indices {
+		out[i] = valueAt(s, idx)
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package bm25
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultK1 = 1.2
+	DefaultB  = 0.75
+)
+
+// Tokenizer splits a text into terms.
+type Tokenizer func(text string) []string
+
+// DefaultTokenizer lowercases the text and splits it on any character that is not a letter or a digit.
+func DefaultTokenizer(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Option configures an Index or a SparseEncoder.
+type Option func(*config) error
+
+type config struct {
+	k1        float64
+	b         float64
+	tokenizer Tokenizer
+}
+
+func newConfig(opts []Option) (config, error) {
+	c := config{k1: DefaultK1, b: DefaultB, tokenizer: DefaultTokenizer}
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// WithK1 sets the term frequency saturation parameter. Defaults to 1.2.
+func WithK1(k1 float64) Option {
+	return func(i *config) error {
+		if k1 < 0 {
+			return fmt.Errorf("k1 must be greater than or equal to 0")
+		}
+		i.k1 = k1
+		return nil
+	}
+}
+
+// WithB sets the document length normalization parameter. Defaults to 0.75.
+func WithB(b float64) Option {
+	return func(i *config) error {
+		if b < 0 || b > 1 {
+			return fmt.Errorf("b must be between 0 and 1")
+		}
+		i.b = b
+		return nil
+	}
+}
+
+// WithTokenizer sets the tokenizer used for both documents and queries.
+func WithTokenizer(tokenizer Tokenizer) Option {
+	return func(i *config) error {
+		if tokenizer == nil {
+			return fmt.Errorf("tokenizer must not be nil")
+		}
+		i.tokenizer = tokenizer
+		return nil
+	}
+}
+
+// Index is an in-memory BM25 index over a fixed set of documents.
+type Index struct {
+	config
+	termFreqs    []map[string]int
+	docLengths   []int
+	avgDocLength float64
+	docFreqs     map[string]int
+}
+
+func NewIndex(documents []string, opts ...Option) (*Index, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	index := &Index{config: c, docFreqs: make(map[string]int)}
+	index.termFreqs = make([]map[string]int, len(documents))
+	index.docLengths = make([]int, len(documents))
+	var totalLength int
+	for i, document := range documents {
+		terms := index.tokenizer(document)
+		freqs := make(map[string]int, len(terms))
+		for _, term := range terms {
+			freqs[term]++
+		}
+		for term := range freqs {
+			index.docFreqs[term]++
+		}
+		index.termFreqs[i] = freqs
+		index.docLengths[i] = len(terms)
+		totalLength += len(terms)
+	}
+	if len(documents) > 0 {
+		index.avgDocLength = float64(totalLength) / float64(len(documents))
+	}
+	return index, nil
+}
+
+// Len returns the number of indexed documents.
+func (i *Index) Len() int {
+	return len(i.termFreqs)
+}
+
+// IDF returns the inverse document frequency of the term.
+func (i *Index) IDF(term string) float64 {
+	n := float64(i.docFreqs[term])
+	return math.Log((float64(i.Len())-n+0.5)/(n+0.5) + 1)
+}
+
+// Score returns the BM25 score of the query for every indexed document, in the order the documents were indexed.
+func (i *Index) Score(query string) []float64 {
+	scores := make([]float64, i.Len())
+	seen := make(map[string]bool)
+	for _, term := range i.tokenizer(query) {
+		if seen[term] || i.docFreqs[term] == 0 {
+			continue
+		}
+		seen[term] = true
+		idf := i.IDF(term)
+		for d, freqs := range i.termFreqs {
+			tf := float64(freqs[term])
+			if tf == 0 {
+				continue
+			}
+			norm := 1 - i.b
+			if i.avgDocLength > 0 {
+				norm += i.b * float64(i.docLengths[d]) / i.avgDocLength
+			}
+			scores[d] += idf * tf * (i.k1 + 1) / (tf + i.k1*norm)
+		}
+	}
+	return scores
+}
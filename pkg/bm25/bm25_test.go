@@ -0,0 +1,64 @@
+//go:build basic
+
+package bm25
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBM25Index(t *testing.T) {
+	documents := []string{
+		"The quick brown fox jumps over the lazy dog",
+		"A quick brown dog",
+		"Lorem ipsum dolor sit amet",
+	}
+
+	t.Run("Test default tokenizer", func(t *testing.T) {
+		require.Equal(t, []string{"hello", "world", "42"}, DefaultTokenizer("Hello, World! 42"))
+	})
+
+	t.Run("Test score ranks matching documents", func(t *testing.T) {
+		index, err := NewIndex(documents)
+		require.NoError(t, err)
+		require.Equal(t, 3, index.Len())
+		scores := index.Score("lazy fox")
+		require.Len(t, scores, 3)
+		require.Greater(t, scores[0], 0.0)
+		require.Equal(t, 0.0, scores[1])
+		require.Equal(t, 0.0, scores[2])
+
+		scores = index.Score("quick dog")
+		require.Greater(t, scores[1], scores[0], "shorter document should score higher")
+		require.Equal(t, 0.0, scores[2])
+	})
+
+	t.Run("Test rare terms have higher idf", func(t *testing.T) {
+		index, err := NewIndex(documents)
+		require.NoError(t, err)
+		require.Greater(t, index.IDF("lorem"), index.IDF("quick"))
+		require.Greater(t, index.IDF("unknown"), index.IDF("lorem"))
+	})
+
+	t.Run("Test options", func(t *testing.T) {
+		index, err := NewIndex(documents, WithB(0), WithK1(1.5), WithTokenizer(strings.Fields))
+		require.NoError(t, err)
+		scores := index.Score("quick")
+		require.InDelta(t, scores[0], scores[1], 1e-9, "without length normalization scores must match")
+
+		_, err = NewIndex(documents, WithB(2))
+		require.Error(t, err)
+		_, err = NewIndex(documents, WithK1(-1))
+		require.Error(t, err)
+		_, err = NewIndex(documents, WithTokenizer(nil))
+		require.Error(t, err)
+	})
+
+	t.Run("Test empty index", func(t *testing.T) {
+		index, err := NewIndex(nil)
+		require.NoError(t, err)
+		require.Empty(t, index.Score("anything"))
+	})
+}
@@ -0,0 +1,234 @@
+package bm25
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+const sparseEncoderVersion = 1
+
+// SparseEncoder encodes texts as sparse BM25 vectors over a trained vocabulary. Documents are encoded as saturated,
+// length-normalized term frequencies and queries as the IDF of their terms, so the dot product of a query and a document
+// vector is the BM25 score of the document. Terms not in the vocabulary are ignored.
+//
+// The vocabulary and document frequencies are updated by Fit and can be persisted with Save and restored with LoadSparseEncoder.
+// The tokenizer is not persisted; pass the same WithTokenizer option when loading.
+type SparseEncoder struct {
+	config
+	mu          sync.RWMutex
+	vocabulary  map[string]int
+	terms       []string
+	docFreqs    []int
+	numDocs     int
+	totalLength int
+}
+
+var _ types.SparseEmbeddingFunction = (*SparseEncoder)(nil)
+
+func NewSparseEncoder(opts ...Option) (*SparseEncoder, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &SparseEncoder{config: c, vocabulary: make(map[string]int)}, nil
+}
+
+// Fit adds the documents to the corpus statistics, growing the vocabulary with their terms. It can be called repeatedly as the
+// corpus grows; vectors encoded before a call are not updated.
+func (e *SparseEncoder) Fit(documents []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, document := range documents {
+		terms := e.tokenizer(document)
+		seen := make(map[string]bool, len(terms))
+		for _, term := range terms {
+			if seen[term] {
+				continue
+			}
+			seen[term] = true
+			index, ok := e.vocabulary[term]
+			if !ok {
+				index = len(e.terms)
+				e.vocabulary[term] = index
+				e.terms = append(e.terms, term)
+				e.docFreqs = append(e.docFreqs, 0)
+			}
+			e.docFreqs[index]++
+		}
+		e.numDocs++
+		e.totalLength += len(terms)
+	}
+}
+
+// VocabularySize returns the number of known terms. It is also the dimension of the encoded vectors.
+func (e *SparseEncoder) VocabularySize() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.terms)
+}
+
+// Term returns the term of a vector index.
+func (e *SparseEncoder) Term(index int) (string, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if index < 0 || index >= len(e.terms) {
+		return "", false
+	}
+	return e.terms[index], true
+}
+
+// IDF returns the inverse document frequency of the term, or 0 if it is not in the vocabulary.
+func (e *SparseEncoder) IDF(term string) float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	index, ok := e.vocabulary[term]
+	if !ok {
+		return 0
+	}
+	return e.idf(index)
+}
+
+func (e *SparseEncoder) idf(index int) float64 {
+	n := float64(e.docFreqs[index])
+	return math.Log((float64(e.numDocs)-n+0.5)/(n+0.5) + 1)
+}
+
+// EncodeDocument returns the BM25 term weights of the document.
+func (e *SparseEncoder) EncodeDocument(document string) *types.SparseEmbedding {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	terms := e.tokenizer(document)
+	freqs := make(map[int]int)
+	for _, term := range terms {
+		if index, ok := e.vocabulary[term]; ok {
+			freqs[index]++
+		}
+	}
+	norm := 1 - e.b
+	if e.numDocs > 0 && e.totalLength > 0 {
+		norm += e.b * float64(len(terms)) * float64(e.numDocs) / float64(e.totalLength)
+	}
+	weights := make(map[int]float32, len(freqs))
+	for index, freq := range freqs {
+		tf := float64(freq)
+		weights[index] = float32(tf * (e.k1 + 1) / (tf + e.k1*norm))
+	}
+	// indices are unique and non-negative, so this cannot fail
+	embedding, _ := types.NewSparseEmbeddingFromMap(weights)
+	return embedding
+}
+
+// EncodeQuery returns the IDF weights of the query terms.
+func (e *SparseEncoder) EncodeQuery(query string) *types.SparseEmbedding {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	weights := make(map[int]float32)
+	for _, term := range e.tokenizer(query) {
+		if index, ok := e.vocabulary[term]; ok {
+			weights[index] = float32(e.idf(index))
+		}
+	}
+	embedding, _ := types.NewSparseEmbeddingFromMap(weights)
+	return embedding
+}
+
+func (e *SparseEncoder) EmbedDocumentsSparse(ctx context.Context, documents []string) ([]*types.SparseEmbedding, error) {
+	embeddings := make([]*types.SparseEmbedding, len(documents))
+	for i, document := range documents {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		embeddings[i] = e.EncodeDocument(document)
+	}
+	return embeddings, nil
+}
+
+func (e *SparseEncoder) EmbedQuerySparse(_ context.Context, query string) (*types.SparseEmbedding, error) {
+	return e.EncodeQuery(query), nil
+}
+
+type sparseEncoderState struct {
+	Version     int      `json:"version"`
+	K1          float64  `json:"k1"`
+	B           float64  `json:"b"`
+	NumDocs     int      `json:"num_docs"`
+	TotalLength int      `json:"total_length"`
+	Terms       []string `json:"terms"`
+	DocFreqs    []int    `json:"doc_freqs"`
+}
+
+// Save writes the parameters, vocabulary and document frequencies as JSON.
+func (e *SparseEncoder) Save(w io.Writer) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	state := sparseEncoderState{
+		Version:     sparseEncoderVersion,
+		K1:          e.k1,
+		B:           e.b,
+		NumDocs:     e.numDocs,
+		TotalLength: e.totalLength,
+		Terms:       e.terms,
+		DocFreqs:    e.docFreqs,
+	}
+	return json.NewEncoder(w).Encode(state)
+}
+
+// SaveFile writes the encoder state to a file.
+func (e *SparseEncoder) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := e.Save(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadSparseEncoder restores an encoder written by Save. k1 and b are restored from the state unless overridden by the options.
+func LoadSparseEncoder(r io.Reader, opts ...Option) (*SparseEncoder, error) {
+	var state sparseEncoderState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("error decoding sparse encoder state: %w", err)
+	}
+	if state.Version != sparseEncoderVersion {
+		return nil, fmt.Errorf("unsupported sparse encoder state version %d", state.Version)
+	}
+	if len(state.Terms) != len(state.DocFreqs) {
+		return nil, fmt.Errorf("invalid sparse encoder state: %d terms and %d document frequencies", len(state.Terms), len(state.DocFreqs))
+	}
+	restored := append([]Option{WithK1(state.K1), WithB(state.B)}, opts...)
+	encoder, err := NewSparseEncoder(restored...)
+	if err != nil {
+		return nil, err
+	}
+	for i, term := range state.Terms {
+		if _, ok := encoder.vocabulary[term]; ok {
+			return nil, fmt.Errorf("invalid sparse encoder state: duplicate term %q", term)
+		}
+		encoder.vocabulary[term] = i
+	}
+	encoder.terms = state.Terms
+	encoder.docFreqs = state.DocFreqs
+	encoder.numDocs = state.NumDocs
+	encoder.totalLength = state.TotalLength
+	return encoder, nil
+}
+
+// LoadSparseEncoderFile restores an encoder from a file written by SaveFile.
+func LoadSparseEncoderFile(path string, opts ...Option) (*SparseEncoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadSparseEncoder(f, opts...)
+}
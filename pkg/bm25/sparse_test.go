@@ -0,0 +1,100 @@
+//go:build basic
+
+package bm25
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparseEncoder(t *testing.T) {
+	documents := []string{
+		"The quick brown fox jumps over the lazy dog",
+		"A quick brown dog",
+		"Lorem ipsum dolor sit amet",
+	}
+
+	t.Run("Test sparse dot product equals BM25 score", func(t *testing.T) {
+		encoder, err := NewSparseEncoder()
+		require.NoError(t, err)
+		encoder.Fit(documents)
+		index, err := NewIndex(documents)
+		require.NoError(t, err)
+		for _, query := range []string{"quick dog", "lorem fox", "unknown"} {
+			q := encoder.EncodeQuery(query)
+			scores := index.Score(query)
+			for i, document := range documents {
+				require.InDelta(t, scores[i], q.Dot(encoder.EncodeDocument(document)), 1e-5, query)
+			}
+		}
+	})
+
+	t.Run("Test vocabulary", func(t *testing.T) {
+		encoder, err := NewSparseEncoder()
+		require.NoError(t, err)
+		encoder.Fit(documents[:1])
+		size := encoder.VocabularySize()
+		require.Equal(t, 8, size)
+		encoder.Fit(documents[1:])
+		require.Equal(t, 14, encoder.VocabularySize())
+		term, ok := encoder.Term(0)
+		require.True(t, ok)
+		require.Equal(t, "the", term)
+		_, ok = encoder.Term(100)
+		require.False(t, ok)
+		require.Equal(t, float64(0), encoder.IDF("unknown"))
+		require.Greater(t, encoder.IDF("lorem"), encoder.IDF("quick"))
+		require.Equal(t, 0, encoder.EncodeDocument("completely unseen words").Len())
+	})
+
+	t.Run("Test embedding function", func(t *testing.T) {
+		encoder, err := NewSparseEncoder(WithK1(1.5))
+		require.NoError(t, err)
+		encoder.Fit(documents)
+		docs, err := encoder.EmbedDocumentsSparse(context.Background(), documents)
+		require.NoError(t, err)
+		require.Len(t, docs, 3)
+		q, err := encoder.EmbedQuerySparse(context.Background(), "brown dog")
+		require.NoError(t, err)
+		require.Equal(t, 2, q.Len())
+		require.Greater(t, q.Dot(docs[1]), q.Dot(docs[0]))
+		require.Equal(t, float64(0), q.Dot(docs[2]))
+	})
+
+	t.Run("Test save and load", func(t *testing.T) {
+		encoder, err := NewSparseEncoder(WithK1(2), WithB(0.5))
+		require.NoError(t, err)
+		encoder.Fit(documents)
+		var buf bytes.Buffer
+		require.NoError(t, encoder.Save(&buf))
+		loaded, err := LoadSparseEncoder(&buf)
+		require.NoError(t, err)
+		require.Equal(t, encoder.VocabularySize(), loaded.VocabularySize())
+		require.Equal(t, encoder.EncodeQuery("quick dog"), loaded.EncodeQuery("quick dog"))
+		require.Equal(t, encoder.EncodeDocument(documents[0]), loaded.EncodeDocument(documents[0]))
+
+		path := filepath.Join(t.TempDir(), "bm25.json")
+		require.NoError(t, encoder.SaveFile(path))
+		loaded, err = LoadSparseEncoderFile(path, WithK1(1))
+		require.NoError(t, err)
+		require.NotEqual(t, encoder.EncodeDocument(documents[1]), loaded.EncodeDocument(documents[1]))
+	})
+
+	t.Run("Test load invalid state", func(t *testing.T) {
+		for _, state := range []string{
+			`not json`,
+			`{"version":2}`,
+			`{"version":1,"k1":1.2,"b":0.75,"terms":["a"],"doc_freqs":[]}`,
+			`{"version":1,"k1":1.2,"b":0.75,"terms":["a","a"],"doc_freqs":[1,1]}`,
+			`{"version":1,"k1":1.2,"b":2,"terms":[],"doc_freqs":[]}`,
+		} {
+			_, err := LoadSparseEncoder(strings.NewReader(state))
+			require.Error(t, err, state)
+		}
+	})
+}
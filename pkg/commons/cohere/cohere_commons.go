@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"github.com/go-playground/validator/v10"
 
 	httpc "github.com/szirtesitidom/chroma-go/pkg/commons/http"
+	"github.com/szirtesitidom/chroma-go/types"
 )
 
 type APIVersion string
@@ -38,6 +40,7 @@ type CohereClient struct {
 	Client        *http.Client
 	DefaultModel  CohereModel `validate:"required"`
 	RetryStrategy httpc.RetryStrategy
+	types.RequestLogger
 }
 
 func NewCohereClient(opts ...Option) (*CohereClient, error) {
@@ -170,3 +173,11 @@ func WithRetryStrategy(retryStrategy httpc.RetryStrategy) Option {
 		return nil
 	}
 }
+
+// WithLogger sets the logger of the requests of the Cohere client, see types.RequestLogger
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *CohereClient) error {
+		p.SetLogger(logger)
+		return nil
+	}
+}
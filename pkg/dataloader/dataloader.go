@@ -0,0 +1,157 @@
+package dataloader
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// DefaultMaxSize is the default limit for the size of a single loaded item.
+const DefaultMaxSize = 32 << 20
+
+// FileDataLoader loads the data of local file paths, file:// URLs and data: URLs.
+type FileDataLoader struct {
+	// BaseDir resolves relative file paths. Relative paths are resolved against the working directory if empty.
+	BaseDir string
+	// MaxSize is the maximum size in bytes of a single item. Zero disables the limit.
+	MaxSize int64
+}
+
+var _ types.DataLoader = (*FileDataLoader)(nil)
+
+type Option func(l *FileDataLoader) error
+
+// WithBaseDir sets the directory that relative file paths are resolved against.
+func WithBaseDir(dir string) Option {
+	return func(l *FileDataLoader) error {
+		if dir == "" {
+			return fmt.Errorf("base dir must not be empty")
+		}
+		l.BaseDir = dir
+		return nil
+	}
+}
+
+// WithMaxSize sets the maximum size in bytes of a single item. Zero disables the limit.
+func WithMaxSize(size int64) Option {
+	return func(l *FileDataLoader) error {
+		if size < 0 {
+			return fmt.Errorf("max size must not be negative")
+		}
+		l.MaxSize = size
+		return nil
+	}
+}
+
+func NewFileDataLoader(opts ...Option) (*FileDataLoader, error) {
+	loader := &FileDataLoader{MaxSize: DefaultMaxSize}
+	for _, opt := range opts {
+		if err := opt(loader); err != nil {
+			return nil, err
+		}
+	}
+	return loader, nil
+}
+
+// LoadData returns the data of each URI. Supported URIs are local file paths, file:// URLs and data: URLs (base64 or percent-encoded).
+func (l *FileDataLoader) LoadData(ctx context.Context, uris []string) ([][]byte, error) {
+	data := make([][]byte, len(uris))
+	for i, uri := range uris {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		item, err := l.load(uri)
+		if err != nil {
+			return nil, fmt.Errorf("error loading uri %q: %w", shortURI(uri), err)
+		}
+		data[i] = item
+	}
+	return data, nil
+}
+
+func (l *FileDataLoader) load(uri string) ([]byte, error) {
+	switch {
+	case uri == "":
+		return nil, fmt.Errorf("uri must not be empty")
+	case strings.HasPrefix(uri, "data:"):
+		return l.loadDataURL(uri)
+	case strings.HasPrefix(uri, "file://"):
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, err
+		}
+		if u.Host != "" && u.Host != "localhost" {
+			return nil, fmt.Errorf("unsupported file url host %q", u.Host)
+		}
+		return l.loadFile(u.Path)
+	case strings.Contains(uri, "://"):
+		return nil, fmt.Errorf("unsupported uri scheme")
+	default:
+		return l.loadFile(uri)
+	}
+}
+
+func (l *FileDataLoader) loadFile(path string) ([]byte, error) {
+	if !filepath.IsAbs(path) && l.BaseDir != "" {
+		path = filepath.Join(l.BaseDir, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if l.MaxSize == 0 {
+		return io.ReadAll(f)
+	}
+	data, err := io.ReadAll(io.LimitReader(f, l.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > l.MaxSize {
+		return nil, fmt.Errorf("file exceeds the maximum size of %d bytes", l.MaxSize)
+	}
+	return data, nil
+}
+
+// loadDataURL decodes a data URL of the form data:[<mediatype>][;base64],<data>.
+func (l *FileDataLoader) loadDataURL(uri string) ([]byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid data url")
+	}
+	var data []byte
+	var err error
+	if strings.HasSuffix(header, ";base64") {
+		data, err = base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			// some encoders omit the padding
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+		}
+	} else {
+		var s string
+		s, err = url.PathUnescape(payload)
+		data = []byte(s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid data url: %w", err)
+	}
+	if l.MaxSize > 0 && int64(len(data)) > l.MaxSize {
+		return nil, fmt.Errorf("data exceeds the maximum size of %d bytes", l.MaxSize)
+	}
+	return data, nil
+}
+
+// shortURI keeps error messages readable for large data URLs.
+func shortURI(uri string) string {
+	if len(uri) > 64 {
+		return uri[:64] + "..."
+	}
+	return uri
+}
@@ -0,0 +1,56 @@
+//go:build basic
+
+package dataloader
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileDataLoader(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.png"), []byte("image-a"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "big.png"), make([]byte, 10), 0o600))
+
+	t.Run("Test load paths and urls", func(t *testing.T) {
+		loader, err := NewFileDataLoader(WithBaseDir(dir))
+		require.NoError(t, err)
+		data, err := loader.LoadData(context.Background(), []string{
+			"a.png",
+			filepath.Join(dir, "a.png"),
+			"file://" + filepath.ToSlash(filepath.Join(dir, "a.png")),
+			"data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("image-b")),
+			"data:image/png;base64," + base64.RawStdEncoding.EncodeToString([]byte("image-b")),
+			"data:,hello%20world",
+		})
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{
+			[]byte("image-a"), []byte("image-a"), []byte("image-a"),
+			[]byte("image-b"), []byte("image-b"), []byte("hello world"),
+		}, data)
+	})
+
+	t.Run("Test max size", func(t *testing.T) {
+		loader, err := NewFileDataLoader(WithBaseDir(dir), WithMaxSize(5))
+		require.NoError(t, err)
+		_, err = loader.LoadData(context.Background(), []string{"big.png"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "maximum size")
+		_, err = loader.LoadData(context.Background(), []string{"data:,123456"})
+		require.Error(t, err)
+	})
+
+	t.Run("Test invalid uris", func(t *testing.T) {
+		loader, err := NewFileDataLoader()
+		require.NoError(t, err)
+		for _, uri := range []string{"", "https://example.com/a.png", "file://remote/a.png", "data:image/png;base64", "data:;base64,!!!", filepath.Join(dir, "missing.png")} {
+			_, err = loader.LoadData(context.Background(), []string{uri})
+			require.Error(t, err, uri)
+		}
+	})
+}
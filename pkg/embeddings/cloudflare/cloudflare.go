@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/szirtesitidom/chroma-go/types"
 )
@@ -30,6 +31,7 @@ type CloudflareClient struct {
 	MaxBatchSize   int
 	DefaultHeaders map[string]string
 	Client         *http.Client
+	types.RequestLogger
 }
 
 func applyDefaults(c *CloudflareClient) {
@@ -63,7 +65,7 @@ func validate(c *CloudflareClient) error {
 		return fmt.Errorf("account ID is required")
 	}
 	if c.AccountID != "" && c.IsGateway {
-		fmt.Printf("account ID is ignored when using gateway mode")
+		c.Logger().Warn("account ID is ignored when using gateway mode", "provider", "cloudflare")
 	}
 	if c.MaxBatchSize < 1 {
 		return fmt.Errorf("max batch size must be greater than 0")
@@ -112,7 +114,10 @@ func (c *CreateEmbeddingRequest) JSON() (string, error) {
 	return string(data), nil
 }
 
-func (c *CloudflareClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (*CreateEmbeddingResponse, error) {
+func (c *CloudflareClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "cloudflare", c.DefaultModel, len(req.Text), start, err)
+	}(time.Now())
 	reqJSON, err := req.JSON()
 	if err != nil {
 		return nil, err
@@ -148,8 +153,10 @@ func (c *CloudflareClient) CreateEmbedding(ctx context.Context, req *CreateEmbed
 }
 
 var _ types.EmbeddingFunction = (*CloudflareEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*CloudflareEmbeddingFunction)(nil)
 
 type CloudflareEmbeddingFunction struct {
+	types.UsageTracker
 	apiClient *CloudflareClient
 }
 
@@ -176,6 +183,8 @@ func (e *CloudflareEmbeddingFunction) EmbedDocuments(ctx context.Context, docume
 	if err != nil {
 		return nil, err
 	}
+	// Workers AI does not report token usage for embedding models, only the request is counted
+	e.RecordUsage(ctx, types.Usage{Provider: "cloudflare", Model: e.apiClient.DefaultModel, Requests: 1})
 	return types.NewEmbeddingsFromFloat32(response.Result.Data), nil
 }
 
@@ -187,6 +196,8 @@ func (e *CloudflareEmbeddingFunction) EmbedQuery(ctx context.Context, document s
 	if err != nil {
 		return nil, err
 	}
+	// Workers AI does not report token usage for embedding models, only the request is counted
+	e.RecordUsage(ctx, types.Usage{Provider: "cloudflare", Model: e.apiClient.DefaultModel, Requests: 1})
 	return types.NewEmbeddingFromFloat32(response.Result.Data[0]), nil
 }
 
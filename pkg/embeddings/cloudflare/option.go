@@ -2,6 +2,7 @@ package cloudflare
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 )
@@ -88,3 +89,12 @@ func WithEnvGatewayEndpoint() Option {
 		return fmt.Errorf("CF_GATEWAY_ENDPOINT not set")
 	}
 }
+
+// WithLogger logs the embedding requests at debug level, failed requests and configuration warnings (e.g. an account ID in
+// gateway mode) as warnings.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *CloudflareClient) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
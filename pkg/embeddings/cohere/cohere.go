@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	ccommons "github.com/szirtesitidom/chroma-go/pkg/commons/cohere"
+	"github.com/szirtesitidom/chroma-go/pkg/quantization"
 	"github.com/szirtesitidom/chroma-go/types"
 )
 
@@ -66,6 +68,35 @@ type EmbeddingTypes struct {
 	Float32 [][]float32 `json:"float"`
 	Int8    [][]int8    `json:"int8"`
 	UInt8   [][]uint8   `json:"uint8"`
+	Binary  [][]int8    `json:"binary"`
+	UBinary [][]uint8   `json:"ubinary"`
+}
+
+// ToEmbeddings converts the first available embedding type (in the order float, int8, uint8, binary, ubinary) to embeddings.
+// Binary and ubinary embeddings are both stored as unsigned packed bits, see quantization.BinaryEmbedding.
+func (e *EmbeddingTypes) ToEmbeddings() ([]*types.Embedding, error) {
+	switch {
+	case e.Float32 != nil:
+		return types.NewEmbeddingsFromFloat32(e.Float32), nil
+	case e.Int8 != nil:
+		return convertEmbeddings(e.Int8, quantization.Int8Embedding), nil
+	case e.UInt8 != nil:
+		return convertEmbeddings(e.UInt8, quantization.Uint8Embedding), nil
+	case e.Binary != nil:
+		return convertEmbeddings(e.Binary, quantization.SignedBinaryEmbedding), nil
+	case e.UBinary != nil:
+		return convertEmbeddings(e.UBinary, quantization.BinaryEmbedding), nil
+	default:
+		return nil, fmt.Errorf("unsupported embedding type")
+	}
+}
+
+func convertEmbeddings[T any](embeddings []T, convert func(T) *types.Embedding) []*types.Embedding {
+	out := make([]*types.Embedding, len(embeddings))
+	for i, e := range embeddings {
+		out[i] = convert(e)
+	}
+	return out
 }
 
 type EmbeddingsResponse struct {
@@ -74,9 +105,11 @@ type EmbeddingsResponse struct {
 }
 
 var _ types.EmbeddingFunction = (*CohereEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*CohereEmbeddingFunction)(nil)
 
 type CohereEmbeddingFunction struct {
 	ccommons.CohereClient
+	types.UsageTracker
 	DefaultTruncateMode   TruncateMode
 	DefaultEmbeddingTypes []EmbeddingType
 	DefaultInputType      InputType
@@ -101,7 +134,10 @@ func NewCohereEmbeddingFunction(opts ...Option) (*CohereEmbeddingFunction, error
 	return ef, nil
 }
 
-func (c *CohereEmbeddingFunction) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (*CreateEmbeddingResponse, error) {
+func (c *CohereEmbeddingFunction) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "cohere", req.Model, len(req.Texts), start, err)
+	}(time.Now())
 	reqJSON, err := req.JSON()
 	if err != nil {
 		return nil, err
@@ -158,24 +194,13 @@ func (c *CohereEmbeddingFunction) EmbedDocuments(ctx context.Context, documents
 	if err != nil {
 		return nil, err
 	}
+	c.RecordUsage(ctx, response.usage(_model.String()))
 	switch {
 	case response.Embeddings.Embeddings != nil:
 		return types.NewEmbeddingsFromFloat32(response.Embeddings.Embeddings), nil
 
 	case response.Embeddings.EmbeddingsTypes != nil:
-		switch {
-		case response.Embeddings.EmbeddingsTypes.Float32 != nil:
-			return types.NewEmbeddingsFromFloat32(response.Embeddings.EmbeddingsTypes.Float32), nil
-
-		case response.Embeddings.EmbeddingsTypes.Int8 != nil:
-			return types.NewEmbeddingsFromInt32(int32FromInt8Embeddings(response.Embeddings.EmbeddingsTypes.Int8)), nil
-
-		case response.Embeddings.EmbeddingsTypes.UInt8 != nil:
-			return types.NewEmbeddingsFromInt32(int32FromUInt8Embeddings(response.Embeddings.EmbeddingsTypes.UInt8)), nil
-
-		default:
-			return nil, fmt.Errorf("unsupported embedding type")
-		}
+		return response.Embeddings.EmbeddingsTypes.ToEmbeddings()
 
 	default:
 		return nil, fmt.Errorf("unexpected response from API")
@@ -203,24 +228,20 @@ func (c *CohereEmbeddingFunction) EmbedQuery(ctx context.Context, document strin
 	if err != nil {
 		return nil, err
 	}
+	c.RecordUsage(ctx, response.usage(_model.String()))
 	switch {
 	case response.Embeddings.Embeddings != nil:
 		return types.NewEmbeddingFromFloat32(response.Embeddings.Embeddings[0]), nil
 
 	case response.Embeddings.EmbeddingsTypes != nil:
-		switch {
-		case response.Embeddings.EmbeddingsTypes.Float32 != nil:
-			return types.NewEmbeddingFromFloat32(response.Embeddings.EmbeddingsTypes.Float32[0]), nil
-
-		case response.Embeddings.EmbeddingsTypes.Int8 != nil:
-			return types.NewEmbeddingFromInt32(int32FromInt8Embeddings(response.Embeddings.EmbeddingsTypes.Int8)[0]), nil
-
-		case response.Embeddings.EmbeddingsTypes.UInt8 != nil:
-			return types.NewEmbeddingFromInt32(int32FromUInt8Embeddings(response.Embeddings.EmbeddingsTypes.UInt8)[0]), nil
-
-		default:
-			return nil, fmt.Errorf("unsupported embedding type")
+		embeddings, err := response.Embeddings.EmbeddingsTypes.ToEmbeddings()
+		if err != nil {
+			return nil, err
+		}
+		if len(embeddings) == 0 {
+			return nil, fmt.Errorf("unexpected response from API")
 		}
+		return embeddings[0], nil
 
 	default:
 		return nil, fmt.Errorf("unexpected response from API")
@@ -261,36 +282,28 @@ type CreateEmbeddingResponse struct {
 	Meta       map[string]any      `json:"meta"`
 }
 
-func (c *CreateEmbeddingRequest) JSON() (string, error) {
-	data, err := json.Marshal(c)
-	if err != nil {
-		return "", err
+// BilledInputTokens returns the input tokens reported in meta.billed_units, or 0 if the response has none.
+func (c *CreateEmbeddingResponse) BilledInputTokens() int {
+	billedUnits, ok := c.Meta["billed_units"].(map[string]any)
+	if !ok {
+		return 0
 	}
-	return string(data), nil
-}
-
-func int32FromUInt8Embeddings(embeddings [][]uint8) [][]int32 {
-	var int32s = make([][]int32, len(embeddings))
-
-	for i, innerSlice := range embeddings {
-		newInnerSlice := make([]int32, len(innerSlice)) // Pre-allocate with the exact size
-		for j, num := range innerSlice {
-			newInnerSlice[j] = int32(num)
-		}
-		int32s[i] = newInnerSlice
+	tokens, ok := billedUnits["input_tokens"].(float64)
+	if !ok {
+		return 0
 	}
-	return int32s
+	return int(tokens)
 }
 
-func int32FromInt8Embeddings(embeddings [][]int8) [][]int32 {
-	var int32s = make([][]int32, len(embeddings))
+func (c *CreateEmbeddingResponse) usage(model string) types.Usage {
+	tokens := c.BilledInputTokens()
+	return types.Usage{Provider: "cohere", Model: model, PromptTokens: tokens, TotalTokens: tokens, Requests: 1}
+}
 
-	for i, innerSlice := range embeddings {
-		newInnerSlice := make([]int32, len(innerSlice)) // Pre-allocate with the exact size
-		for j, num := range innerSlice {
-			newInnerSlice[j] = int32(num)
-		}
-		int32s[i] = newInnerSlice
+func (c *CreateEmbeddingRequest) JSON() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
 	}
-	return int32s
+	return string(data), nil
 }
@@ -4,13 +4,19 @@ package cohere
 
 import (
 	"context"
-	ccommons "github.com/szirtesitidom/chroma-go/pkg/commons/cohere"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	ccommons "github.com/szirtesitidom/chroma-go/pkg/commons/cohere"
+	"github.com/szirtesitidom/chroma-go/pkg/quantization"
+	"github.com/szirtesitidom/chroma-go/types"
 )
 
 func Test_ef(t *testing.T) {
@@ -109,3 +115,62 @@ func Test_ef(t *testing.T) {
 		require.Empty(t, resp.ArrayOfInt32)
 	})
 }
+
+func Test_ef_quantized_embedding_types(t *testing.T) {
+	var lastRequest CreateEmbeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&lastRequest))
+		w.Header().Set("Content-Type", "application/json")
+		var embeddings string
+		switch lastRequest.EmbeddingTypes[0] {
+		case EmbeddingTypeBinary:
+			embeddings = `{"binary":[[-128,0,127],[-1,-1,-1]]}`
+		case EmbeddingTypeUBinary:
+			embeddings = `{"ubinary":[[0,128,255],[127,127,127]]}`
+		case EmbeddingTypeInt8:
+			embeddings = `{"int8":[[-5,3],[7,-2]]}`
+		}
+		_, err := w.Write([]byte(`{"id":"1","texts":["a","b"],"embeddings":` + embeddings + `,"meta":{"billed_units":{"input_tokens":4}}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	t.Run("Test binary and ubinary map to the same embedding", func(t *testing.T) {
+		binaryEf, err := NewCohereEmbeddingFunction(WithAPIKey("test"), WithBaseURL(server.URL), WithEmbeddingTypes(EmbeddingTypeBinary))
+		require.NoError(t, err)
+		binary, err := binaryEf.EmbedDocuments(context.Background(), []string{"a", "b"})
+		require.NoError(t, err)
+		ubinaryEf, err := NewCohereEmbeddingFunction(WithAPIKey("test"), WithBaseURL(server.URL), WithEmbeddingTypes(EmbeddingTypeUBinary))
+		require.NoError(t, err)
+		ubinary, err := ubinaryEf.EmbedDocuments(context.Background(), []string{"a", "b"})
+		require.NoError(t, err)
+		require.Equal(t, []int32{0, 128, 255}, *binary[0].ArrayOfInt32)
+		require.Equal(t, *ubinary[0].ArrayOfInt32, *binary[0].ArrayOfInt32)
+		require.Equal(t, *ubinary[1].ArrayOfInt32, *binary[1].ArrayOfInt32)
+		d, err := quantization.HammingDistance(binary[0], ubinary[1])
+		require.NoError(t, err)
+		require.Equal(t, 16, d)
+	})
+
+	t.Run("Test int8 query", func(t *testing.T) {
+		ef, err := NewCohereEmbeddingFunction(WithAPIKey("test"), WithBaseURL(server.URL), WithEmbeddingTypes(EmbeddingTypeInt8))
+		require.NoError(t, err)
+		query, err := ef.EmbedQuery(context.Background(), "a")
+		require.NoError(t, err)
+		require.Equal(t, []int32{-5, 3}, *query.ArrayOfInt32)
+		require.Equal(t, InputTypeSearchQuery, lastRequest.InputType)
+	})
+
+	t.Run("Test usage is collected from billed units", func(t *testing.T) {
+		ef, err := NewCohereEmbeddingFunction(WithAPIKey("test"), WithBaseURL(server.URL), WithEmbeddingTypes(EmbeddingTypeInt8))
+		require.NoError(t, err)
+		collector := types.NewUsageCollector()
+		ctx := types.ContextWithUsageCollector(context.Background(), collector)
+		_, err = ef.EmbedDocuments(ctx, []string{"a", "b"})
+		require.NoError(t, err)
+		_, err = ef.EmbedQuery(ctx, "a")
+		require.NoError(t, err)
+		require.Equal(t, types.Usage{Provider: "cohere", Model: string(DefaultEmbedModel), PromptTokens: 8, TotalTokens: 8, Requests: 2}, collector.Total())
+		require.Equal(t, collector.Total(), ef.TotalUsage())
+	})
+}
@@ -2,6 +2,7 @@ package cohere
 
 import (
 	"fmt"
+	"log/slog"
 
 	ccommons "github.com/szirtesitidom/chroma-go/pkg/commons/cohere"
 	httpc "github.com/szirtesitidom/chroma-go/pkg/commons/http"
@@ -80,12 +81,14 @@ func WithTruncateMode(truncate TruncateMode) Option {
 // uint8
 // binary
 // ubinary
-// TODO we do not have support for returning multiple embedding types from the EmbeddingFunction, so for float->int8, unit8 are supported and returned in the that order
+// The EmbeddingFunction returns a single embedding type, the first available in the order float, int8, uint8, binary, ubinary.
+// Binary and ubinary embeddings are returned as unsigned packed bits, use quantization.HammingDistance to compare them.
 func WithEmbeddingTypes(embeddingTypes ...EmbeddingType) Option {
 	return func(p *CohereEmbeddingFunction) ccommons.Option {
-		// if embeddingstypes contains binary or ubinary error
 		for _, et := range embeddingTypes {
-			if et == EmbeddingTypeBinary || et == EmbeddingTypeUBinary {
+			switch et {
+			case EmbeddingTypeFloat32, EmbeddingTypeInt8, EmbeddingTypeUInt8, EmbeddingTypeBinary, EmbeddingTypeUBinary:
+			default:
 				return func(c *ccommons.CohereClient) error {
 					return fmt.Errorf("embedding type %s is not supported", et)
 				}
@@ -106,3 +109,10 @@ func WithRetryStrategy(retryStrategy httpc.RetryStrategy) Option {
 		return ccommons.WithRetryStrategy(retryStrategy)
 	}
 }
+
+// WithLogger logs the embed requests (model, number of texts and latency) at debug level and failed requests as warnings
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *CohereEmbeddingFunction) ccommons.Option {
+		return ccommons.WithLogger(logger)
+	}
+}
@@ -3,6 +3,7 @@ package defaultef
 const (
 	LibTokenizersVersion      = "0.9.0"
 	LibOnnxRuntimeVersion     = "1.18.0"
+	defaultModel              = "all-MiniLM-L6-v2"
 	onnxModelDownloadEndpoint = "https://chroma-onnx-models.s3.amazonaws.com/all-MiniLM-L6-v2/onnx.tar.gz"
 	ChromaCacheDir            = ".cache/chroma/"
 )
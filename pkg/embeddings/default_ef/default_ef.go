@@ -3,9 +3,12 @@ package defaultef
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"os"
+	"time"
 
 	ort "github.com/yalue/onnxruntime_go"
 
@@ -19,18 +22,35 @@ var _ types.EmbeddingFunction = (*DefaultEmbeddingFunction)(nil)
 
 type DefaultEmbeddingFunction struct {
 	tokenizer *tokenizers.Tokenizer
+	types.RequestLogger
+}
+
+// WithLogger logs the downloads of the shared libraries and the model, the embeddings computed by the function and failures
+// to release native resources.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *DefaultEmbeddingFunction) error {
+		p.SetLogger(logger)
+		return nil
+	}
 }
 
 func NewDefaultEmbeddingFunction(opts ...Option) (*DefaultEmbeddingFunction, func(), error) {
-	err := EnsureLibTokenizersSharedLibrary()
+	ef := &DefaultEmbeddingFunction{}
+	for _, opt := range opts {
+		if err := opt(ef); err != nil {
+			return nil, nil, err
+		}
+	}
+	logger := ef.Logger()
+	err := ensureLibTokenizersSharedLibrary(logger)
 	if err != nil {
 		return nil, nil, err
 	}
-	err = EnsureOnnxRuntimeSharedLibrary()
+	err = ensureOnnxRuntimeSharedLibrary(logger)
 	if err != nil {
 		return nil, nil, err
 	}
-	err = EnsureDefaultEmbeddingFunctionModel()
+	err = ensureDefaultEmbeddingFunctionModel(logger)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -52,18 +72,19 @@ func NewDefaultEmbeddingFunction(opts ...Option) (*DefaultEmbeddingFunction, fun
 		return nil, func() {
 			err := tk.Close()
 			if err != nil {
-				fmt.Println(err)
+				logger.Error("failed to close tokenizer", "error", err)
 			}
 		}, err
 	}
-	return &DefaultEmbeddingFunction{tokenizer: tk}, func() {
+	ef.tokenizer = tk
+	return ef, func() {
 		err := tk.Close()
 		if err != nil {
-			fmt.Println(err)
+			logger.Error("failed to close tokenizer", "error", err)
 		}
 		err = ort.DestroyEnvironment()
 		if err != nil {
-			fmt.Println(err)
+			logger.Error("failed to destroy onnxruntime environment", "error", err)
 		}
 	}, nil
 }
@@ -83,21 +104,18 @@ func NewEmbeddingInput(inputIDs []int64, attnMask []int64, typeIDs []int64, numI
 	}
 	attentionTensor, err := ort.NewTensor(inputShape, attnMask)
 	if err != nil {
-		derr := inputTensor.Destroy()
-		if derr != nil {
-			fmt.Printf("potential memory leak. Failed to destroy input tensor %e", derr)
+		if derr := inputTensor.Destroy(); derr != nil {
+			err = errors.Join(err, fmt.Errorf("potential memory leak, failed to destroy input tensor: %w", derr))
 		}
 		return nil, err
 	}
 	typeTensor, err := ort.NewTensor(inputShape, typeIDs)
 	if err != nil {
-		derr := inputTensor.Destroy()
-		if derr != nil {
-			fmt.Printf("potential memory leak. Failed to destroy input tensor %e", derr)
+		if derr := inputTensor.Destroy(); derr != nil {
+			err = errors.Join(err, fmt.Errorf("potential memory leak, failed to destroy input tensor: %w", derr))
 		}
-		derr = attentionTensor.Destroy()
-		if derr != nil {
-			fmt.Printf("potential memory leak. Failed to destroy attention tensor %e", derr)
+		if derr := attentionTensor.Destroy(); derr != nil {
+			err = errors.Join(err, fmt.Errorf("potential memory leak, failed to destroy attention tensor: %w", derr))
 		}
 		return nil, err
 	}
@@ -167,7 +185,7 @@ func (e *DefaultEmbeddingFunction) encode(embeddingInput *EmbeddingInput) ([]*ty
 	defer func(outputTensor *ort.Tensor[float32]) {
 		err := outputTensor.Destroy()
 		if err != nil {
-			fmt.Printf("potential memory leak. Failed to destory outputTensor %v", err)
+			e.Logger().Warn("potential memory leak, failed to destroy output tensor", "error", err)
 		}
 	}(outputTensor)
 	session, err := ort.NewAdvancedSession(onnxModelPath,
@@ -179,7 +197,7 @@ func (e *DefaultEmbeddingFunction) encode(embeddingInput *EmbeddingInput) ([]*ty
 	defer func(session *ort.AdvancedSession) {
 		err := session.Destroy()
 		if err != nil {
-			fmt.Printf("potential memory leak. Failed to destory ORT session %v", err)
+			e.Logger().Warn("potential memory leak, failed to destroy ORT session", "error", err)
 		}
 	}(session)
 
@@ -215,7 +233,11 @@ func (e *DefaultEmbeddingFunction) encode(embeddingInput *EmbeddingInput) ([]*ty
 	return out, nil
 }
 
-func (e *DefaultEmbeddingFunction) EmbedDocuments(ctx context.Context, documents []string) ([]*types.Embedding, error) {
+// embed tokenizes and encodes the documents.
+func (e *DefaultEmbeddingFunction) embed(ctx context.Context, documents []string) (_ []*types.Embedding, err error) {
+	defer func(start time.Time) {
+		e.LogRequest(ctx, "default", defaultModel, len(documents), start, err)
+	}(time.Now())
 	embeddingInputs, err := e.tokenize(documents)
 	if err != nil {
 		return nil, err
@@ -223,12 +245,12 @@ func (e *DefaultEmbeddingFunction) EmbedDocuments(ctx context.Context, documents
 	return e.encode(embeddingInputs)
 }
 
+func (e *DefaultEmbeddingFunction) EmbedDocuments(ctx context.Context, documents []string) ([]*types.Embedding, error) {
+	return e.embed(ctx, documents)
+}
+
 func (e *DefaultEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
-	embeddingInputs, err := e.tokenize([]string{document})
-	if err != nil {
-		return nil, err
-	}
-	embeddings, err := e.encode(embeddingInputs)
+	embeddings, err := e.embed(ctx, []string{document})
 	if err != nil {
 		return nil, err
 	}
@@ -5,10 +5,13 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+
+	"github.com/szirtesitidom/chroma-go/types"
 )
 
 var libCacheDir = filepath.Join(os.Getenv("HOME"), ChromaCacheDir)
@@ -95,7 +98,6 @@ func extractSpecificFile(tarGzPath, targetFile, destPath string) error {
 				return fmt.Errorf("could not copy file data: %v", err)
 			}
 
-			fmt.Printf("Successfully extracted %s to %s\n", targetFile, destPath)
 			return nil // Successfully extracted the file
 		}
 		if targetFile == "" {
@@ -120,6 +122,10 @@ func extractSpecificFile(tarGzPath, targetFile, destPath string) error {
 }
 
 func EnsureOnnxRuntimeSharedLibrary() error {
+	return ensureOnnxRuntimeSharedLibrary(types.DiscardLogger())
+}
+
+func ensureOnnxRuntimeSharedLibrary(logger *slog.Logger) error {
 	cos, carch := getOSAndArch()
 	if carch == "amd64" {
 		carch = "x64"
@@ -146,7 +152,7 @@ func EnsureOnnxRuntimeSharedLibrary() error {
 		// Download the library
 		url := "https://github.com/microsoft/onnxruntime/releases/download/v" + LibOnnxRuntimeVersion + "/onnxruntime-" + cos + "-" + carch + "-" + LibOnnxRuntimeVersion + ".tgz"
 
-		fmt.Println("Downloading onnxruntime from GitHub...")
+		logger.Info("downloading onnxruntime from GitHub", "url", url)
 		// TODO integrity check
 		if _, err := os.Stat(targetArchive); os.IsNotExist(err) {
 			if err := downloadFile(targetArchive, url); err != nil {
@@ -158,9 +164,9 @@ func EnsureOnnxRuntimeSharedLibrary() error {
 	if cos == "linux" {
 		targetFile = "onnxruntime-" + cos + "-" + carch + "-" + LibOnnxRuntimeVersion + "/lib/libonnxruntime." + getExtensionForOs() + "." + LibOnnxRuntimeVersion
 	}
-	fmt.Println("Extracting onnxruntime shared library..." + onnxLibPath)
+	logger.Info("extracting onnxruntime shared library", "path", onnxLibPath)
 	if err := extractSpecificFile(targetArchive, targetFile, onnxCacheDir); err != nil {
-		fmt.Println("Error:", err)
+		logger.Error("failed to extract onnxruntime shared library", "archive", targetArchive, "error", err)
 	}
 
 	if cos == "linux" {
@@ -179,6 +185,10 @@ func EnsureOnnxRuntimeSharedLibrary() error {
 }
 
 func EnsureLibTokenizersSharedLibrary() error {
+	return ensureLibTokenizersSharedLibrary(types.DiscardLogger())
+}
+
+func ensureLibTokenizersSharedLibrary(logger *slog.Logger) error {
 	cos, carch := getOSAndArch()
 	downloadAndExtractNeeded := false
 	if _, err := os.Stat(libTokenizersLibPath); os.IsNotExist(err) {
@@ -195,7 +205,7 @@ func EnsureLibTokenizersSharedLibrary() error {
 		// Download the library
 		url := "https://github.com/szirtesitidom/tokenizers/releases/download/v" + LibTokenizersVersion + "/libtokenizers." + cos + "-" + carch + ".tar.gz"
 
-		fmt.Println("Downloading libtokenizers from GitHub...")
+		logger.Info("downloading libtokenizers from GitHub", "url", url)
 		// TODO integrity check
 		if _, err := os.Stat(targetArchive); os.IsNotExist(err) {
 			if err := downloadFile(targetArchive, url); err != nil {
@@ -204,9 +214,9 @@ func EnsureLibTokenizersSharedLibrary() error {
 		}
 	}
 	targetFile := "libtokenizers." + getExtensionForOs()
-	fmt.Println("Extracting libtokenizers shared library..." + onnxLibPath)
+	logger.Info("extracting libtokenizers shared library", "path", libTokenizersLibPath)
 	if err := extractSpecificFile(targetArchive, targetFile, libTokenizersCacheDir); err != nil {
-		fmt.Println("Error:", err)
+		logger.Error("failed to extract libtokenizers shared library", "archive", targetArchive, "error", err)
 	}
 
 	err := os.RemoveAll(targetArchive)
@@ -217,6 +227,10 @@ func EnsureLibTokenizersSharedLibrary() error {
 }
 
 func EnsureDefaultEmbeddingFunctionModel() error {
+	return ensureDefaultEmbeddingFunctionModel(types.DiscardLogger())
+}
+
+func ensureDefaultEmbeddingFunctionModel(logger *slog.Logger) error {
 	downloadAndExtractNeeded := false
 	if _, err := os.Stat(onnxModelCachePath); os.IsNotExist(err) {
 		downloadAndExtractNeeded = true
@@ -229,15 +243,14 @@ func EnsureDefaultEmbeddingFunctionModel() error {
 	}
 	targetArchive := filepath.Join(onnxModelsCachePath, "onnx.tar.gz")
 	if _, err := os.Stat(targetArchive); os.IsNotExist(err) {
-		fmt.Println("Downloading onnx model from S3...")
+		logger.Info("downloading onnx model from S3", "url", onnxModelDownloadEndpoint)
 		// TODO integrity check
 		if err := downloadFile(targetArchive, onnxModelDownloadEndpoint); err != nil {
 			return err
 		}
 	}
-	fmt.Println("Extracting onnx model..." + onnxModelCachePath)
+	logger.Info("extracting onnx model", "path", onnxModelCachePath)
 	if err := extractSpecificFile(targetArchive, "", onnxModelCachePath); err != nil {
-		fmt.Println("Error:", err)
 		return err
 	}
 
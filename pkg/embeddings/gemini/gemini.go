@@ -3,6 +3,7 @@ package gemini
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
@@ -24,6 +25,7 @@ type Client struct {
 	Client         *genai.Client
 	DefaultContext *context.Context
 	MaxBatchSize   int
+	types.RequestLogger
 }
 
 func applyDefaults(c *Client) (err error) {
@@ -71,13 +73,15 @@ func NewGeminiClient(opts ...Option) (*Client, error) {
 	return client, nil
 }
 
-func (c *Client) CreateEmbedding(ctx context.Context, req []string) ([]*types.Embedding, error) {
-	var em *genai.EmbeddingModel
+func (c *Client) CreateEmbedding(ctx context.Context, req []string) (_ []*types.Embedding, err error) {
+	model := c.DefaultModel
 	if ctx.Value(ModelContextVar) != nil {
-		em = c.Client.EmbeddingModel(ctx.Value(ModelContextVar).(string))
-	} else {
-		em = c.Client.EmbeddingModel(c.DefaultModel)
+		model = ctx.Value(ModelContextVar).(string)
 	}
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "gemini", model, len(req), start, err)
+	}(time.Now())
+	em := c.Client.EmbeddingModel(model)
 	b := em.NewBatch()
 	for _, t := range req {
 		b.AddContent(genai.Text(t))
@@ -2,6 +2,7 @@ package gemini
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/google/generative-ai-go/genai"
@@ -57,3 +58,11 @@ func WithMaxBatchSize(maxBatchSize int) Option {
 		return nil
 	}
 }
+
+// WithLogger sets the logger of the batch embedding requests to Gemini.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
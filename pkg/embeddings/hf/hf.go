@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/szirtesitidom/chroma-go/types"
 )
@@ -18,6 +20,17 @@ type HuggingFaceClient struct {
 	Model          string
 	Client         *http.Client
 	DefaultHeaders map[string]string
+	// The following fields are only used with Text Embeddings Inference servers.
+	Normalize           *bool
+	Truncate            *bool
+	TruncationDirection TruncationDirection
+	PromptName          string
+	// MaxBatchSize is the maximum number of inputs per request. If 0, max_client_batch_size from /info is used.
+	MaxBatchSize int
+	tei          bool
+	info         *TEIInfo
+	infoMu       sync.Mutex
+	types.RequestLogger
 }
 
 func NewHuggingFaceClient(apiKey string, model string) *HuggingFaceClient {
@@ -76,7 +89,10 @@ func (c *CreateEmbeddingRequest) JSON() (string, error) {
 	return string(data), nil
 }
 
-func (c *HuggingFaceClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (*CreateEmbeddingResponse, error) {
+func (c *HuggingFaceClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "huggingface", c.Model, len(req.Inputs), start, err)
+	}(time.Now())
 	reqJSON, err := req.JSON()
 	if err != nil {
 		return nil, err
@@ -124,6 +140,7 @@ func (c *HuggingFaceClient) CreateEmbedding(ctx context.Context, req *CreateEmbe
 }
 
 var _ types.EmbeddingFunction = (*HuggingFaceEmbeddingFunction)(nil)
+var _ types.SparseEmbeddingFunction = (*HuggingFaceEmbeddingFunction)(nil)
 
 type HuggingFaceEmbeddingFunction struct {
 	apiClient *HuggingFaceClient
@@ -148,12 +165,17 @@ func NewHuggingFaceEmbeddingFunctionFromOptions(opts ...Option) (*HuggingFaceEmb
 	}, nil
 }
 
+// NewHuggingFaceEmbeddingInferenceFunction creates an embedding function for a HuggingFace Text Embeddings Inference (TEI) server.
+// Documents are embedded with /embed in batches of at most max_client_batch_size inputs as reported by /info.
+// baseURL is the server URL, a trailing /embed is removed for compatibility.
 func NewHuggingFaceEmbeddingInferenceFunction(baseURL string, opts ...Option) (*HuggingFaceEmbeddingFunction, error) {
 	opts = append(opts, WithBaseURL(baseURL))
 	cli, err := NewHuggingFaceClientFromOptions(opts...)
 	if err != nil {
 		return nil, err
 	}
+	cli.BaseURL = strings.TrimSuffix(strings.TrimSuffix(cli.BaseURL, "/"), "/"+TEIEmbedEndpoint)
+	cli.tei = true
 
 	return &HuggingFaceEmbeddingFunction{
 		apiClient: cli,
@@ -161,6 +183,9 @@ func NewHuggingFaceEmbeddingInferenceFunction(baseURL string, opts ...Option) (*
 }
 
 func (e *HuggingFaceEmbeddingFunction) EmbedDocuments(ctx context.Context, documents []string) ([]*types.Embedding, error) {
+	if e.apiClient.tei {
+		return e.teiEmbed(ctx, documents)
+	}
 	response, err := e.apiClient.CreateEmbedding(ctx, &CreateEmbeddingRequest{
 		Inputs: documents,
 	})
@@ -171,6 +196,13 @@ func (e *HuggingFaceEmbeddingFunction) EmbedDocuments(ctx context.Context, docum
 }
 
 func (e *HuggingFaceEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
+	if e.apiClient.tei {
+		embeddings, err := e.teiEmbed(ctx, []string{document})
+		if err != nil {
+			return nil, err
+		}
+		return embeddings[0], nil
+	}
 	response, err := e.apiClient.CreateEmbedding(ctx, &CreateEmbeddingRequest{
 		Inputs: []string{document},
 	})
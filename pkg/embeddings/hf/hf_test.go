@@ -4,6 +4,8 @@ package hf
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -143,3 +145,138 @@ func Test_Huggingface_client_with_options(t *testing.T) {
 		require.NotNil(t, resp)
 	})
 }
+
+func Test_huggingface_tei(t *testing.T) {
+	var requests []string
+	var bodies []TEIRequest
+	infoAvailable := true
+	maxBatchTokens := 16384
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/info" {
+			if !infoAvailable {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"model_id":"BAAI/bge-small-en-v1.5","model_dtype":"float32","model_type":{"embedding":{"pooling":"cls"}},"max_concurrent_requests":512,"max_input_length":512,"max_batch_tokens":%d,"max_batch_requests":null,"max_client_batch_size":2,"version":"1.5.0"}`, maxBatchTokens)
+			return
+		}
+		var body TEIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		response := make([]any, len(body.Inputs))
+		for i := range body.Inputs {
+			switch r.URL.Path {
+			case "/embed":
+				response[i] = []float32{float32(len(body.Inputs[i])), 1}
+			case "/embed_sparse":
+				response[i] = []TEISparseValue{{Index: 7, Value: 0.5}, {Index: 3, Value: float32(len(body.Inputs[i]))}}
+			case "/embed_all":
+				response[i] = [][]float32{{1, 0}, {0, 1}}
+			}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	defer server.Close()
+
+	t.Run("Test info and batches", func(t *testing.T) {
+		requests, bodies = nil, nil
+		ef, err := NewHuggingFaceEmbeddingInferenceFunction(server.URL, WithTruncate(true), WithTruncationDirection(TruncationDirectionLeft), WithPromptName("query"), WithNormalize(false))
+		require.NoError(t, err)
+		info, err := ef.Info(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 512, info.MaxInputLength)
+		require.Equal(t, 2, info.MaxClientBatchSize)
+		resp, err := ef.EmbedDocuments(context.Background(), []string{"a", "bb", "ccc"})
+		require.NoError(t, err)
+		require.Len(t, resp, 3)
+		require.Equal(t, []float32{3, 1}, resp[2].ToFloat32())
+		require.Equal(t, []string{"GET /info", "GET /info", "POST /embed", "POST /embed"}, requests)
+		require.Equal(t, []string{"a", "bb"}, bodies[0].Inputs)
+		require.Equal(t, true, *bodies[0].Truncate)
+		require.Equal(t, false, *bodies[0].Normalize)
+		require.Equal(t, TruncationDirectionLeft, bodies[0].TruncationDirection)
+		require.Equal(t, "query", bodies[0].PromptName)
+
+		_, err = ef.EmbedQuery(context.Background(), "query")
+		require.NoError(t, err)
+		require.Len(t, requests, 5, "info is discovered once")
+	})
+
+	t.Run("Test fallback without info", func(t *testing.T) {
+		requests, bodies = nil, nil
+		infoAvailable = false
+		defer func() { infoAvailable = true }()
+		ef, err := NewHuggingFaceEmbeddingInferenceFunction(server.URL)
+		require.NoError(t, err)
+		documents := make([]string, DefaultTEIMaxBatchSize+1)
+		for i := range documents {
+			documents[i] = "doc"
+		}
+		resp, err := ef.EmbedDocuments(context.Background(), documents)
+		require.NoError(t, err)
+		require.Len(t, resp, len(documents))
+		require.Len(t, bodies, 2)
+		require.Nil(t, bodies[0].Truncate)
+
+		infoAvailable = true
+		requests, bodies = nil, nil
+		_, err = ef.EmbedDocuments(context.Background(), []string{"a", "b", "c"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"GET /info", "POST /embed", "POST /embed"}, requests, "a failed /info is not cached")
+		require.Equal(t, true, *bodies[0].Truncate, "truncation defaults to max_input_length")
+	})
+
+	t.Run("Test batches are split by max batch tokens", func(t *testing.T) {
+		requests, bodies = nil, nil
+		maxBatchTokens = 10
+		defer func() { maxBatchTokens = 16384 }()
+		ef, err := NewHuggingFaceEmbeddingInferenceFunction(server.URL, WithTruncate(false))
+		require.NoError(t, err)
+		resp, err := ef.EmbedDocuments(context.Background(), []string{"aaaa", "bbbb", "cc", strings.Repeat("d", 20)})
+		require.NoError(t, err)
+		require.Len(t, resp, 4)
+		require.Len(t, bodies, 3)
+		require.Equal(t, []string{"aaaa"}, bodies[0].Inputs)
+		require.Equal(t, []string{"bbbb", "cc"}, bodies[1].Inputs)
+		require.Equal(t, []string{strings.Repeat("d", 20)}, bodies[2].Inputs, "an input over the limit is sent alone")
+		require.Equal(t, false, *bodies[0].Truncate)
+	})
+
+	t.Run("Test sparse embeddings", func(t *testing.T) {
+		requests, bodies = nil, nil
+		ef, err := NewHuggingFaceEmbeddingInferenceFunction(server.URL, WithMaxBatchSize(10))
+		require.NoError(t, err)
+		resp, err := ef.EmbedDocumentsSparse(context.Background(), []string{"a", "bb"})
+		require.NoError(t, err)
+		require.Equal(t, []int{3, 7}, resp[1].Indices)
+		require.Equal(t, []float32{2, 0.5}, resp[1].Values)
+		query, err := ef.EmbedQuerySparse(context.Background(), "q")
+		require.NoError(t, err)
+		require.Equal(t, float32(1), query.Get(3))
+		require.NotContains(t, requests, "GET /info", "batch size is configured")
+	})
+
+	t.Run("Test token embeddings", func(t *testing.T) {
+		ef, err := NewHuggingFaceEmbeddingInferenceFunction(server.URL+"/embed", WithMaxBatchSize(10))
+		require.NoError(t, err)
+		resp, err := ef.EmbedTokens(context.Background(), []string{"a", "b"})
+		require.NoError(t, err)
+		require.Len(t, resp, 2)
+		require.Len(t, resp[0], 2)
+		require.Equal(t, []float32{0, 1}, resp[1][1].ToFloat32())
+	})
+
+	t.Run("Test TEI only methods and invalid options", func(t *testing.T) {
+		ef := NewHuggingFaceEmbeddingFunction("key", "model")
+		_, err := ef.Info(context.Background())
+		require.Error(t, err)
+		_, err = ef.EmbedDocumentsSparse(context.Background(), []string{"a"})
+		require.Error(t, err)
+		_, err = NewHuggingFaceEmbeddingInferenceFunction(server.URL, WithMaxBatchSize(0))
+		require.Error(t, err)
+		_, err = NewHuggingFaceEmbeddingInferenceFunction(server.URL, WithTruncationDirection("Middle"))
+		require.Error(t, err)
+	})
+}
@@ -2,6 +2,7 @@ package hf
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 )
 
@@ -45,3 +46,63 @@ func WithDefaultHeaders(headers map[string]string) Option {
 		return nil
 	}
 }
+
+// WithNormalize sets whether a Text Embeddings Inference server normalizes embeddings. The server default is true.
+func WithNormalize(normalize bool) Option {
+	return func(p *HuggingFaceClient) error {
+		p.Normalize = &normalize
+		return nil
+	}
+}
+
+// WithTruncate sets whether a Text Embeddings Inference server truncates inputs longer than max_input_length. If false, such inputs fail.
+// By default inputs are truncated if the server reports its max_input_length in /info.
+func WithTruncate(truncate bool) Option {
+	return func(p *HuggingFaceClient) error {
+		p.Truncate = &truncate
+		return nil
+	}
+}
+
+// WithTruncationDirection sets which end of long inputs a Text Embeddings Inference server cuts off.
+func WithTruncationDirection(direction TruncationDirection) Option {
+	return func(p *HuggingFaceClient) error {
+		if direction != TruncationDirectionLeft && direction != TruncationDirectionRight {
+			return fmt.Errorf("invalid truncation direction %s", direction)
+		}
+		p.TruncationDirection = direction
+		return nil
+	}
+}
+
+// WithPromptName sets the name of a prompt from the sentence-transformers configuration of the model, prepended to the inputs by
+// a Text Embeddings Inference server.
+func WithPromptName(promptName string) Option {
+	return func(p *HuggingFaceClient) error {
+		if promptName == "" {
+			return fmt.Errorf("empty prompt name")
+		}
+		p.PromptName = promptName
+		return nil
+	}
+}
+
+// WithMaxBatchSize sets the maximum number of inputs per request to a Text Embeddings Inference server instead of discovering it from /info.
+// With a configured size /info is not requested, so batches are not split by max_batch_tokens and truncation is not defaulted.
+func WithMaxBatchSize(size int) Option {
+	return func(p *HuggingFaceClient) error {
+		if size <= 0 {
+			return fmt.Errorf("max batch size must be greater than 0")
+		}
+		p.MaxBatchSize = size
+		return nil
+	}
+}
+
+// WithLogger logs the embedding requests to the Inference API or the TEI server with the number of inputs and latency.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *HuggingFaceClient) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
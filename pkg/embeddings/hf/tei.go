@@ -0,0 +1,302 @@
+package hf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// Text Embeddings Inference (TEI) endpoints. Docs: https://huggingface.github.io/text-embeddings-inference/
+const (
+	TEIEmbedEndpoint       = "embed"
+	TEIEmbedSparseEndpoint = "embed_sparse"
+	TEIEmbedAllEndpoint    = "embed_all"
+	TEIInfoEndpoint        = "info"
+	// DefaultTEIMaxBatchSize is the default max_client_batch_size of TEI, used if /info is not available.
+	DefaultTEIMaxBatchSize = 32
+)
+
+type TruncationDirection string
+
+const (
+	TruncationDirectionLeft  TruncationDirection = "Left"
+	TruncationDirectionRight TruncationDirection = "Right"
+)
+
+// TEIRequest is the request of the TEI /embed, /embed_sparse and /embed_all endpoints. Normalize is only used by /embed.
+type TEIRequest struct {
+	Inputs              []string            `json:"inputs"`
+	Normalize           *bool               `json:"normalize,omitempty"`
+	Truncate            *bool               `json:"truncate,omitempty"`
+	TruncationDirection TruncationDirection `json:"truncation_direction,omitempty"`
+	PromptName          string              `json:"prompt_name,omitempty"`
+}
+
+// TEISparseValue is a single non-zero value of a sparse embedding returned by /embed_sparse.
+type TEISparseValue struct {
+	Index int     `json:"index"`
+	Value float32 `json:"value"`
+}
+
+// TEIInfo is the model metadata returned by /info. Fields not listed are ignored.
+type TEIInfo struct {
+	ModelID               string          `json:"model_id"`
+	ModelSHA              string          `json:"model_sha,omitempty"`
+	ModelDType            string          `json:"model_dtype"`
+	ModelType             json.RawMessage `json:"model_type,omitempty"`
+	MaxConcurrentRequests int             `json:"max_concurrent_requests"`
+	MaxInputLength        int             `json:"max_input_length"`
+	MaxBatchTokens        int             `json:"max_batch_tokens"`
+	MaxBatchRequests      *int            `json:"max_batch_requests,omitempty"`
+	MaxClientBatchSize    int             `json:"max_client_batch_size"`
+	Version               string          `json:"version"`
+}
+
+func (c *HuggingFaceClient) teiURL(endpoint string) string {
+	return strings.TrimSuffix(c.BaseURL, "/") + "/" + endpoint
+}
+
+func (c *HuggingFaceClient) teiDo(ctx context.Context, method string, endpoint string, req any, out any) error {
+	var body io.Reader
+	if req != nil {
+		reqJSON, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(reqJSON)
+	}
+	url := c.teiURL(endpoint)
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.DefaultHeaders {
+		httpReq.Header.Set(k, v)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if req != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.getAPIKey() != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.getAPIKey())
+	}
+
+	resp, err := c.Client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected code [%v] while making a request to %v: %v", resp.Status, url, string(respData))
+	}
+	return json.Unmarshal(respData, out)
+}
+
+// Info returns the model metadata of the TEI server.
+func (c *HuggingFaceClient) Info(ctx context.Context) (*TEIInfo, error) {
+	var info TEIInfo
+	if err := c.teiDo(ctx, http.MethodGet, TEIInfoEndpoint, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// teiEmbed sends the embedding request to the TEI endpoint and logs it.
+func (c *HuggingFaceClient) teiEmbed(ctx context.Context, endpoint string, req *TEIRequest, out any) (err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "huggingface-tei", c.Model, len(req.Inputs), start, err)
+	}(time.Now())
+	return c.teiDo(ctx, http.MethodPost, endpoint, req, out)
+}
+
+// Embed returns dense embeddings from the TEI /embed endpoint.
+func (c *HuggingFaceClient) Embed(ctx context.Context, req *TEIRequest) ([][]float32, error) {
+	var embeddings [][]float32
+	if err := c.teiEmbed(ctx, TEIEmbedEndpoint, req, &embeddings); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// EmbedSparse returns sparse embeddings from the TEI /embed_sparse endpoint. Requires a SPLADE model.
+func (c *HuggingFaceClient) EmbedSparse(ctx context.Context, req *TEIRequest) ([][]TEISparseValue, error) {
+	var embeddings [][]TEISparseValue
+	if err := c.teiEmbed(ctx, TEIEmbedSparseEndpoint, req, &embeddings); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// EmbedAll returns the embeddings of all tokens of each input from the TEI /embed_all endpoint.
+func (c *HuggingFaceClient) EmbedAll(ctx context.Context, req *TEIRequest) ([][][]float32, error) {
+	var embeddings [][][]float32
+	if err := c.teiEmbed(ctx, TEIEmbedAllEndpoint, req, &embeddings); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// teiRequest builds a request with the configured truncation and prompt options. If truncation is not configured and the
+// server reports its max_input_length, inputs are truncated instead of failing the request.
+func (c *HuggingFaceClient) teiRequest(inputs []string, info *TEIInfo) *TEIRequest {
+	truncate := c.Truncate
+	if truncate == nil && info != nil && info.MaxInputLength > 0 {
+		truncate = new(bool)
+		*truncate = true
+	}
+	return &TEIRequest{
+		Inputs:              inputs,
+		Truncate:            truncate,
+		TruncationDirection: c.TruncationDirection,
+		PromptName:          c.PromptName,
+	}
+}
+
+// teiInfo returns the info of the TEI server, nil if a max batch size is configured or /info is not available, e.g. behind
+// a proxy. Only a successful response is cached, a failed request is retried by the next call.
+func (c *HuggingFaceClient) teiInfo(ctx context.Context) *TEIInfo {
+	if c.MaxBatchSize > 0 {
+		return nil
+	}
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	if c.info == nil {
+		info, err := c.Info(ctx)
+		if err != nil {
+			return nil
+		}
+		c.info = info
+	}
+	return c.info
+}
+
+// teiTokens returns an upper bound of the tokens of an input, assuming at most one token per byte plus the special tokens.
+// Inputs longer than max_input_length are truncated or rejected by the server, so they count as max_input_length tokens.
+func teiTokens(input string, info *TEIInfo) int {
+	tokens := len(input) + 2
+	if info.MaxInputLength > 0 {
+		tokens = min(tokens, info.MaxInputLength)
+	}
+	return tokens
+}
+
+// teiBatches calls embed for consecutive batches of the inputs and concatenates the results. Batches have at most the
+// configured max batch size or the max_client_batch_size reported by /info (DefaultTEIMaxBatchSize if neither is known)
+// inputs, and are split further so that their estimated tokens do not exceed the max_batch_tokens of the server.
+func teiBatches[T any](ctx context.Context, c *HuggingFaceClient, inputs []string, embed func(req *TEIRequest) ([]T, error)) ([]T, error) {
+	info := c.teiInfo(ctx)
+	batchSize := c.MaxBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultTEIMaxBatchSize
+		if info != nil && info.MaxClientBatchSize > 0 {
+			batchSize = info.MaxClientBatchSize
+		}
+	}
+	results := make([]T, 0, len(inputs))
+	for start := 0; start < len(inputs); {
+		end, tokens := start, 0
+		for end < len(inputs) && end-start < batchSize {
+			if info != nil && info.MaxBatchTokens > 0 {
+				tokens += teiTokens(inputs[end], info)
+				if tokens > info.MaxBatchTokens && end > start {
+					break
+				}
+			}
+			end++
+		}
+		batch := inputs[start:end]
+		embeddings, err := embed(c.teiRequest(batch, info))
+		if err != nil {
+			return nil, err
+		}
+		if len(embeddings) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(embeddings))
+		}
+		results = append(results, embeddings...)
+		start = end
+	}
+	return results, nil
+}
+
+func (e *HuggingFaceEmbeddingFunction) teiEmbed(ctx context.Context, documents []string) ([]*types.Embedding, error) {
+	embeddings, err := teiBatches(ctx, e.apiClient, documents, func(req *TEIRequest) ([][]float32, error) {
+		req.Normalize = e.apiClient.Normalize
+		return e.apiClient.Embed(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return types.NewEmbeddingsFromFloat32(embeddings), nil
+}
+
+// Info returns the model metadata of the TEI server. Only available for embedding functions created with NewHuggingFaceEmbeddingInferenceFunction.
+func (e *HuggingFaceEmbeddingFunction) Info(ctx context.Context) (*TEIInfo, error) {
+	if !e.apiClient.tei {
+		return nil, fmt.Errorf("model info is only available for Text Embeddings Inference servers")
+	}
+	return e.apiClient.Info(ctx)
+}
+
+// EmbedDocumentsSparse returns sparse embeddings from the TEI /embed_sparse endpoint.
+func (e *HuggingFaceEmbeddingFunction) EmbedDocumentsSparse(ctx context.Context, documents []string) ([]*types.SparseEmbedding, error) {
+	if !e.apiClient.tei {
+		return nil, fmt.Errorf("sparse embeddings are only available for Text Embeddings Inference servers")
+	}
+	sparse, err := teiBatches(ctx, e.apiClient, documents, func(req *TEIRequest) ([][]TEISparseValue, error) {
+		return e.apiClient.EmbedSparse(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	embeddings := make([]*types.SparseEmbedding, len(sparse))
+	for i, values := range sparse {
+		indices := make([]int, len(values))
+		weights := make([]float32, len(values))
+		for j, v := range values {
+			indices[j] = v.Index
+			weights[j] = v.Value
+		}
+		embeddings[i], err = types.NewSparseEmbedding(indices, weights)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return embeddings, nil
+}
+
+func (e *HuggingFaceEmbeddingFunction) EmbedQuerySparse(ctx context.Context, query string) (*types.SparseEmbedding, error) {
+	embeddings, err := e.EmbedDocumentsSparse(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedTokens returns the embeddings of all tokens of each document from the TEI /embed_all endpoint, e.g. for late interaction models.
+func (e *HuggingFaceEmbeddingFunction) EmbedTokens(ctx context.Context, documents []string) ([][]*types.Embedding, error) {
+	if !e.apiClient.tei {
+		return nil, fmt.Errorf("token embeddings are only available for Text Embeddings Inference servers")
+	}
+	all, err := teiBatches(ctx, e.apiClient, documents, func(req *TEIRequest) ([][][]float32, error) {
+		return e.apiClient.EmbedAll(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	embeddings := make([][]*types.Embedding, len(all))
+	for i, tokens := range all {
+		embeddings[i] = types.NewEmbeddingsFromFloat32(tokens)
+	}
+	return embeddings, nil
+}
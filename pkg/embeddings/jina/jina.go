@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	chttp "github.com/szirtesitidom/chroma-go/pkg/commons/http"
 	"github.com/szirtesitidom/chroma-go/types"
@@ -42,6 +43,7 @@ type EmbeddingResponse struct {
 }
 
 var _ types.EmbeddingFunction = (*JinaEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*JinaEmbeddingFunction)(nil)
 
 func getDefaults() *JinaEmbeddingFunction {
 	return &JinaEmbeddingFunction{
@@ -54,6 +56,8 @@ func getDefaults() *JinaEmbeddingFunction {
 }
 
 type JinaEmbeddingFunction struct {
+	types.UsageTracker
+	types.RequestLogger
 	httpClient        *http.Client
 	apiKey            string
 	defaultModel      types.EmbeddingModel
@@ -73,13 +77,16 @@ func NewJinaEmbeddingFunction(opts ...Option) (*JinaEmbeddingFunction, error) {
 	return ef, nil
 }
 
-func (e *JinaEmbeddingFunction) sendRequest(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+func (e *JinaEmbeddingFunction) sendRequest(ctx context.Context, req *EmbeddingRequest) (_ *EmbeddingResponse, err error) {
+	defer func(start time.Time) {
+		e.LogRequest(ctx, "jina", req.Model, len(req.Input), start, err)
+	}(time.Now())
 	payload, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequest("POST", e.embeddingEndpoint, bytes.NewBuffer(payload))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", e.embeddingEndpoint, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +114,17 @@ func (e *JinaEmbeddingFunction) sendRequest(ctx context.Context, req *EmbeddingR
 	if err := json.Unmarshal(respData, &response); err != nil {
 		return nil, err
 	}
-
+	model := response.Model
+	if model == "" {
+		model = req.Model
+	}
+	e.RecordUsage(ctx, types.Usage{
+		Provider:     "jina",
+		Model:        model,
+		PromptTokens: response.Usage.PromptTokens,
+		TotalTokens:  response.Usage.TotalTokens,
+		Requests:     1,
+	})
 	return response, nil
 }
 
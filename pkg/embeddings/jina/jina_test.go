@@ -4,12 +4,16 @@ package jina
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/szirtesitidom/chroma-go/types"
 )
 
 func TestJinaEmbeddingFunction(t *testing.T) {
@@ -101,3 +105,22 @@ func TestJinaEmbeddingFunction(t *testing.T) {
 		require.Equal(t, 768, resp[0].Len())
 	})
 }
+
+func TestJinaUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"model":"jina-embeddings-v2-base-en","object":"list","usage":{"total_tokens":9,"prompt_tokens":9},"data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	ef, err := NewJinaEmbeddingFunction(WithAPIKey("test"), WithEmbeddingEndpoint(server.URL))
+	require.NoError(t, err)
+	collector := types.NewUsageCollector()
+	ctx := types.ContextWithUsageCollector(context.Background(), collector)
+	_, err = ef.EmbedDocuments(ctx, []string{"a"})
+	require.NoError(t, err)
+	_, err = ef.EmbedQuery(ctx, "b")
+	require.NoError(t, err)
+	require.Equal(t, types.Usage{Provider: "jina", Model: "jina-embeddings-v2-base-en", PromptTokens: 18, TotalTokens: 18, Requests: 2}, collector.Total())
+	require.Equal(t, collector.Total(), ef.TotalUsage())
+}
@@ -2,6 +2,7 @@ package jina
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/szirtesitidom/chroma-go/types"
@@ -55,3 +56,11 @@ func WithEmbeddingType(embeddingType EmbeddingType) Option {
 		return nil
 	}
 }
+
+// WithLogger logs the requests to the Jina embeddings API. Without a logger nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *JinaEmbeddingFunction) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
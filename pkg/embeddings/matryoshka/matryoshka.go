@@ -0,0 +1,86 @@
+package matryoshka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+type Option func(*MatryoshkaEmbeddingFunction) error
+
+// WithNormalize controls whether the truncated embeddings are re-normalized to unit length. Defaults to true.
+func WithNormalize(normalize bool) Option {
+	return func(e *MatryoshkaEmbeddingFunction) error {
+		e.normalize = normalize
+		return nil
+	}
+}
+
+var _ types.EmbeddingFunction = (*MatryoshkaEmbeddingFunction)(nil)
+
+// MatryoshkaEmbeddingFunction truncates the embeddings of the wrapped embedding function to the first Dimension values.
+// This is only meaningful for models trained with Matryoshka representation learning, where the leading dimensions carry most of the information.
+type MatryoshkaEmbeddingFunction struct {
+	ef        types.EmbeddingFunction
+	Dimension int
+	normalize bool
+}
+
+func NewMatryoshkaEmbeddingFunction(ef types.EmbeddingFunction, dimension int, opts ...Option) (*MatryoshkaEmbeddingFunction, error) {
+	if ef == nil {
+		return nil, fmt.Errorf("embedding function must not be nil")
+	}
+	if dimension < 1 {
+		return nil, fmt.Errorf("dimension must be greater than 0")
+	}
+	e := &MatryoshkaEmbeddingFunction{ef: ef, Dimension: dimension, normalize: true}
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// truncate returns the first Dimension values of the embedding, normalized if enabled.
+func (e *MatryoshkaEmbeddingFunction) truncate(embedding *types.Embedding) (*types.Embedding, error) {
+	if embedding == nil || !embedding.IsDefined() {
+		return nil, &types.EmptyEmbeddingError{}
+	}
+	if embedding.Len() < e.Dimension {
+		return nil, fmt.Errorf("cannot truncate embedding of dimension %d to %d", embedding.Len(), e.Dimension)
+	}
+	truncated := types.NewEmbeddingFromFloat32(append([]float32{}, embedding.ToFloat32()[:e.Dimension]...))
+	if !e.normalize {
+		return truncated, nil
+	}
+	return truncated.Normalize()
+}
+
+func (e *MatryoshkaEmbeddingFunction) EmbedDocuments(ctx context.Context, documents []string) ([]*types.Embedding, error) {
+	embeddings, err := e.ef.EmbedDocuments(ctx, documents)
+	if err != nil {
+		return nil, err
+	}
+	truncated := make([]*types.Embedding, len(embeddings))
+	for i, embedding := range embeddings {
+		truncated[i], err = e.truncate(embedding)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return truncated, nil
+}
+
+func (e *MatryoshkaEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
+	embedding, err := e.ef.EmbedQuery(ctx, document)
+	if err != nil {
+		return nil, err
+	}
+	return e.truncate(embedding)
+}
+
+func (e *MatryoshkaEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
+}
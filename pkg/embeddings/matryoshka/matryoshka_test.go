@@ -0,0 +1,55 @@
+//go:build ef
+
+package matryoshka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+func TestMatryoshkaEmbeddingFunction(t *testing.T) {
+	base := types.NewConsistentHashEmbeddingFunction()
+
+	t.Run("Test truncate and normalize", func(t *testing.T) {
+		ef, err := NewMatryoshkaEmbeddingFunction(base, 64)
+		require.NoError(t, err)
+		embeddings, err := ef.EmbedDocuments(context.Background(), []string{"hello", "world"})
+		require.NoError(t, err)
+		require.Len(t, embeddings, 2)
+		for _, embedding := range embeddings {
+			require.Equal(t, 64, embedding.Len())
+			require.InDelta(t, 1, embedding.Norm(), 1e-5)
+		}
+		query, err := ef.EmbedQuery(context.Background(), "hello")
+		require.NoError(t, err)
+		require.True(t, query.Compare(embeddings[0]))
+	})
+
+	t.Run("Test truncate without normalization keeps prefix", func(t *testing.T) {
+		ef, err := NewMatryoshkaEmbeddingFunction(base, 8, WithNormalize(false))
+		require.NoError(t, err)
+		full, err := base.EmbedQuery(context.Background(), "hello")
+		require.NoError(t, err)
+		truncated, err := ef.EmbedQuery(context.Background(), "hello")
+		require.NoError(t, err)
+		require.Equal(t, (*full.ArrayOfFloat32)[:8], *truncated.ArrayOfFloat32)
+	})
+
+	t.Run("Test dimension larger than embedding", func(t *testing.T) {
+		ef, err := NewMatryoshkaEmbeddingFunction(base, 4096)
+		require.NoError(t, err)
+		_, err = ef.EmbedQuery(context.Background(), "hello")
+		require.Error(t, err)
+	})
+
+	t.Run("Test invalid arguments", func(t *testing.T) {
+		_, err := NewMatryoshkaEmbeddingFunction(nil, 8)
+		require.Error(t, err)
+		_, err = NewMatryoshkaEmbeddingFunction(base, 0)
+		require.Error(t, err)
+	})
+}
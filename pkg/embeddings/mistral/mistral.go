@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/szirtesitidom/chroma-go/types"
 )
@@ -30,6 +31,7 @@ type Client struct {
 	MaxBatchSize      int
 	EmbeddingEndpoint string
 	DefaultHeaders    map[string]string
+	types.RequestLogger
 }
 
 func applyDefaults(c *Client) (err error) {
@@ -108,6 +110,25 @@ func (c *CreateEmbeddingRequest) JSON() (string, error) {
 }
 
 func (c *Client) CreateEmbedding(ctx context.Context, req CreateEmbeddingRequest) ([]*types.Embedding, error) {
+	response, err := c.createEmbedding(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.embeddings(), nil
+}
+
+func (r *CreateEmbeddingResponse) embeddings() []*types.Embedding {
+	embeddings := make([]*types.Embedding, len(r.Data))
+	for i, e := range r.Data {
+		embeddings[i] = types.NewEmbeddingFromFloat32(e.Embedding)
+	}
+	return embeddings
+}
+
+func (c *Client) createEmbedding(ctx context.Context, req CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "mistral", req.Model, len(req.Input), start, err)
+	}(time.Now())
 	reqJSON, err := req.JSON()
 	if err != nil {
 		return nil, err
@@ -142,16 +163,14 @@ func (c *Client) CreateEmbedding(ctx context.Context, req CreateEmbeddingRequest
 	if err := json.Unmarshal(respData, &embeddingResponse); err != nil {
 		return nil, err
 	}
-	embeddings := make([]*types.Embedding, len(embeddingResponse.Data))
-	for i, e := range embeddingResponse.Data {
-		embeddings[i] = types.NewEmbeddingFromFloat32(e.Embedding)
-	}
-	return embeddings, nil
+	return &embeddingResponse, nil
 }
 
 var _ types.EmbeddingFunction = (*MistralEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*MistralEmbeddingFunction)(nil)
 
 type MistralEmbeddingFunction struct {
+	types.UsageTracker
 	apiClient *Client
 }
 
@@ -182,11 +201,12 @@ func (e *MistralEmbeddingFunction) EmbedDocuments(ctx context.Context, documents
 		Model: model,
 		Input: documents,
 	}
-	response, err := e.apiClient.CreateEmbedding(ctx, req)
+	response, err := e.apiClient.createEmbedding(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	return response, nil
+	e.RecordUsage(ctx, types.NewUsageFromMap("mistral", model, response.Usage))
+	return response.embeddings(), nil
 }
 
 func (e *MistralEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
@@ -198,11 +218,16 @@ func (e *MistralEmbeddingFunction) EmbedQuery(ctx context.Context, document stri
 		Model: model,
 		Input: []string{document},
 	}
-	response, err := e.apiClient.CreateEmbedding(ctx, req)
+	response, err := e.apiClient.createEmbedding(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	return response[0], nil
+	e.RecordUsage(ctx, types.NewUsageFromMap("mistral", model, response.Usage))
+	embeddings := response.embeddings()
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+	return embeddings[0], nil
 }
 
 func (e *MistralEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
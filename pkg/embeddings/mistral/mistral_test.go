@@ -8,9 +8,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 	"time"
+
+	"github.com/szirtesitidom/chroma-go/types"
 )
 
 func Test_mistral_client(t *testing.T) {
@@ -139,3 +143,36 @@ func Test_mistral_embedding_function(t *testing.T) {
 		time.Sleep(2 * time.Second)
 	})
 }
+
+func Test_mistral_usage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"id":"1","object":"list","model":"mistral-embed","usage":{"prompt_tokens":6,"total_tokens":6,"completion_tokens":0},"data":[{"embedding":[0.1,0.2],"index":0},{"embedding":[0.3,0.4],"index":1}]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	// the embedding endpoint is always the default one, send its requests to the test server
+	client := &http.Client{Transport: rewriteHostTransport{host: serverURL.Host}}
+	ef, err := NewMistralEmbeddingFunction(WithAPIKey("test"), WithHTTPClient(client))
+	require.NoError(t, err)
+	collector := types.NewUsageCollector()
+	ctx := types.ContextWithUsageCollector(context.Background(), collector)
+	embeddings, err := ef.EmbedDocuments(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Len(t, embeddings, 2)
+	require.Equal(t, []types.Usage{{Provider: "mistral", Model: DefaultEmbeddingModel, PromptTokens: 6, TotalTokens: 6, Requests: 1}}, collector.Records())
+	require.Equal(t, collector.Total(), ef.TotalUsage())
+}
+
+type rewriteHostTransport struct {
+	host string
+}
+
+func (r rewriteHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = r.host
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -2,6 +2,7 @@ package mistral
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 )
@@ -67,3 +68,11 @@ func WithBaseURL(baseURL string) Option {
 		return nil
 	}
 }
+
+// WithLogger sets the logger of the embedding requests. Requests are logged at debug level, failures as warnings.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
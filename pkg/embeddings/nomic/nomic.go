@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/szirtesitidom/chroma-go/types"
 )
@@ -44,6 +45,7 @@ type Client struct {
 	DefaultDimensionality    *int
 	BaseURL                  string
 	EmbeddingsEndpointSuffix string
+	types.RequestLogger
 }
 
 func applyDefaults(c *Client) (err error) {
@@ -119,6 +121,25 @@ func (c *CreateEmbeddingRequest) JSON() (string, error) {
 }
 
 func (c *Client) CreateEmbedding(ctx context.Context, req CreateEmbeddingRequest) ([]*types.Embedding, error) {
+	response, err := c.createEmbedding(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.embeddings(), nil
+}
+
+func (r *CreateEmbeddingResponse) embeddings() []*types.Embedding {
+	embeddings := make([]*types.Embedding, len(r.Embeddings))
+	for i, e := range r.Embeddings {
+		embeddings[i] = types.NewEmbeddingFromFloat32(e)
+	}
+	return embeddings
+}
+
+func (c *Client) createEmbedding(ctx context.Context, req CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "nomic", req.Model, len(req.Texts), start, err)
+	}(time.Now())
 	reqJSON, err := req.JSON()
 	if err != nil {
 		return nil, err
@@ -153,16 +174,14 @@ func (c *Client) CreateEmbedding(ctx context.Context, req CreateEmbeddingRequest
 	if err := json.Unmarshal(respData, &embeddingResponse); err != nil {
 		return nil, err
 	}
-	embeddings := make([]*types.Embedding, len(embeddingResponse.Embeddings))
-	for i, e := range embeddingResponse.Embeddings {
-		embeddings[i] = types.NewEmbeddingFromFloat32(e)
-	}
-	return embeddings, nil
+	return &embeddingResponse, nil
 }
 
 var _ types.EmbeddingFunction = (*NomicEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*NomicEmbeddingFunction)(nil)
 
 type NomicEmbeddingFunction struct {
+	types.UsageTracker
 	apiClient *Client
 }
 
@@ -203,11 +222,12 @@ func (e *NomicEmbeddingFunction) EmbedDocuments(ctx context.Context, documents [
 		Dimensionality: dimensionality,
 		TaskType:       &taskType,
 	}
-	response, err := e.apiClient.CreateEmbedding(ctx, req)
+	response, err := e.apiClient.createEmbedding(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	return response, nil
+	e.RecordUsage(ctx, types.NewUsageFromMap("nomic", model, response.Usage))
+	return response.embeddings(), nil
 }
 
 func (e *NomicEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
@@ -229,11 +249,16 @@ func (e *NomicEmbeddingFunction) EmbedQuery(ctx context.Context, document string
 		Dimensionality: dimensionality,
 		TaskType:       &taskType,
 	}
-	response, err := e.apiClient.CreateEmbedding(ctx, req)
+	response, err := e.apiClient.createEmbedding(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	return response[0], nil
+	e.RecordUsage(ctx, types.NewUsageFromMap("nomic", model, response.Usage))
+	embeddings := response.embeddings()
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+	return embeddings[0], nil
 }
 
 func (e *NomicEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
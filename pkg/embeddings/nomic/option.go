@@ -2,6 +2,7 @@ package mistral
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -79,3 +80,11 @@ func WithTextEmbeddings() Option {
 		return nil
 	}
 }
+
+// WithLogger logs the requests to the Nomic API with the model, number of texts and latency.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
@@ -8,23 +8,48 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/szirtesitidom/chroma-go/types"
 )
 
+const (
+	DefaultMaxBatchSize = 64
+	EmbedEndpoint       = "api/embed"
+	ShowEndpoint        = "api/show"
+	PullEndpoint        = "api/pull"
+)
+
 type OllamaClient struct {
 	BaseURL        string
 	Model          string
 	Client         *http.Client
 	DefaultHeaders map[string]string
+	MaxBatchSize   int
+	Truncate       *bool
+	KeepAlive      string
+	Options        map[string]any
+	ensureModel    bool
+	types.RequestLogger
 }
+
 type CreateEmbeddingRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
+	Model string   `json:"model"`
+	Input []string `json:"input,omitempty"`
+	// Prompt is the single input of the legacy /api/embeddings endpoint. If set and Input is empty it is sent as Input.
+	Prompt    string         `json:"-"`
+	Truncate  *bool          `json:"truncate,omitempty"`
+	KeepAlive string         `json:"keep_alive,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
 }
 
+// CreateEmbeddingResponse is the response of /api/embed (embeddings) or of the legacy /api/embeddings endpoint (embedding).
 type CreateEmbeddingResponse struct {
-	Embedding []float32 `json:"embedding"`
+	Model           string      `json:"model,omitempty"`
+	Embeddings      [][]float32 `json:"embeddings,omitempty"`
+	Embedding       []float32   `json:"embedding,omitempty"`
+	PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
 }
 
 func (c *CreateEmbeddingRequest) JSON() (string, error) {
@@ -35,9 +60,21 @@ func (c *CreateEmbeddingRequest) JSON() (string, error) {
 	return string(data), nil
 }
 
+// AllEmbeddings returns the embeddings of the response regardless of its shape.
+func (r *CreateEmbeddingResponse) AllEmbeddings() [][]float32 {
+	if len(r.Embeddings) > 0 {
+		return r.Embeddings
+	}
+	if len(r.Embedding) > 0 {
+		return [][]float32{r.Embedding}
+	}
+	return nil
+}
+
 func NewOllamaClient(opts ...Option) (*OllamaClient, error) {
 	client := &OllamaClient{
-		Client: &http.Client{},
+		Client:       &http.Client{},
+		MaxBatchSize: DefaultMaxBatchSize,
 	}
 	for _, opt := range opts {
 		err := opt(client)
@@ -48,21 +85,23 @@ func NewOllamaClient(opts ...Option) (*OllamaClient, error) {
 	return client, nil
 }
 
-func (c *OllamaClient) createEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (*CreateEmbeddingResponse, error) {
-	reqJSON, err := req.JSON()
-	if err != nil {
-		return nil, err
-	}
-	var url string
+func (c *OllamaClient) url(endpoint string) string {
 	if !strings.HasSuffix(c.BaseURL, "/") {
-		url = c.BaseURL + "/api/embed"
-	} else {
-		url = c.BaseURL + "api/embed"
+		return c.BaseURL + "/" + endpoint
 	}
+	return c.BaseURL + endpoint
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBufferString(reqJSON))
+// post sends the JSON request to the endpoint and decodes the JSON response into out if it is not nil. Returns the status code.
+func (c *OllamaClient) post(ctx context.Context, endpoint string, req any, out any) (int, error) {
+	reqJSON, err := json.Marshal(req)
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	url := c.url(endpoint)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqJSON))
+	if err != nil {
+		return 0, err
 	}
 	for k, v := range c.DefaultHeaders {
 		httpReq.Header.Set(k, v)
@@ -72,31 +111,87 @@ func (c *OllamaClient) createEmbedding(ctx context.Context, req *CreateEmbedding
 
 	resp, err := c.Client.Do(httpReq)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected code [%v] while making a request to %v", resp.Status, url)
-	}
-
+	defer resp.Body.Close()
 	respData, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("unexpected code [%v] while making a request to %v: %v", resp.Status, url, string(respData))
+	}
+	if out == nil {
+		return resp.StatusCode, nil
 	}
+	return resp.StatusCode, json.Unmarshal(respData, out)
+}
 
+func (c *OllamaClient) createEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
+	if len(req.Input) == 0 && req.Prompt != "" {
+		req.Input = []string{req.Prompt}
+	}
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "ollama", req.Model, len(req.Input), start, err)
+	}(time.Now())
 	var embeddingResponse CreateEmbeddingResponse
-	if err := json.Unmarshal(respData, &embeddingResponse); err != nil {
+	if _, err := c.post(ctx, EmbedEndpoint, req, &embeddingResponse); err != nil {
 		return nil, err
 	}
 	return &embeddingResponse, nil
 }
 
+// HasModel returns true if the model is available on the Ollama server.
+func (c *OllamaClient) HasModel(ctx context.Context, model string) (bool, error) {
+	status, err := c.post(ctx, ShowEndpoint, map[string]string{"model": model}, nil)
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PullModel downloads the model to the Ollama server and waits until the download is complete.
+func (c *OllamaClient) PullModel(ctx context.Context, model string) error {
+	var pullResponse struct {
+		Status string `json:"status"`
+	}
+	if _, err := c.post(ctx, PullEndpoint, map[string]any{"model": model, "stream": false}, &pullResponse); err != nil {
+		return fmt.Errorf("failed to pull model %s: %w", model, err)
+	}
+	if pullResponse.Status != "success" {
+		return fmt.Errorf("failed to pull model %s: %s", model, pullResponse.Status)
+	}
+	return nil
+}
+
+// EnsureModel pulls the model if it is not available on the Ollama server.
+func (c *OllamaClient) EnsureModel(ctx context.Context, model string) error {
+	ok, err := c.HasModel(ctx, model)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	c.Logger().InfoContext(ctx, "pulling model", "provider", "ollama", "model", model)
+	return c.PullModel(ctx, model)
+}
+
 type OllamaEmbeddingFunction struct {
+	types.UsageTracker
 	apiClient *OllamaClient
+	ensureMu  sync.Mutex
+	ensured   bool
 }
 
 var _ types.EmbeddingFunction = (*OllamaEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*OllamaEmbeddingFunction)(nil)
 
+// NewOllamaEmbeddingFunction creates an embedding function for the Ollama /api/embed endpoint.
+// Use WithEnsureModel to pull the model before the first request if the server does not have it.
 func NewOllamaEmbeddingFunction(option ...Option) (*OllamaEmbeddingFunction, error) {
 	client, err := NewOllamaClient(option...)
 	if err != nil {
@@ -107,30 +202,74 @@ func NewOllamaEmbeddingFunction(option ...Option) (*OllamaEmbeddingFunction, err
 	}, nil
 }
 
+// EnsureModel pulls the model of the embedding function if the Ollama server does not have it.
+func (e *OllamaEmbeddingFunction) EnsureModel(ctx context.Context) error {
+	e.ensureMu.Lock()
+	defer e.ensureMu.Unlock()
+	if e.ensured {
+		return nil
+	}
+	if err := e.apiClient.EnsureModel(ctx, e.apiClient.Model); err != nil {
+		return err
+	}
+	e.ensured = true
+	return nil
+}
+
+func (e *OllamaEmbeddingFunction) embed(ctx context.Context, input []string) ([][]float32, error) {
+	if e.apiClient.ensureModel {
+		if err := e.EnsureModel(ctx); err != nil {
+			return nil, err
+		}
+	}
+	response, err := e.apiClient.createEmbedding(ctx, &CreateEmbeddingRequest{
+		Model:     e.apiClient.Model,
+		Input:     input,
+		Truncate:  e.apiClient.Truncate,
+		KeepAlive: e.apiClient.KeepAlive,
+		Options:   e.apiClient.Options,
+	})
+	if err != nil {
+		return nil, err
+	}
+	embeddings := response.AllEmbeddings()
+	if len(embeddings) != len(input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(input), len(embeddings))
+	}
+	e.RecordUsage(ctx, types.Usage{
+		Provider:     "ollama",
+		Model:        e.apiClient.Model,
+		PromptTokens: response.PromptEvalCount,
+		TotalTokens:  response.PromptEvalCount,
+		Requests:     1,
+	})
+	return embeddings, nil
+}
+
+// EmbedDocuments embeds the documents in batches of at most MaxBatchSize documents per request.
 func (e *OllamaEmbeddingFunction) EmbedDocuments(ctx context.Context, documents []string) ([]*types.Embedding, error) {
-	embeddings := make([]*types.Embedding, 0)
-	for _, document := range documents {
-		response, err := e.apiClient.createEmbedding(ctx, &CreateEmbeddingRequest{
-			Model:  e.apiClient.Model,
-			Prompt: document,
-		})
+	embeddings := make([]*types.Embedding, 0, len(documents))
+	batchSize := e.apiClient.MaxBatchSize
+	if batchSize <= 0 {
+		batchSize = len(documents)
+	}
+	for start := 0; start < len(documents); start += batchSize {
+		end := min(start+batchSize, len(documents))
+		batch, err := e.embed(ctx, documents[start:end])
 		if err != nil {
 			return nil, err
 		}
-		embeddings = append(embeddings, types.NewEmbeddingFromFloat32(response.Embedding))
+		embeddings = append(embeddings, types.NewEmbeddingsFromFloat32(batch)...)
 	}
 	return embeddings, nil
 }
 
 func (e *OllamaEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
-	response, err := e.apiClient.createEmbedding(ctx, &CreateEmbeddingRequest{
-		Model:  e.apiClient.Model,
-		Prompt: document,
-	})
+	embeddings, err := e.embed(ctx, []string{document})
 	if err != nil {
 		return nil, err
 	}
-	return types.NewEmbeddingFromFloat32(response.Embedding), nil
+	return types.NewEmbeddingFromFloat32(embeddings[0]), nil
 }
 
 func (e *OllamaEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
@@ -4,8 +4,12 @@ package ollama
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -47,3 +51,107 @@ func Test_ollama(t *testing.T) {
 		require.Len(t, resp, 2)
 	})
 }
+
+func Test_ollama_batch(t *testing.T) {
+	var requests []map[string]any
+	var pulled, hasModel bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/show":
+			if !hasModel {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":"model not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		case "/api/pull":
+			require.Equal(t, false, body["stream"])
+			pulled, hasModel = true, true
+			_, _ = w.Write([]byte(`{"status":"success"}`))
+		case "/api/embed":
+			requests = append(requests, body)
+			input := body["input"].([]any)
+			if len(input) == 1 {
+				// legacy single embedding shape
+				_, _ = w.Write([]byte(`{"embedding":[1,2]}`))
+				return
+			}
+			embeddings := make([][]float32, len(input))
+			for i := range input {
+				embeddings[i] = []float32{float32(i), 1}
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"model": body["model"], "embeddings": embeddings, "prompt_eval_count": len(input)}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("Test batches and request options", func(t *testing.T) {
+		requests = nil
+		ef, err := NewOllamaEmbeddingFunction(
+			WithBaseURL(server.URL),
+			WithModel("nomic-embed-text"),
+			WithMaxBatchSize(2),
+			WithTruncate(false),
+			WithKeepAlive(5*time.Minute),
+			WithModelOptions(map[string]any{"num_ctx": 2048}),
+		)
+		require.NoError(t, err)
+		resp, err := ef.EmbedDocuments(context.Background(), []string{"a", "b", "c", "d", "e"})
+		require.NoError(t, err)
+		require.Len(t, resp, 5)
+		require.Len(t, requests, 3)
+		require.Equal(t, []any{"a", "b"}, requests[0]["input"])
+		require.Equal(t, []any{"e"}, requests[2]["input"])
+		require.Equal(t, false, requests[0]["truncate"])
+		require.Equal(t, "5m0s", requests[0]["keep_alive"])
+		require.Equal(t, map[string]any{"num_ctx": float64(2048)}, requests[0]["options"])
+		require.Equal(t, []float32{1, 1}, resp[1].ToFloat32())
+		require.Equal(t, []float32{1, 2}, resp[4].ToFloat32())
+		require.Equal(t, 4, ef.TotalUsage().PromptTokens)
+		require.Equal(t, 3, ef.TotalUsage().Requests)
+	})
+
+	t.Run("Test query", func(t *testing.T) {
+		requests = nil
+		ef, err := NewOllamaEmbeddingFunction(WithBaseURL(server.URL+"/"), WithModel("nomic-embed-text"))
+		require.NoError(t, err)
+		resp, err := ef.EmbedQuery(context.Background(), "query")
+		require.NoError(t, err)
+		require.Equal(t, []float32{1, 2}, resp.ToFloat32())
+		require.NotContains(t, requests[0], "truncate")
+		require.NotContains(t, requests[0], "keep_alive")
+	})
+
+	t.Run("Test ensure model pulls missing model once", func(t *testing.T) {
+		pulled, hasModel = false, false
+		ef, err := NewOllamaEmbeddingFunction(WithBaseURL(server.URL), WithModel("nomic-embed-text"), WithEnsureModel())
+		require.NoError(t, err)
+		_, err = ef.EmbedDocuments(context.Background(), []string{"a", "b"})
+		require.NoError(t, err)
+		require.True(t, pulled)
+		pulled = false
+		_, err = ef.EmbedQuery(context.Background(), "query")
+		require.NoError(t, err)
+		require.False(t, pulled)
+	})
+
+	t.Run("Test ensure model with existing model", func(t *testing.T) {
+		pulled, hasModel = false, true
+		client, err := NewOllamaClient(WithBaseURL(server.URL))
+		require.NoError(t, err)
+		require.NoError(t, client.EnsureModel(context.Background(), "nomic-embed-text"))
+		require.False(t, pulled)
+	})
+
+	t.Run("Test invalid options", func(t *testing.T) {
+		_, err := NewOllamaEmbeddingFunction(WithMaxBatchSize(0))
+		require.Error(t, err)
+		_, err = NewOllamaEmbeddingFunction(WithModelOptions(nil))
+		require.Error(t, err)
+	})
+}
@@ -1,5 +1,11 @@
 package ollama
 
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
 type Option func(p *OllamaClient) error
 
 func WithBaseURL(baseURL string) Option {
@@ -8,9 +14,66 @@ func WithBaseURL(baseURL string) Option {
 		return nil
 	}
 }
+
 func WithModel(model string) Option {
 	return func(p *OllamaClient) error {
 		p.Model = model
 		return nil
 	}
 }
+
+// WithMaxBatchSize sets the maximum number of documents sent in one request. Defaults to DefaultMaxBatchSize.
+func WithMaxBatchSize(size int) Option {
+	return func(p *OllamaClient) error {
+		if size <= 0 {
+			return fmt.Errorf("max batch size must be greater than 0")
+		}
+		p.MaxBatchSize = size
+		return nil
+	}
+}
+
+// WithTruncate sets whether Ollama truncates inputs that exceed the context length of the model. If false, such inputs return an error.
+// The server default is to truncate.
+func WithTruncate(truncate bool) Option {
+	return func(p *OllamaClient) error {
+		p.Truncate = &truncate
+		return nil
+	}
+}
+
+// WithKeepAlive sets how long the model stays loaded after a request. A negative duration keeps it loaded indefinitely, zero unloads it immediately.
+func WithKeepAlive(keepAlive time.Duration) Option {
+	return func(p *OllamaClient) error {
+		p.KeepAlive = keepAlive.String()
+		return nil
+	}
+}
+
+// WithModelOptions sets model parameters such as num_ctx or num_thread sent as options with every request.
+func WithModelOptions(options map[string]any) Option {
+	return func(p *OllamaClient) error {
+		if options == nil {
+			return fmt.Errorf("model options cannot be nil")
+		}
+		p.Options = options
+		return nil
+	}
+}
+
+// WithEnsureModel pulls the model before the first embedding request if the Ollama server does not have it.
+func WithEnsureModel() Option {
+	return func(p *OllamaClient) error {
+		p.ensureModel = true
+		return nil
+	}
+}
+
+// WithLogger logs the embedding requests with model, batch size and latency, and model pulls of WithEnsureModel. Nothing is
+// logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *OllamaClient) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/szirtesitidom/chroma-go/types"
 )
@@ -20,8 +22,14 @@ const (
 	TextEmbeddingAda002  EmbeddingModel = "text-embedding-ada-002"
 	TextEmbedding3Small  EmbeddingModel = "text-embedding-3-small"
 	TextEmbedding3Large  EmbeddingModel = "text-embedding-3-large"
+	// DefaultAzureAPIVersion is the Azure OpenAI REST API version used when none is set with WithAzureAPIVersion.
+	DefaultAzureAPIVersion = "2024-02-01"
 )
 
+// TokenProvider returns a bearer token for a request, e.g. a Microsoft Entra ID token for Azure OpenAI.
+// It is called for every request, so it should cache tokens until they expire.
+type TokenProvider func(ctx context.Context) (string, error)
+
 type Input struct {
 	Text                 string   `json:"-"`
 	Texts                []string `json:"-"`
@@ -29,6 +37,20 @@ type Input struct {
 	ListOfListOfIntegers [][]int  `json:"-"`
 }
 
+// size returns the number of inputs.
+func (i *Input) size() int {
+	switch {
+	case i == nil:
+		return 0
+	case i.Texts != nil:
+		return len(i.Texts)
+	case i.ListOfListOfIntegers != nil:
+		return len(i.ListOfListOfIntegers)
+	default:
+		return 1
+	}
+}
+
 func (i *Input) MarshalJSON() ([]byte, error) {
 	switch {
 	case i.Text != "":
@@ -94,6 +116,11 @@ type OpenAIClient struct {
 	Client     *http.Client
 	Model      string
 	Dimensions *int
+	// AzureDeployment is the Azure OpenAI deployment name. If set, BaseURL is the Azure OpenAI resource endpoint.
+	AzureDeployment string
+	AzureAPIVersion string
+	TokenProvider   TokenProvider
+	types.RequestLogger
 }
 
 func applyDefaults(c *OpenAIClient) {
@@ -103,6 +130,9 @@ func applyDefaults(c *OpenAIClient) {
 	if !strings.HasSuffix(c.BaseURL, "/") {
 		c.BaseURL += "/"
 	}
+	if c.AzureDeployment != "" && c.AzureAPIVersion == "" {
+		c.AzureAPIVersion = DefaultAzureAPIVersion
+	}
 }
 
 func NewOpenAIClient(apiKey string, opts ...Option) (*OpenAIClient, error) {
@@ -132,6 +162,37 @@ func (c *OpenAIClient) SetBaseURL(baseURL string) {
 	c.BaseURL = baseURL
 }
 
+// IsAzure returns true if the client sends requests to an Azure OpenAI deployment.
+func (c *OpenAIClient) IsAzure() bool {
+	return c.AzureDeployment != ""
+}
+
+// embeddingsURL returns BaseURL/embeddings for OpenAI and BaseURL/openai/deployments/{deployment}/embeddings?api-version=... for Azure.
+func (c *OpenAIClient) embeddingsURL() string {
+	if !c.IsAzure() {
+		return c.BaseURL + "embeddings"
+	}
+	return c.BaseURL + "openai/deployments/" + url.PathEscape(c.AzureDeployment) + "/embeddings?api-version=" + url.QueryEscape(c.AzureAPIVersion)
+}
+
+// setAuthHeaders sets a bearer token from the token provider if there is one, otherwise the API key as api-key header for Azure
+// and as bearer token for OpenAI.
+func (c *OpenAIClient) setAuthHeaders(ctx context.Context, httpReq *http.Request) error {
+	switch {
+	case c.TokenProvider != nil:
+		token, err := c.TokenProvider(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get token: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	case c.IsAzure():
+		httpReq.Header.Set("api-key", c.getAPIKey())
+	default:
+		httpReq.Header.Set("Authorization", "Bearer "+c.getAPIKey())
+	}
+	return nil
+}
+
 func (c *OpenAIClient) getAPIKey() string {
 	if c.APIKey == "" {
 		panic("API Key not set")
@@ -139,22 +200,35 @@ func (c *OpenAIClient) getAPIKey() string {
 	return c.APIKey
 }
 
-func (c *OpenAIClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (*CreateEmbeddingResponse, error) {
+// provider returns the provider name used for usage and logs.
+func (c *OpenAIClient) provider() string {
+	if c.IsAzure() {
+		return "azure-openai"
+	}
+	return "openai"
+}
+
+func (c *OpenAIClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
 	if req.Model == "" {
 		req.Model = c.Model
 	}
+	defer func(start time.Time) {
+		c.LogRequest(ctx, c.provider(), req.Model, req.Input.size(), start, err)
+	}(time.Now())
 	reqJSON, err := req.JSON()
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"embeddings", bytes.NewBufferString(reqJSON))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.embeddingsURL(), bytes.NewBufferString(reqJSON))
 	if err != nil {
 		return nil, err
 	}
 	httpReq.Header.Set("Accept", "application/json")
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.getAPIKey())
+	if err := c.setAuthHeaders(ctx, httpReq); err != nil {
+		return nil, err
+	}
 
 	// OpenAI Organization ID (Optional)
 	if c.OrgID != "" {
@@ -183,8 +257,10 @@ func (c *OpenAIClient) CreateEmbedding(ctx context.Context, req *CreateEmbedding
 }
 
 var _ types.EmbeddingFunction = (*OpenAIEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*OpenAIEmbeddingFunction)(nil)
 
 type OpenAIEmbeddingFunction struct {
+	types.UsageTracker
 	apiClient *OpenAIClient
 }
 
@@ -193,6 +269,9 @@ func NewOpenAIEmbeddingFunction(apiKey string, opts ...Option) (*OpenAIEmbedding
 	if err != nil {
 		return nil, err
 	}
+	if apiClient.APIKey == "" && apiClient.TokenProvider == nil {
+		return nil, fmt.Errorf("API key or token provider is required")
+	}
 	cli := &OpenAIEmbeddingFunction{
 		apiClient: apiClient,
 	}
@@ -240,6 +319,7 @@ func (e *OpenAIEmbeddingFunction) EmbedDocuments(ctx context.Context, documents
 	if err != nil {
 		return nil, err
 	}
+	e.recordUsage(ctx, e.getModel(ctx), response)
 	return types.NewEmbeddingsFromFloat32(ConvertToMatrix(response)), nil
 }
 
@@ -250,13 +330,28 @@ func (e *OpenAIEmbeddingFunction) EmbedQuery(ctx context.Context, document strin
 		Input: &Input{
 			Texts: []string{document},
 		},
+		Dimensions: e.getDimensions(ctx),
 	})
 	if err != nil {
 		return nil, err
 	}
+	e.recordUsage(ctx, e.getModel(ctx), response)
 	return types.NewEmbeddingFromFloat32(ConvertToMatrix(response)[0]), nil
 }
 
+func (e *OpenAIEmbeddingFunction) recordUsage(ctx context.Context, model string, response *CreateEmbeddingResponse) {
+	if response.Model != "" {
+		model = response.Model
+	}
+	e.RecordUsage(ctx, types.Usage{
+		Provider:     e.apiClient.provider(),
+		Model:        model,
+		PromptTokens: response.Usage.PromptTokens,
+		TotalTokens:  response.Usage.TotalTokens,
+		Requests:     1,
+	})
+}
+
 func (e *OpenAIEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
 	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
 }
@@ -3,7 +3,11 @@
 package openai
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -12,6 +16,8 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/szirtesitidom/chroma-go/types"
 )
 
 func Test_openai_client(t *testing.T) {
@@ -150,3 +156,111 @@ func Test_openai_client(t *testing.T) {
 	})
 
 }
+
+func Test_openai_usage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"object":"list","data":[{"embedding":[1,2,3]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":5,"total_tokens":5}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	ef, err := NewOpenAIEmbeddingFunction("test", WithBaseURL(server.URL))
+	require.NoError(t, err)
+	collector := types.NewUsageCollector()
+	ctx := types.ContextWithUsageCollector(context.Background(), collector)
+	_, err = ef.EmbedDocuments(ctx, []string{"Document 1 content here"})
+	require.NoError(t, err)
+	_, err = ef.EmbedQuery(ctx, "query")
+	require.NoError(t, err)
+	expected := types.Usage{Provider: "openai", Model: "text-embedding-3-small", PromptTokens: 10, TotalTokens: 10, Requests: 2}
+	require.Equal(t, expected, collector.Total())
+	require.Equal(t, expected, ef.TotalUsage())
+}
+
+func Test_openai_logger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"object":"list","data":[{"embedding":[1,2,3]},{"embedding":[4,5,6]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":5,"total_tokens":5}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ef, err := NewOpenAIEmbeddingFunction("test", WithBaseURL(server.URL), WithModel(TextEmbedding3Small), WithLogger(logger))
+	require.NoError(t, err)
+	_, err = ef.EmbedDocuments(context.Background(), []string{"Document 1", "Document 2"})
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "msg=\"provider request\" provider=openai model=text-embedding-3-small batch_size=2 latency=")
+	require.NotContains(t, buf.String(), "Document 1")
+}
+
+func Test_openai_azure(t *testing.T) {
+	var lastRequest *http.Request
+	var lastBody struct {
+		Model      string `json:"model"`
+		Dimensions *int   `json:"dimensions"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequest = r
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&lastBody))
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"object":"list","data":[{"embedding":[1,2,3]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":5,"total_tokens":5}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	t.Run("Test api-key auth", func(t *testing.T) {
+		ef, err := NewOpenAIEmbeddingFunction("azure-key", WithAzureDeployment(server.URL, "my embeddings"), WithModel(TextEmbedding3Small), WithDimensions(3))
+		require.NoError(t, err)
+		_, err = ef.EmbedDocuments(context.Background(), []string{"Document 1 content here"})
+		require.NoError(t, err)
+		require.Equal(t, "/openai/deployments/my%20embeddings/embeddings", lastRequest.URL.EscapedPath())
+		require.Equal(t, DefaultAzureAPIVersion, lastRequest.URL.Query().Get("api-version"))
+		require.Equal(t, "azure-key", lastRequest.Header.Get("api-key"))
+		require.Empty(t, lastRequest.Header.Get("Authorization"))
+		require.Equal(t, 3, *lastBody.Dimensions)
+
+		_, err = ef.EmbedQuery(context.Background(), "query")
+		require.NoError(t, err)
+		require.Equal(t, 3, *lastBody.Dimensions, "query uses the same dimensions as documents")
+		require.Equal(t, types.Usage{Provider: "azure-openai", Model: "text-embedding-3-small", PromptTokens: 10, TotalTokens: 10, Requests: 2}, ef.TotalUsage())
+	})
+
+	t.Run("Test token provider auth", func(t *testing.T) {
+		ef, err := NewOpenAIEmbeddingFunction("",
+			WithAzureDeployment(server.URL+"/", "embeddings"),
+			WithAzureAPIVersion("2024-06-01"),
+			WithTokenProvider(func(ctx context.Context) (string, error) {
+				return "entra-token", nil
+			}),
+		)
+		require.NoError(t, err)
+		_, err = ef.EmbedQuery(context.Background(), "query")
+		require.NoError(t, err)
+		require.Equal(t, "/openai/deployments/embeddings/embeddings", lastRequest.URL.Path)
+		require.Equal(t, "2024-06-01", lastRequest.URL.Query().Get("api-version"))
+		require.Equal(t, "Bearer entra-token", lastRequest.Header.Get("Authorization"))
+		require.Empty(t, lastRequest.Header.Get("api-key"))
+	})
+
+	t.Run("Test token provider error", func(t *testing.T) {
+		ef, err := NewOpenAIEmbeddingFunction("", WithAzureDeployment(server.URL, "embeddings"), WithTokenProvider(func(ctx context.Context) (string, error) {
+			return "", fmt.Errorf("no credentials")
+		}))
+		require.NoError(t, err)
+		_, err = ef.EmbedQuery(context.Background(), "query")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no credentials")
+	})
+
+	t.Run("Test invalid options", func(t *testing.T) {
+		_, err := NewOpenAIEmbeddingFunction("key", WithAzureDeployment(server.URL, ""))
+		require.Error(t, err)
+		_, err = NewOpenAIEmbeddingFunction("key", WithAzureDeployment("", "embeddings"))
+		require.Error(t, err)
+		_, err = NewOpenAIEmbeddingFunction("key", WithAzureAPIVersion(""))
+		require.Error(t, err)
+		_, err = NewOpenAIEmbeddingFunction("", WithAzureDeployment(server.URL, "embeddings"))
+		require.Error(t, err)
+	})
+}
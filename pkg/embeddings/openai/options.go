@@ -2,6 +2,7 @@ package openai
 
 import (
 	"fmt"
+	"log/slog"
 )
 
 // Option is a function type that can be used to modify the client.
@@ -48,6 +49,53 @@ func WithDimensions(dimensions int) Option {
 	}
 }
 
+// WithAzureDeployment sends requests to the Azure OpenAI deployment at the resource endpoint, e.g. https://my-resource.openai.azure.com.
+// The model of the deployment is fixed in Azure, WithModel and the model context variable only set the model reported in usage.
+// The API key is sent in the api-key header unless WithTokenProvider is used.
+func WithAzureDeployment(endpoint string, deployment string) Option {
+	return func(c *OpenAIClient) error {
+		if endpoint == "" {
+			return fmt.Errorf("empty Azure endpoint")
+		}
+		if deployment == "" {
+			return fmt.Errorf("empty Azure deployment name")
+		}
+		c.BaseURL = endpoint
+		c.AzureDeployment = deployment
+		return nil
+	}
+}
+
+// WithAzureAPIVersion sets the Azure OpenAI API version. Defaults to DefaultAzureAPIVersion.
+func WithAzureAPIVersion(apiVersion string) Option {
+	return func(c *OpenAIClient) error {
+		if apiVersion == "" {
+			return fmt.Errorf("empty Azure API version")
+		}
+		c.AzureAPIVersion = apiVersion
+		return nil
+	}
+}
+
+// WithTokenProvider authenticates requests with a bearer token from the provider instead of the API key, e.g. with Microsoft Entra ID for Azure OpenAI.
+func WithTokenProvider(provider TokenProvider) Option {
+	return func(c *OpenAIClient) error {
+		if provider == nil {
+			return fmt.Errorf("token provider cannot be nil")
+		}
+		c.TokenProvider = provider
+		return nil
+	}
+}
+
+// WithLogger logs the embedding requests (provider openai or azure-openai, model, batch size, latency). Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *OpenAIClient) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
+
 func applyClientOptions(c *OpenAIClient, opts ...Option) error {
 	for _, opt := range opts {
 		err := opt(c)
@@ -0,0 +1,267 @@
+package openaicompat
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+type EncodingFormat string
+
+const (
+	EncodingFormatFloat  EncodingFormat = "float"
+	EncodingFormatBase64 EncodingFormat = "base64"
+	EmbeddingsEndpoint                  = "embeddings"
+)
+
+// Client talks to servers exposing an OpenAI-compatible embeddings endpoint, e.g. vLLM, LM Studio, LocalAI,
+// llama.cpp server or HuggingFace TEI. BaseURL is the URL the /embeddings path is appended to, e.g. http://localhost:8000/v1.
+type Client struct {
+	BaseURL        string
+	APIKey         string
+	Model          string
+	Dimensions     *int
+	EncodingFormat EncodingFormat
+	MaxBatchSize   int
+	// RequestExtras are added to the request body, e.g. {"truncate_prompt_tokens": 512} for vLLM.
+	RequestExtras  map[string]any
+	DefaultHeaders map[string]string
+	Client         *http.Client
+	types.RequestLogger
+}
+
+type CreateEmbeddingRequest struct {
+	Model          string         `json:"model,omitempty"`
+	Input          []string       `json:"input"`
+	Dimensions     *int           `json:"dimensions,omitempty"`
+	EncodingFormat EncodingFormat `json:"encoding_format,omitempty"`
+	Extras         map[string]any `json:"-"`
+}
+
+// MarshalJSON merges the extras into the request body. Extras do not override fields that are set.
+func (r *CreateEmbeddingRequest) MarshalJSON() ([]byte, error) {
+	type request CreateEmbeddingRequest
+	data, err := json.Marshal((*request)(r))
+	if err != nil || len(r.Extras) == 0 {
+		return data, err
+	}
+	var body map[string]any
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	for k, v := range r.Extras {
+		if _, ok := body[k]; !ok {
+			body[k] = v
+		}
+	}
+	return json.Marshal(body)
+}
+
+type EmbeddingData struct {
+	Index *int `json:"index,omitempty"`
+	// Embedding is a JSON array of floats or a base64 string of little-endian float32 values.
+	Embedding json.RawMessage `json:"embedding"`
+}
+
+type Usage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+type CreateEmbeddingResponse struct {
+	Model string          `json:"model,omitempty"`
+	Data  []EmbeddingData `json:"data"`
+	Usage *Usage          `json:"usage,omitempty"`
+}
+
+func NewClient(opts ...Option) (*Client, error) {
+	client := &Client{
+		Client: &http.Client{},
+	}
+	for _, opt := range opts {
+		err := opt(client)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if client.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required")
+	}
+	return client, nil
+}
+
+func (c *Client) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "openai-compatible", req.Model, len(req.Input), start, err)
+	}(time.Now())
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	url := strings.TrimSuffix(c.BaseURL, "/") + "/" + EmbeddingsEndpoint
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqJSON))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.DefaultHeaders {
+		httpReq.Header.Set(k, v)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected code [%v] while making a request to %v: %v", resp.Status, url, string(respData))
+	}
+	var embeddingResponse CreateEmbeddingResponse
+	if err := json.Unmarshal(respData, &embeddingResponse); err != nil {
+		return nil, err
+	}
+	return &embeddingResponse, nil
+}
+
+// Embeddings decodes the embeddings of the response in input order. Entries are ordered by index if the server returns it,
+// otherwise they are assumed to be in input order.
+func (r *CreateEmbeddingResponse) Embeddings(inputs int) ([][]float32, error) {
+	if len(r.Data) != inputs {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", inputs, len(r.Data))
+	}
+	embeddings := make([][]float32, inputs)
+	for i, d := range r.Data {
+		position := i
+		if d.Index != nil {
+			position = *d.Index
+		}
+		if position < 0 || position >= inputs {
+			return nil, fmt.Errorf("embedding index %d out of range", position)
+		}
+		if embeddings[position] != nil {
+			return nil, fmt.Errorf("duplicate embedding index %d", position)
+		}
+		embedding, err := decodeEmbedding(d.Embedding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode embedding %d: %w", position, err)
+		}
+		embeddings[position] = embedding
+	}
+	return embeddings, nil
+}
+
+func decodeEmbedding(raw json.RawMessage) ([]float32, error) {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		var embedding []float32
+		if err := json.Unmarshal(raw, &embedding); err != nil {
+			return nil, err
+		}
+		return embedding, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("base64 embedding length %d is not a multiple of 4 bytes", len(data))
+	}
+	embedding := make([]float32, len(data)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return embedding, nil
+}
+
+var _ types.EmbeddingFunction = (*OpenAICompatEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*OpenAICompatEmbeddingFunction)(nil)
+
+type OpenAICompatEmbeddingFunction struct {
+	types.UsageTracker
+	apiClient *Client
+}
+
+func NewOpenAICompatEmbeddingFunction(opts ...Option) (*OpenAICompatEmbeddingFunction, error) {
+	client, err := NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAICompatEmbeddingFunction{apiClient: client}, nil
+}
+
+func (e *OpenAICompatEmbeddingFunction) embed(ctx context.Context, input []string) ([]*types.Embedding, error) {
+	response, err := e.apiClient.CreateEmbedding(ctx, &CreateEmbeddingRequest{
+		Model:          e.apiClient.Model,
+		Input:          input,
+		Dimensions:     e.apiClient.Dimensions,
+		EncodingFormat: e.apiClient.EncodingFormat,
+		Extras:         e.apiClient.RequestExtras,
+	})
+	if err != nil {
+		return nil, err
+	}
+	embeddings, err := response.Embeddings(len(input))
+	if err != nil {
+		return nil, err
+	}
+	usage := types.Usage{Provider: "openai-compatible", Model: e.apiClient.Model, Requests: 1}
+	if response.Model != "" {
+		usage.Model = response.Model
+	}
+	if response.Usage != nil {
+		usage.PromptTokens = response.Usage.PromptTokens
+		usage.TotalTokens = response.Usage.TotalTokens
+	}
+	e.RecordUsage(ctx, usage)
+	return types.NewEmbeddingsFromFloat32(embeddings), nil
+}
+
+// EmbedDocuments embeds the documents, in batches of MaxBatchSize documents if it is set.
+func (e *OpenAICompatEmbeddingFunction) EmbedDocuments(ctx context.Context, documents []string) ([]*types.Embedding, error) {
+	if len(documents) == 0 {
+		return []*types.Embedding{}, nil
+	}
+	batchSize := e.apiClient.MaxBatchSize
+	if batchSize <= 0 {
+		batchSize = len(documents)
+	}
+	embeddings := make([]*types.Embedding, 0, len(documents))
+	for start := 0; start < len(documents); start += batchSize {
+		batch, err := e.embed(ctx, documents[start:min(start+batchSize, len(documents))])
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+func (e *OpenAICompatEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
+	embeddings, err := e.embed(ctx, []string{document})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e *OpenAICompatEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
+}
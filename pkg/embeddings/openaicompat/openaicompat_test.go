@@ -0,0 +1,117 @@
+//go:build ef
+
+package openaicompat
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+func encodeBase64(values []float32) string {
+	data := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(v))
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func Test_openai_compat(t *testing.T) {
+	var lastRequest *http.Request
+	var lastBody map[string]any
+	var response string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequest = r
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&lastBody))
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(response))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	t.Run("Test without auth, usage and index", func(t *testing.T) {
+		response = `{"data":[{"embedding":[1,2]},{"embedding":[3,4]}]}`
+		ef, err := NewOpenAICompatEmbeddingFunction(WithBaseURL(server.URL+"/v1/"), WithModel("local-model"))
+		require.NoError(t, err)
+		resp, err := ef.EmbedDocuments(context.Background(), []string{"a", "b"})
+		require.NoError(t, err)
+		require.Equal(t, "/v1/embeddings", lastRequest.URL.Path)
+		require.Empty(t, lastRequest.Header.Get("Authorization"))
+		require.Empty(t, lastRequest.Header.Get("OpenAI-Organization"))
+		require.Equal(t, map[string]any{"model": "local-model", "input": []any{"a", "b"}}, lastBody)
+		require.Equal(t, []float32{3, 4}, resp[1].ToFloat32())
+		require.Equal(t, types.Usage{Provider: "openai-compatible", Model: "local-model", Requests: 1}, ef.TotalUsage())
+	})
+
+	t.Run("Test auth, headers, extras and out of order indices", func(t *testing.T) {
+		response = `{"model":"served-model","data":[{"index":1,"embedding":[3,4]},{"index":0,"embedding":[1,2]}],"usage":{"prompt_tokens":4,"total_tokens":4}}`
+		ef, err := NewOpenAICompatEmbeddingFunction(
+			WithBaseURL(server.URL),
+			WithAPIKey("secret"),
+			WithModel("local-model"),
+			WithDefaultHeaders(map[string]string{"X-Tenant": "t1"}),
+			WithDimensions(2),
+			WithRequestExtras(map[string]any{"truncate_prompt_tokens": 512, "model": "ignored"}),
+		)
+		require.NoError(t, err)
+		resp, err := ef.EmbedDocuments(context.Background(), []string{"a", "b"})
+		require.NoError(t, err)
+		require.Equal(t, "Bearer secret", lastRequest.Header.Get("Authorization"))
+		require.Equal(t, "t1", lastRequest.Header.Get("X-Tenant"))
+		require.Equal(t, float64(512), lastBody["truncate_prompt_tokens"])
+		require.Equal(t, float64(2), lastBody["dimensions"])
+		require.Equal(t, "local-model", lastBody["model"], "extras do not override fields that are set")
+		require.Equal(t, []float32{1, 2}, resp[0].ToFloat32())
+		require.Equal(t, []float32{3, 4}, resp[1].ToFloat32())
+		require.Equal(t, types.Usage{Provider: "openai-compatible", Model: "served-model", PromptTokens: 4, TotalTokens: 4, Requests: 1}, ef.TotalUsage())
+	})
+
+	t.Run("Test base64 encoding", func(t *testing.T) {
+		response = `{"data":[{"index":0,"embedding":"` + encodeBase64([]float32{0.5, -1.25}) + `"}]}`
+		ef, err := NewOpenAICompatEmbeddingFunction(WithBaseURL(server.URL), WithEncodingFormat(EncodingFormatBase64))
+		require.NoError(t, err)
+		resp, err := ef.EmbedQuery(context.Background(), "query")
+		require.NoError(t, err)
+		require.Equal(t, "base64", lastBody["encoding_format"])
+		require.Equal(t, []float32{0.5, -1.25}, resp.ToFloat32())
+	})
+
+	t.Run("Test batches", func(t *testing.T) {
+		response = `{"data":[{"embedding":[1]},{"embedding":[2]}]}`
+		ef, err := NewOpenAICompatEmbeddingFunction(WithBaseURL(server.URL), WithMaxBatchSize(2))
+		require.NoError(t, err)
+		_, err = ef.EmbedDocuments(context.Background(), []string{"a", "b", "c"})
+		require.Error(t, err, "the last batch has one input but the server returns two embeddings")
+		require.Contains(t, err.Error(), "expected 1 embeddings, got 2")
+		require.Equal(t, 1, ef.TotalUsage().Requests)
+	})
+
+	t.Run("Test invalid responses", func(t *testing.T) {
+		ef, err := NewOpenAICompatEmbeddingFunction(WithBaseURL(server.URL))
+		require.NoError(t, err)
+		response = `{"data":[{"index":0,"embedding":[1]},{"index":0,"embedding":[2]}]}`
+		_, err = ef.EmbedDocuments(context.Background(), []string{"a", "b"})
+		require.Error(t, err)
+		response = `{"data":[{"index":0,"embedding":"AAA="}]}`
+		_, err = ef.EmbedQuery(context.Background(), "a")
+		require.Error(t, err)
+	})
+
+	t.Run("Test invalid options", func(t *testing.T) {
+		_, err := NewOpenAICompatEmbeddingFunction()
+		require.Error(t, err)
+		_, err = NewOpenAICompatEmbeddingFunction(WithBaseURL(server.URL), WithEncodingFormat("int8"))
+		require.Error(t, err)
+		_, err = NewOpenAICompatEmbeddingFunction(WithBaseURL(server.URL), WithEnvAPIKey("OPENAI_COMPAT_TEST_KEY_NOT_SET"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,121 @@
+package openaicompat
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+type Option func(c *Client) error
+
+// WithBaseURL sets the URL the /embeddings path is appended to, e.g. http://localhost:8000/v1. Required.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		if baseURL == "" {
+			return fmt.Errorf("empty base URL")
+		}
+		c.BaseURL = baseURL
+		return nil
+	}
+}
+
+// WithAPIKey sets the key sent as bearer token. Servers without authentication do not need it, an empty key sends no Authorization header.
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) error {
+		c.APIKey = apiKey
+		return nil
+	}
+}
+
+// WithEnvAPIKey reads the API key from the environment variable.
+func WithEnvAPIKey(envVar string) Option {
+	return func(c *Client) error {
+		if os.Getenv(envVar) == "" {
+			return fmt.Errorf("%s not set", envVar)
+		}
+		c.APIKey = os.Getenv(envVar)
+		return nil
+	}
+}
+
+func WithModel(model string) Option {
+	return func(c *Client) error {
+		if model == "" {
+			return fmt.Errorf("empty model name")
+		}
+		c.Model = model
+		return nil
+	}
+}
+
+// WithDimensions sets the dimensions request parameter. Only some servers and models support it.
+func WithDimensions(dimensions int) Option {
+	return func(c *Client) error {
+		if dimensions <= 0 {
+			return fmt.Errorf("invalid dimensions %d", dimensions)
+		}
+		c.Dimensions = &dimensions
+		return nil
+	}
+}
+
+// WithEncodingFormat requests float or base64 encoded embeddings. Base64 responses are smaller and decoded transparently.
+func WithEncodingFormat(format EncodingFormat) Option {
+	return func(c *Client) error {
+		if format != EncodingFormatFloat && format != EncodingFormatBase64 {
+			return fmt.Errorf("invalid encoding format %s. Must be one of: %v", format, []EncodingFormat{EncodingFormatFloat, EncodingFormatBase64})
+		}
+		c.EncodingFormat = format
+		return nil
+	}
+}
+
+// WithMaxBatchSize splits EmbedDocuments into requests of at most size documents.
+func WithMaxBatchSize(size int) Option {
+	return func(c *Client) error {
+		if size <= 0 {
+			return fmt.Errorf("max batch size must be greater than 0")
+		}
+		c.MaxBatchSize = size
+		return nil
+	}
+}
+
+// WithRequestExtras adds server specific fields to the request body. They do not override fields that are set by other options.
+func WithRequestExtras(extras map[string]any) Option {
+	return func(c *Client) error {
+		if c.RequestExtras == nil {
+			c.RequestExtras = make(map[string]any, len(extras))
+		}
+		for k, v := range extras {
+			c.RequestExtras[k] = v
+		}
+		return nil
+	}
+}
+
+func WithDefaultHeaders(headers map[string]string) Option {
+	return func(c *Client) error {
+		c.DefaultHeaders = headers
+		return nil
+	}
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) error {
+		if client == nil {
+			return fmt.Errorf("http client cannot be nil")
+		}
+		c.Client = client
+		return nil
+	}
+}
+
+// WithLogger logs each request to the server with model, batch size and latency at debug level, and failed requests as warnings.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
@@ -0,0 +1,159 @@
+package openclip
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+const DefaultModel = "ViT-B-32"
+
+// OpenCLIPClient talks to an OpenCLIP-style embedding server that embeds texts and images into a shared vector space.
+// The server exposes POST /embed accepting {"model": ..., "texts": [...]} or {"model": ..., "images": [<base64>...]} and
+// returning {"embeddings": [[...]]}.
+type OpenCLIPClient struct {
+	BaseURL        string
+	Model          string
+	Client         *http.Client
+	DefaultHeaders map[string]string
+	types.RequestLogger
+}
+
+type CreateEmbeddingRequest struct {
+	Model  string   `json:"model,omitempty"`
+	Texts  []string `json:"texts,omitempty"`
+	Images []string `json:"images,omitempty"` // base64 encoded
+}
+
+type CreateEmbeddingResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func NewOpenCLIPClient(opts ...Option) (*OpenCLIPClient, error) {
+	client := &OpenCLIPClient{
+		Model:  DefaultModel,
+		Client: &http.Client{},
+	}
+	for _, opt := range opts {
+		err := opt(client)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if client.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required")
+	}
+	return client, nil
+}
+
+func (c *OpenCLIPClient) createEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "openclip", req.Model, len(req.Texts)+len(req.Images), start, err)
+	}(time.Now())
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	url := strings.TrimSuffix(c.BaseURL, "/") + "/embed"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqJSON))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.DefaultHeaders {
+		httpReq.Header.Set(k, v)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected code [%v] while making a request to %v: %v", resp.Status, url, string(respData))
+	}
+	var embeddingResponse CreateEmbeddingResponse
+	if err := json.Unmarshal(respData, &embeddingResponse); err != nil {
+		return nil, err
+	}
+	inputs := len(req.Texts) + len(req.Images)
+	if len(embeddingResponse.Embeddings) != inputs {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", inputs, len(embeddingResponse.Embeddings))
+	}
+	return &embeddingResponse, nil
+}
+
+type OpenCLIPEmbeddingFunction struct {
+	apiClient *OpenCLIPClient
+}
+
+var _ types.ImageEmbeddingFunction = (*OpenCLIPEmbeddingFunction)(nil)
+
+func NewOpenCLIPEmbeddingFunction(opts ...Option) (*OpenCLIPEmbeddingFunction, error) {
+	client, err := NewOpenCLIPClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenCLIPEmbeddingFunction{apiClient: client}, nil
+}
+
+func (e *OpenCLIPEmbeddingFunction) EmbedDocuments(ctx context.Context, documents []string) ([]*types.Embedding, error) {
+	if len(documents) == 0 {
+		return []*types.Embedding{}, nil
+	}
+	response, err := e.apiClient.createEmbedding(ctx, &CreateEmbeddingRequest{
+		Model: e.apiClient.Model,
+		Texts: documents,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return types.NewEmbeddingsFromFloat32(response.Embeddings), nil
+}
+
+func (e *OpenCLIPEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
+	embeddings, err := e.EmbedDocuments(ctx, []string{document})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e *OpenCLIPEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
+}
+
+// EmbedImages embeds encoded images (e.g. PNG or JPEG bytes) into the same space as the texts.
+func (e *OpenCLIPEmbeddingFunction) EmbedImages(ctx context.Context, images [][]byte) ([]*types.Embedding, error) {
+	if len(images) == 0 {
+		return []*types.Embedding{}, nil
+	}
+	encoded := make([]string, len(images))
+	for i, image := range images {
+		if len(image) == 0 {
+			return nil, fmt.Errorf("image %d is empty", i)
+		}
+		encoded[i] = base64.StdEncoding.EncodeToString(image)
+	}
+	response, err := e.apiClient.createEmbedding(ctx, &CreateEmbeddingRequest{
+		Model:  e.apiClient.Model,
+		Images: encoded,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return types.NewEmbeddingsFromFloat32(response.Embeddings), nil
+}
@@ -0,0 +1,82 @@
+//go:build ef
+
+package openclip
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newStandInServer embeds each text as [len(text), 0] and each image as [0, len(image)].
+func newStandInServer(t *testing.T, requests *[]CreateEmbeddingRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/embed", r.URL.Path)
+		var req CreateEmbeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		*requests = append(*requests, req)
+		resp := CreateEmbeddingResponse{Embeddings: make([][]float32, 0)}
+		for _, text := range req.Texts {
+			resp.Embeddings = append(resp.Embeddings, []float32{float32(len(text)), 0})
+		}
+		for _, image := range req.Images {
+			data, err := base64.StdEncoding.DecodeString(image)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			resp.Embeddings = append(resp.Embeddings, []float32{0, float32(len(data))})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+}
+
+func Test_openclip(t *testing.T) {
+	var requests []CreateEmbeddingRequest
+	server := newStandInServer(t, &requests)
+	defer server.Close()
+	ef, err := NewOpenCLIPEmbeddingFunction(WithBaseURL(server.URL+"/"), WithModel("ViT-L-14"))
+	require.NoError(t, err)
+
+	t.Run("Test EmbedDocuments", func(t *testing.T) {
+		embeddings, err := ef.EmbedDocuments(context.Background(), []string{"a cat", "dog"})
+		require.NoError(t, err)
+		require.Len(t, embeddings, 2)
+		require.Equal(t, []float32{5, 0}, embeddings[0].ToFloat32())
+		require.Equal(t, []float32{3, 0}, embeddings[1].ToFloat32())
+		require.Equal(t, "ViT-L-14", requests[len(requests)-1].Model)
+	})
+
+	t.Run("Test EmbedQuery", func(t *testing.T) {
+		embedding, err := ef.EmbedQuery(context.Background(), "a cat")
+		require.NoError(t, err)
+		require.Equal(t, []float32{5, 0}, embedding.ToFloat32())
+	})
+
+	t.Run("Test EmbedImages", func(t *testing.T) {
+		embeddings, err := ef.EmbedImages(context.Background(), [][]byte{[]byte("png"), []byte("jpeg")})
+		require.NoError(t, err)
+		require.Len(t, embeddings, 2)
+		require.Equal(t, []float32{0, 3}, embeddings[0].ToFloat32())
+		require.Equal(t, []float32{0, 4}, embeddings[1].ToFloat32())
+		last := requests[len(requests)-1]
+		require.Empty(t, last.Texts)
+		require.Equal(t, base64.StdEncoding.EncodeToString([]byte("png")), last.Images[0])
+	})
+
+	t.Run("Test EmbedImages with empty image", func(t *testing.T) {
+		_, err := ef.EmbedImages(context.Background(), [][]byte{{}})
+		require.Error(t, err)
+	})
+
+	t.Run("Test missing base URL", func(t *testing.T) {
+		_, err := NewOpenCLIPEmbeddingFunction()
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,55 @@
+package openclip
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+type Option func(p *OpenCLIPClient) error
+
+// WithBaseURL sets the URL of the embedding server, e.g. http://localhost:8000.
+func WithBaseURL(baseURL string) Option {
+	return func(p *OpenCLIPClient) error {
+		if baseURL == "" {
+			return fmt.Errorf("base URL cannot be empty")
+		}
+		p.BaseURL = baseURL
+		return nil
+	}
+}
+
+// WithModel sets the model name sent with each request. Servers that host a single model may ignore it.
+func WithModel(model string) Option {
+	return func(p *OpenCLIPClient) error {
+		p.Model = model
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to make requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *OpenCLIPClient) error {
+		if client == nil {
+			return fmt.Errorf("http client cannot be nil")
+		}
+		p.Client = client
+		return nil
+	}
+}
+
+// WithDefaultHeaders sets headers sent with each request, e.g. for authentication.
+func WithDefaultHeaders(headers map[string]string) Option {
+	return func(p *OpenCLIPClient) error {
+		p.DefaultHeaders = headers
+		return nil
+	}
+}
+
+// WithLogger logs the text and image embedding requests to the server.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *OpenCLIPClient) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
@@ -2,6 +2,7 @@ package together
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 )
@@ -52,3 +53,11 @@ func WithHTTPClient(client *http.Client) Option {
 		return nil
 	}
 }
+
+// WithLogger logs each embedding request (model, number of inputs, latency) to the logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *TogetherAIClient) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/szirtesitidom/chroma-go/types"
 )
@@ -26,6 +27,7 @@ type TogetherAIClient struct {
 	MaxBatchSize   int
 	DefaultHeaders map[string]string
 	Client         *http.Client
+	types.RequestLogger
 }
 
 func applyDefaults(c *TogetherAIClient) {
@@ -87,6 +89,18 @@ func (e *EmbeddingInputs) MarshalJSON() ([]byte, error) {
 	return nil, fmt.Errorf("EmbeddingInput has no data")
 }
 
+// size returns the number of inputs.
+func (e *EmbeddingInputs) size() int {
+	switch {
+	case e == nil:
+		return 0
+	case e.Input != "":
+		return 1
+	default:
+		return len(e.Inputs)
+	}
+}
+
 type CreateEmbeddingRequest struct {
 	Model string           `json:"model"`
 	Input *EmbeddingInputs `json:"input"`
@@ -103,6 +117,7 @@ type CreateEmbeddingResponse struct {
 	Data      []EmbeddingResult `json:"data"`
 	Model     string            `json:"model"`
 	RequestID string            `json:"request_id"`
+	Usage     map[string]any    `json:"usage,omitempty"`
 }
 
 func (c *CreateEmbeddingRequest) JSON() (string, error) {
@@ -113,7 +128,10 @@ func (c *CreateEmbeddingRequest) JSON() (string, error) {
 	return string(data), nil
 }
 
-func (c *TogetherAIClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (*CreateEmbeddingResponse, error) {
+func (c *TogetherAIClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "together", req.Model, req.Input.size(), start, err)
+	}(time.Now())
 	reqJSON, err := req.JSON()
 	if err != nil {
 		return nil, err
@@ -149,8 +167,10 @@ func (c *TogetherAIClient) CreateEmbedding(ctx context.Context, req *CreateEmbed
 }
 
 var _ types.EmbeddingFunction = (*TogetherEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*TogetherEmbeddingFunction)(nil)
 
 type TogetherEmbeddingFunction struct {
+	types.UsageTracker
 	apiClient *TogetherAIClient
 }
 
@@ -186,6 +206,7 @@ func (e *TogetherEmbeddingFunction) EmbedDocuments(ctx context.Context, document
 	if err != nil {
 		return nil, err
 	}
+	e.recordUsage(ctx, req.Model, response)
 	embeddings := make([]*types.Embedding, 0, len(response.Data))
 	for _, result := range response.Data {
 		embeddings = append(embeddings, types.NewEmbeddingFromFloat32(result.Embedding))
@@ -202,9 +223,18 @@ func (e *TogetherEmbeddingFunction) EmbedQuery(ctx context.Context, document str
 	if err != nil {
 		return nil, err
 	}
+	e.recordUsage(ctx, req.Model, response)
 	return types.NewEmbeddingFromFloat32(response.Data[0].Embedding), nil
 }
 
+// recordUsage records the request. Together AI does not always report token usage, in which case only the request is counted.
+func (e *TogetherEmbeddingFunction) recordUsage(ctx context.Context, model string, response *CreateEmbeddingResponse) {
+	if response.Model != "" {
+		model = response.Model
+	}
+	e.RecordUsage(ctx, types.NewUsageFromMap("together", model, response.Usage))
+}
+
 func (e *TogetherEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
 	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
 }
@@ -0,0 +1,132 @@
+package tokenlimit
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tokenizers "github.com/szirtesitidom/chroma-go/pkg/tokenizers/libtokenizers"
+)
+
+// DefaultCharsPerToken is a conservative estimate for the heuristic counter. English text averages about four characters per
+// token with BPE tokenizers, other languages and code fewer.
+const DefaultCharsPerToken = 3.0
+
+// TokenCounter estimates the number of tokens of a text.
+type TokenCounter interface {
+	CountTokens(text string) (int, error)
+	// Truncate returns the longest prefix of text that has at most maxTokens tokens.
+	Truncate(text string, maxTokens int) (string, error)
+}
+
+// HeuristicCounter estimates tokens from the number of characters. It does not need a tokenizer but may over- or under-estimate
+// for a particular model, so the limit should leave some headroom.
+type HeuristicCounter struct {
+	CharsPerToken float64
+}
+
+var _ TokenCounter = (*HeuristicCounter)(nil)
+
+func NewHeuristicCounter(charsPerToken float64) (*HeuristicCounter, error) {
+	if charsPerToken <= 0 {
+		return nil, fmt.Errorf("chars per token must be greater than 0")
+	}
+	return &HeuristicCounter{CharsPerToken: charsPerToken}, nil
+}
+
+func (h *HeuristicCounter) CountTokens(text string) (int, error) {
+	return int(math.Ceil(float64(utf8.RuneCountInString(text)) / h.CharsPerToken)), nil
+}
+
+// Truncate cuts the text after maxTokens * CharsPerToken characters, preferring to cut at whitespace in the second half of the prefix.
+func (h *HeuristicCounter) Truncate(text string, maxTokens int) (string, error) {
+	maxRunes := int(float64(maxTokens) * h.CharsPerToken)
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text, nil
+	}
+	end := 0
+	for i := 0; i < maxRunes; i++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	prefix := text[:end]
+	next, _ := utf8.DecodeRuneInString(text[end:])
+	if unicode.IsSpace(next) {
+		return prefix, nil
+	}
+	if cut := strings.LastIndexFunc(prefix, unicode.IsSpace); cut >= len(prefix)/2 {
+		return prefix[:cut], nil
+	}
+	return prefix, nil
+}
+
+// LibTokenizersCounter counts tokens with a HuggingFace tokenizer.json loaded with libtokenizers.
+type LibTokenizersCounter struct {
+	tokenizer *tokenizers.Tokenizer
+}
+
+var _ TokenCounter = (*LibTokenizersCounter)(nil)
+
+// NewLibTokenizersCounter loads the tokenizer file. If libraryPath is not empty the libtokenizers shared library is loaded from it first,
+// otherwise it must have been loaded already (e.g. by the default embedding function).
+func NewLibTokenizersCounter(libraryPath string, tokenizerFile string) (*LibTokenizersCounter, error) {
+	if libraryPath != "" {
+		if err := tokenizers.LoadLibrary(libraryPath); err != nil {
+			return nil, err
+		}
+	}
+	tokenizer, err := tokenizers.FromFile(tokenizerFile)
+	if err != nil {
+		return nil, err
+	}
+	return &LibTokenizersCounter{tokenizer: tokenizer}, nil
+}
+
+func (l *LibTokenizersCounter) CountTokens(text string) (int, error) {
+	ids, _, err := l.tokenizer.Encode(text, false)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// Truncate cuts the text at the end offset of the last token that fits.
+func (l *LibTokenizersCounter) Truncate(text string, maxTokens int) (string, error) {
+	encoding, err := l.tokenizer.EncodeWithOptions(text, false, tokenizers.WithReturnOffsets())
+	if err != nil {
+		return "", err
+	}
+	if len(encoding.IDs) <= maxTokens {
+		return text, nil
+	}
+	if maxTokens < 1 {
+		return "", nil
+	}
+	end := int(encoding.Offsets[maxTokens-1][1])
+	if end > len(text) {
+		end = len(text)
+	}
+	// offsets are byte offsets, but never cut inside a multi-byte character
+	for end > 0 && end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[:end], nil
+}
+
+// Close releases the tokenizer.
+func (l *LibTokenizersCounter) Close() error {
+	return l.tokenizer.Close()
+}
+
+// newCounterForFile returns a libtokenizers counter if the tokenizer file exists and a heuristic counter otherwise.
+func newCounterForFile(libraryPath string, tokenizerFile string) (TokenCounter, error) {
+	if tokenizerFile != "" {
+		if _, err := os.Stat(tokenizerFile); err == nil {
+			return NewLibTokenizersCounter(libraryPath, tokenizerFile)
+		}
+	}
+	return NewHeuristicCounter(DefaultCharsPerToken)
+}
@@ -0,0 +1,329 @@
+package tokenlimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// Strategy decides what happens to inputs that exceed the token limit.
+type Strategy string
+
+const (
+	// StrategyTruncate embeds the longest prefix that fits the limit.
+	StrategyTruncate Strategy = "truncate"
+	// StrategySplitAverage splits the input into chunks that fit the limit, embeds each chunk and returns the token weighted average of
+	// the chunk embeddings, normalized to unit length.
+	StrategySplitAverage Strategy = "split_average"
+	// StrategyReject fails with an InputTooLongError before any request is sent to the provider.
+	StrategyReject Strategy = "reject"
+)
+
+// Input limits in tokens of common providers, for use with NewTokenLimitEmbeddingFunction. Limits of other providers depend on the model.
+const (
+	OpenAIMaxTokens  = 8191
+	CohereMaxTokens  = 512
+	MistralMaxTokens = 8192
+	NomicMaxTokens   = 8192
+	JinaMaxTokens    = 8192
+)
+
+// InputModification describes an input that exceeded the token limit and was changed before embedding.
+type InputModification struct {
+	Index          int      `json:"index"` // index of the input in the EmbedDocuments call
+	Strategy       Strategy `json:"strategy"`
+	OriginalTokens int      `json:"original_tokens"`
+	Tokens         int      `json:"tokens"` // tokens embedded, i.e. of the truncated text or of all chunks
+	Chunks         int      `json:"chunks"`
+}
+
+// InputTooLongError is returned by StrategyReject. It lists all inputs over the limit so that callers can drop or fix them.
+type InputTooLongError struct {
+	MaxTokens int
+	Inputs    []InputModification
+}
+
+func (e *InputTooLongError) Error() string {
+	indices := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		indices[i] = fmt.Sprintf("%d (%d tokens)", input.Index, input.OriginalTokens)
+	}
+	return fmt.Sprintf("inputs exceed the limit of %d tokens: %s", e.MaxTokens, strings.Join(indices, ", "))
+}
+
+type Option func(*TokenLimitEmbeddingFunction) error
+
+// WithStrategy sets what happens to inputs over the limit. Defaults to StrategyTruncate.
+func WithStrategy(strategy Strategy) Option {
+	return func(e *TokenLimitEmbeddingFunction) error {
+		switch strategy {
+		case StrategyTruncate, StrategySplitAverage, StrategyReject:
+			e.strategy = strategy
+			return nil
+		default:
+			return fmt.Errorf("invalid strategy: %s", strategy)
+		}
+	}
+}
+
+// WithTokenCounter sets the token counter. Defaults to a HeuristicCounter.
+func WithTokenCounter(counter TokenCounter) Option {
+	return func(e *TokenLimitEmbeddingFunction) error {
+		if counter == nil {
+			return fmt.Errorf("token counter must not be nil")
+		}
+		e.counter = counter
+		return nil
+	}
+}
+
+// WithTokenizerFile counts tokens with libtokenizers using the tokenizer.json of the model, if the file exists. Otherwise the heuristic
+// counter is used. libraryPath is the path of the libtokenizers shared library, it can be empty if the library is already loaded.
+func WithTokenizerFile(libraryPath string, tokenizerFile string) Option {
+	return func(e *TokenLimitEmbeddingFunction) error {
+		counter, err := newCounterForFile(libraryPath, tokenizerFile)
+		if err != nil {
+			return err
+		}
+		e.counter = counter
+		return nil
+	}
+}
+
+// WithMaxBatchSize limits the number of inputs sent to the wrapped embedding function per call, as splitting can increase the number of
+// inputs beyond the provider's batch limit.
+func WithMaxBatchSize(size int) Option {
+	return func(e *TokenLimitEmbeddingFunction) error {
+		if size < 1 {
+			return fmt.Errorf("max batch size must be greater than 0")
+		}
+		e.maxBatchSize = size
+		return nil
+	}
+}
+
+// WithModificationHandler registers a function that is called with the modified inputs of each call, e.g. to log them.
+func WithModificationHandler(handler func(ctx context.Context, modifications []InputModification)) Option {
+	return func(e *TokenLimitEmbeddingFunction) error {
+		e.handler = handler
+		return nil
+	}
+}
+
+var _ types.EmbeddingFunction = (*TokenLimitEmbeddingFunction)(nil)
+
+// TokenLimitEmbeddingFunction checks the token count of the inputs before they are sent to the wrapped embedding function and
+// truncates, splits or rejects inputs over MaxTokens.
+type TokenLimitEmbeddingFunction struct {
+	ef           types.EmbeddingFunction
+	MaxTokens    int
+	strategy     Strategy
+	counter      TokenCounter
+	maxBatchSize int
+	handler      func(ctx context.Context, modifications []InputModification)
+}
+
+func NewTokenLimitEmbeddingFunction(ef types.EmbeddingFunction, maxTokens int, opts ...Option) (*TokenLimitEmbeddingFunction, error) {
+	if ef == nil {
+		return nil, fmt.Errorf("embedding function must not be nil")
+	}
+	if maxTokens < 1 {
+		return nil, fmt.Errorf("max tokens must be greater than 0")
+	}
+	e := &TokenLimitEmbeddingFunction{ef: ef, MaxTokens: maxTokens, strategy: StrategyTruncate}
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, err
+		}
+	}
+	if e.counter == nil {
+		counter, err := NewHeuristicCounter(DefaultCharsPerToken)
+		if err != nil {
+			return nil, err
+		}
+		e.counter = counter
+	}
+	return e, nil
+}
+
+// prepared is an input after applying the strategy: the texts to embed and their token counts used as averaging weights.
+type prepared struct {
+	texts   []string
+	weights []float64
+}
+
+// prepare applies the strategy to the documents.
+func (e *TokenLimitEmbeddingFunction) prepare(documents []string) ([]prepared, []InputModification, error) {
+	inputs := make([]prepared, len(documents))
+	modifications := make([]InputModification, 0)
+	for i, document := range documents {
+		tokens, err := e.counter.CountTokens(document)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tokens <= e.MaxTokens {
+			inputs[i] = prepared{texts: []string{document}, weights: []float64{1}}
+			continue
+		}
+		modification := InputModification{Index: i, Strategy: e.strategy, OriginalTokens: tokens}
+		switch e.strategy {
+		case StrategyReject:
+		case StrategyTruncate:
+			truncated, err := e.counter.Truncate(document, e.MaxTokens)
+			if err != nil {
+				return nil, nil, err
+			}
+			if modification.Tokens, err = e.counter.CountTokens(truncated); err != nil {
+				return nil, nil, err
+			}
+			modification.Chunks = 1
+			inputs[i] = prepared{texts: []string{truncated}, weights: []float64{1}}
+		case StrategySplitAverage:
+			chunks, weights, err := e.split(document)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, w := range weights {
+				modification.Tokens += int(w)
+			}
+			modification.Chunks = len(chunks)
+			inputs[i] = prepared{texts: chunks, weights: weights}
+		}
+		modifications = append(modifications, modification)
+	}
+	if e.strategy == StrategyReject && len(modifications) > 0 {
+		return nil, nil, &InputTooLongError{MaxTokens: e.MaxTokens, Inputs: modifications}
+	}
+	return inputs, modifications, nil
+}
+
+// split cuts the document into chunks of at most MaxTokens tokens and returns them with their token counts.
+func (e *TokenLimitEmbeddingFunction) split(document string) ([]string, []float64, error) {
+	chunks := make([]string, 0)
+	weights := make([]float64, 0)
+	rest := document
+	for strings.TrimSpace(rest) != "" {
+		chunk, err := e.counter.Truncate(rest, e.MaxTokens)
+		if err != nil {
+			return nil, nil, err
+		}
+		if chunk == "" {
+			return nil, nil, fmt.Errorf("cannot split input into chunks of %d tokens", e.MaxTokens)
+		}
+		tokens, err := e.counter.CountTokens(chunk)
+		if err != nil {
+			return nil, nil, err
+		}
+		if strings.TrimSpace(chunk) != "" {
+			chunks = append(chunks, chunk)
+			weights = append(weights, float64(max(tokens, 1)))
+		}
+		rest = rest[len(chunk):]
+	}
+	return chunks, weights, nil
+}
+
+// embed embeds all texts of the prepared inputs in batches and combines the chunk embeddings of split inputs.
+func (e *TokenLimitEmbeddingFunction) embed(ctx context.Context, inputs []prepared) ([]*types.Embedding, error) {
+	texts := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		texts = append(texts, input.texts...)
+	}
+	batchSize := len(texts)
+	if e.maxBatchSize > 0 {
+		batchSize = e.maxBatchSize
+	}
+	embedded := make([]*types.Embedding, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		batch, err := e.ef.EmbedDocuments(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("embedding function returned %d embeddings for %d inputs", len(batch), end-start)
+		}
+		embedded = append(embedded, batch...)
+	}
+	embeddings := make([]*types.Embedding, len(inputs))
+	offset := 0
+	for i, input := range inputs {
+		chunkEmbeddings := embedded[offset : offset+len(input.texts)]
+		offset += len(input.texts)
+		if len(chunkEmbeddings) == 1 {
+			embeddings[i] = chunkEmbeddings[0]
+			continue
+		}
+		average, err := weightedAverage(chunkEmbeddings, input.weights)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = average
+	}
+	return embeddings, nil
+}
+
+// weightedAverage returns the weighted average of the embeddings normalized to unit length.
+func weightedAverage(embeddings []*types.Embedding, weights []float64) (*types.Embedding, error) {
+	if err := types.CheckDimensions(embeddings...); err != nil {
+		return nil, err
+	}
+	sum := make([]float64, embeddings[0].Len())
+	for i, embedding := range embeddings {
+		for j, v := range embedding.ToFloat32() {
+			sum[j] += weights[i] * float64(v)
+		}
+	}
+	average := make([]float32, len(sum))
+	for j, v := range sum {
+		average[j] = float32(v)
+	}
+	return types.NewEmbeddingFromFloat32(average).Normalize()
+}
+
+// EmbedDocumentsWithReport embeds the documents and returns the inputs that were modified to fit the limit.
+func (e *TokenLimitEmbeddingFunction) EmbedDocumentsWithReport(ctx context.Context, documents []string) ([]*types.Embedding, []InputModification, error) {
+	inputs, modifications, err := e.prepare(documents)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(modifications) > 0 && e.handler != nil {
+		e.handler(ctx, modifications)
+	}
+	embeddings, err := e.embed(ctx, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return embeddings, modifications, nil
+}
+
+func (e *TokenLimitEmbeddingFunction) EmbedDocuments(ctx context.Context, documents []string) ([]*types.Embedding, error) {
+	embeddings, _, err := e.EmbedDocumentsWithReport(ctx, documents)
+	return embeddings, err
+}
+
+// EmbedQuery applies the strategy to the query. Queries that fit the limit are embedded with the EmbedQuery method of the
+// wrapped embedding function, split queries with EmbedDocuments.
+func (e *TokenLimitEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
+	inputs, modifications, err := e.prepare([]string{document})
+	if err != nil {
+		return nil, err
+	}
+	if len(modifications) > 0 && e.handler != nil {
+		e.handler(ctx, modifications)
+	}
+	if len(inputs[0].texts) == 1 {
+		return e.ef.EmbedQuery(ctx, inputs[0].texts[0])
+	}
+	embeddings, err := e.embed(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e *TokenLimitEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
+}
@@ -0,0 +1,139 @@
+//go:build ef
+
+package tokenlimit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// recordingEF embeds each text as [len(text), 1] and records the batches it receives.
+type recordingEF struct {
+	batches [][]string
+	queries []string
+}
+
+func (r *recordingEF) EmbedDocuments(_ context.Context, documents []string) ([]*types.Embedding, error) {
+	r.batches = append(r.batches, documents)
+	embeddings := make([]*types.Embedding, len(documents))
+	for i, d := range documents {
+		embeddings[i] = types.NewEmbeddingFromFloat32([]float32{float32(len(d)), 1})
+	}
+	return embeddings, nil
+}
+
+func (r *recordingEF) EmbedQuery(_ context.Context, document string) (*types.Embedding, error) {
+	r.queries = append(r.queries, document)
+	return types.NewEmbeddingFromFloat32([]float32{float32(len(document)), 1}), nil
+}
+
+func (r *recordingEF) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(r, ctx, records, force)
+}
+
+func TestHeuristicCounter(t *testing.T) {
+	counter, err := NewHeuristicCounter(2)
+	require.NoError(t, err)
+	tokens, err := counter.CountTokens("hello")
+	require.NoError(t, err)
+	require.Equal(t, 3, tokens)
+	truncated, err := counter.Truncate("hello world foo", 5)
+	require.NoError(t, err)
+	require.Equal(t, "hello", truncated, "cuts at whitespace instead of inside a word")
+	truncated, err = counter.Truncate("hello world", 3)
+	require.NoError(t, err)
+	require.Equal(t, "hello", truncated)
+	truncated, err = counter.Truncate("ééééé", 1)
+	require.NoError(t, err)
+	require.Equal(t, "éé", truncated)
+	truncated, err = counter.Truncate("short", 10)
+	require.NoError(t, err)
+	require.Equal(t, "short", truncated)
+	_, err = NewHeuristicCounter(0)
+	require.Error(t, err)
+}
+
+func TestTokenLimitEmbeddingFunction(t *testing.T) {
+	counter, err := NewHeuristicCounter(1)
+	require.NoError(t, err)
+	documents := []string{"short", "aaaa bbbb cccc dd", "ok"}
+
+	t.Run("Test truncate", func(t *testing.T) {
+		inner := &recordingEF{}
+		var handled []InputModification
+		ef, err := NewTokenLimitEmbeddingFunction(inner, 10, WithTokenCounter(counter), WithModificationHandler(func(_ context.Context, m []InputModification) {
+			handled = m
+		}))
+		require.NoError(t, err)
+		embeddings, modifications, err := ef.EmbedDocumentsWithReport(context.Background(), documents)
+		require.NoError(t, err)
+		require.Len(t, embeddings, 3)
+		require.Equal(t, [][]string{{"short", "aaaa bbbb", "ok"}}, inner.batches)
+		require.Equal(t, []InputModification{{Index: 1, Strategy: StrategyTruncate, OriginalTokens: 17, Tokens: 9, Chunks: 1}}, modifications)
+		require.Equal(t, modifications, handled)
+	})
+
+	t.Run("Test split and average", func(t *testing.T) {
+		inner := &recordingEF{}
+		ef, err := NewTokenLimitEmbeddingFunction(inner, 10, WithTokenCounter(counter), WithStrategy(StrategySplitAverage), WithMaxBatchSize(2))
+		require.NoError(t, err)
+		embeddings, modifications, err := ef.EmbedDocumentsWithReport(context.Background(), documents)
+		require.NoError(t, err)
+		require.Equal(t, [][]string{{"short", "aaaa bbbb"}, {" cccc dd", "ok"}}, inner.batches)
+		require.Equal(t, []InputModification{{Index: 1, Strategy: StrategySplitAverage, OriginalTokens: 17, Tokens: 17, Chunks: 2}}, modifications)
+		require.Len(t, embeddings, 3)
+		// weighted average of [9, 1] * 9 and [8, 1] * 8, normalized
+		expected, err := types.NewEmbeddingFromFloat32([]float32{81 + 64, 17}).Normalize()
+		require.NoError(t, err)
+		require.InDeltaSlice(t, expected.ToFloat32(), embeddings[1].ToFloat32(), 1e-6)
+		require.Equal(t, []float32{5, 1}, embeddings[0].ToFloat32())
+	})
+
+	t.Run("Test reject", func(t *testing.T) {
+		inner := &recordingEF{}
+		ef, err := NewTokenLimitEmbeddingFunction(inner, 4, WithTokenCounter(counter), WithStrategy(StrategyReject))
+		require.NoError(t, err)
+		_, err = ef.EmbedDocuments(context.Background(), documents)
+		require.Error(t, err)
+		var tooLong *InputTooLongError
+		require.ErrorAs(t, err, &tooLong)
+		require.Equal(t, 4, tooLong.MaxTokens)
+		require.Len(t, tooLong.Inputs, 2)
+		require.Equal(t, 0, tooLong.Inputs[0].Index)
+		require.Equal(t, 1, tooLong.Inputs[1].Index)
+		require.Empty(t, inner.batches, "nothing is sent to the provider")
+	})
+
+	t.Run("Test query", func(t *testing.T) {
+		inner := &recordingEF{}
+		ef, err := NewTokenLimitEmbeddingFunction(inner, 10, WithTokenCounter(counter))
+		require.NoError(t, err)
+		_, err = ef.EmbedQuery(context.Background(), strings.Repeat("word ", 5))
+		require.NoError(t, err)
+		require.Equal(t, []string{"word word"}, inner.queries)
+
+		ef, err = NewTokenLimitEmbeddingFunction(inner, 10, WithTokenCounter(counter), WithStrategy(StrategySplitAverage))
+		require.NoError(t, err)
+		query, err := ef.EmbedQuery(context.Background(), strings.Repeat("word ", 5))
+		require.NoError(t, err)
+		require.InDelta(t, 1, query.Norm(), 1e-6)
+		require.Len(t, inner.batches, 1)
+	})
+
+	t.Run("Test default heuristic counter and invalid options", func(t *testing.T) {
+		ef, err := NewTokenLimitEmbeddingFunction(&recordingEF{}, OpenAIMaxTokens, WithTokenizerFile("", "does-not-exist.json"))
+		require.NoError(t, err)
+		require.IsType(t, &HeuristicCounter{}, ef.counter)
+		_, err = NewTokenLimitEmbeddingFunction(nil, 10)
+		require.Error(t, err)
+		_, err = NewTokenLimitEmbeddingFunction(&recordingEF{}, 0)
+		require.Error(t, err)
+		_, err = NewTokenLimitEmbeddingFunction(&recordingEF{}, 10, WithStrategy("drop"))
+		require.Error(t, err)
+	})
+}
@@ -2,6 +2,7 @@ package voyage
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 )
@@ -67,3 +68,11 @@ func WithEncodingFormat(format EncodingFormat) Option {
 		return nil
 	}
 }
+
+// WithLogger sets a logger for the embedding requests to VoyageAI, by default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *VoyageAIClient) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
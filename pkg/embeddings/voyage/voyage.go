@@ -10,6 +10,7 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"time"
 
 	"github.com/szirtesitidom/chroma-go/types"
 )
@@ -44,6 +45,7 @@ type VoyageAIClient struct {
 	DefaultTruncation     *bool
 	DefaultEncodingFormat *EncodingFormat
 	Client                *http.Client
+	types.RequestLogger
 }
 
 func applyDefaults(c *VoyageAIClient) {
@@ -111,6 +113,18 @@ func (e *EmbeddingInputs) MarshalJSON() ([]byte, error) {
 	return nil, fmt.Errorf("EmbeddingInput has no data")
 }
 
+// size returns the number of inputs.
+func (e *EmbeddingInputs) size() int {
+	switch {
+	case e == nil:
+		return 0
+	case e.Input != "":
+		return 1
+	default:
+		return len(e.Inputs)
+	}
+}
+
 // from voyageai python client - https://github.com/voyage-ai/voyageai-python/blob/e565fb60b854e80ead526a57ea0e6eb1db9efc33/voyageai/api_resources/embedding.py#L30-L32
 func bytesToFloat32s(b []byte) ([]float32, error) {
 	if len(b)%4 != 0 {
@@ -183,10 +197,13 @@ func (c *CreateEmbeddingRequest) JSON() (string, error) {
 	return string(data), nil
 }
 
-func (c *VoyageAIClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (*CreateEmbeddingResponse, error) {
+func (c *VoyageAIClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (_ *CreateEmbeddingResponse, err error) {
 	if req == nil {
 		return nil, fmt.Errorf("request is nil")
 	}
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "voyageai", req.Model, req.Input.size(), start, err)
+	}(time.Now())
 	reqJSON, err := req.JSON()
 	if err != nil {
 		return nil, err
@@ -222,8 +239,10 @@ func (c *VoyageAIClient) CreateEmbedding(ctx context.Context, req *CreateEmbeddi
 }
 
 var _ types.EmbeddingFunction = (*VoyageAIEmbeddingFunction)(nil)
+var _ types.UsageReporter = (*VoyageAIEmbeddingFunction)(nil)
 
 type VoyageAIEmbeddingFunction struct {
+	types.UsageTracker
 	apiClient *VoyageAIClient
 }
 
@@ -290,6 +309,7 @@ func (e *VoyageAIEmbeddingFunction) EmbedDocuments(ctx context.Context, document
 	if err != nil {
 		return nil, err
 	}
+	e.recordUsage(ctx, req.Model, response)
 	embeddings := make([]*types.Embedding, 0, len(response.Data))
 	for _, result := range response.Data {
 		embeddings = append(embeddings, types.NewEmbeddingFromFloat32(result.Embedding.Floats))
@@ -309,9 +329,23 @@ func (e *VoyageAIEmbeddingFunction) EmbedQuery(ctx context.Context, document str
 	if err != nil {
 		return nil, err
 	}
+	e.recordUsage(ctx, req.Model, response)
 	return types.NewEmbeddingFromFloat32(response.Data[0].Embedding.Floats), nil
 }
 
+func (e *VoyageAIEmbeddingFunction) recordUsage(ctx context.Context, model string, response *CreateEmbeddingResponse) {
+	usage := types.Usage{Provider: "voyageai", Model: model, Requests: 1}
+	if response.Model != "" {
+		usage.Model = response.Model
+	}
+	if response.Usage != nil {
+		// Voyage AI only reports total tokens, all of which are input tokens
+		usage.PromptTokens = response.Usage.TotalTokens
+		usage.TotalTokens = response.Usage.TotalTokens
+	}
+	e.RecordUsage(ctx, usage)
+}
+
 func (e *VoyageAIEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
 	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
 }
@@ -0,0 +1,320 @@
+package quantization
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// Quantizer converts float embeddings to a compact integer representation stored in types.Embedding.ArrayOfInt32.
+type Quantizer interface {
+	Quantize(embedding *types.Embedding) (*types.Embedding, error)
+	Dequantize(embedding *types.Embedding) (*types.Embedding, error)
+}
+
+// ScalarQuantizer maps each dimension linearly from its calibrated [Min, Max] range to int8 values in [-128, 127].
+// Values outside the range are clipped.
+type ScalarQuantizer struct {
+	Min []float32 `json:"min"`
+	Max []float32 `json:"max"`
+}
+
+var _ Quantizer = (*ScalarQuantizer)(nil)
+
+type ScalarOption func(*scalarCalibration) error
+
+type scalarCalibration struct {
+	lowerPercentile float64
+	upperPercentile float64
+}
+
+// WithPercentiles calibrates the range of each dimension to the given percentiles (0-100) instead of the min and max values,
+// which makes the quantizer robust to outliers.
+func WithPercentiles(lower, upper float64) ScalarOption {
+	return func(c *scalarCalibration) error {
+		if lower < 0 || upper > 100 || lower >= upper {
+			return fmt.Errorf("percentiles must satisfy 0 <= lower < upper <= 100")
+		}
+		c.lowerPercentile = lower
+		c.upperPercentile = upper
+		return nil
+	}
+}
+
+// NewScalarQuantizer calibrates an int8 scalar quantizer on the sample embeddings. By default the min and max of each dimension are used.
+func NewScalarQuantizer(sample []*types.Embedding, opts ...ScalarOption) (*ScalarQuantizer, error) {
+	calibration := &scalarCalibration{lowerPercentile: 0, upperPercentile: 100}
+	for _, opt := range opts {
+		if err := opt(calibration); err != nil {
+			return nil, err
+		}
+	}
+	if len(sample) == 0 {
+		return nil, fmt.Errorf("at least one embedding is required for calibration")
+	}
+	if err := types.CheckDimensions(sample...); err != nil {
+		return nil, err
+	}
+	dim := sample[0].Len()
+	q := &ScalarQuantizer{Min: make([]float32, dim), Max: make([]float32, dim)}
+	values := make([]float32, len(sample))
+	vectors := make([][]float32, len(sample))
+	for i, e := range sample {
+		vectors[i] = e.ToFloat32()
+	}
+	for d := 0; d < dim; d++ {
+		for i, v := range vectors {
+			values[i] = v[d]
+		}
+		sort.Slice(values, func(a, b int) bool { return values[a] < values[b] })
+		q.Min[d] = percentile(values, calibration.lowerPercentile)
+		q.Max[d] = percentile(values, calibration.upperPercentile)
+	}
+	return q, nil
+}
+
+// percentile returns the p-th percentile (0-100) of the sorted values using linear interpolation.
+func percentile(sorted []float32, p float64) float32 {
+	pos := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	frac := float32(pos - float64(lo))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*frac
+}
+
+func (q *ScalarQuantizer) checkDimension(embedding *types.Embedding) error {
+	if embedding == nil || !embedding.IsDefined() {
+		return &types.EmptyEmbeddingError{}
+	}
+	if embedding.Len() != len(q.Min) {
+		return &types.DimensionMismatchError{Expected: len(q.Min), Actual: embedding.Len()}
+	}
+	return nil
+}
+
+// Quantize returns the int8 representation of the embedding.
+func (q *ScalarQuantizer) Quantize(embedding *types.Embedding) (*types.Embedding, error) {
+	if err := q.checkDimension(embedding); err != nil {
+		return nil, err
+	}
+	v := embedding.ToFloat32()
+	out := make([]int32, len(v))
+	for d, x := range v {
+		span := q.Max[d] - q.Min[d]
+		if span <= 0 {
+			out[d] = math.MinInt8
+			continue
+		}
+		scaled := math.Round(float64((x-q.Min[d])/span)*255) + math.MinInt8
+		out[d] = int32(math.Max(math.MinInt8, math.Min(math.MaxInt8, scaled)))
+	}
+	return types.NewEmbeddingFromInt32(out), nil
+}
+
+// Dequantize maps an int8 embedding produced by Quantize back to approximate float values.
+func (q *ScalarQuantizer) Dequantize(embedding *types.Embedding) (*types.Embedding, error) {
+	if err := q.checkDimension(embedding); err != nil {
+		return nil, err
+	}
+	v := embedding.ToFloat32()
+	out := make([]float32, len(v))
+	for d, x := range v {
+		out[d] = q.Min[d] + (x-math.MinInt8)/255*(q.Max[d]-q.Min[d])
+	}
+	return types.NewEmbeddingFromFloat32(out), nil
+}
+
+// BinaryQuantizer keeps one bit per dimension (1 if the value is greater than the threshold of the dimension) and packs the bits
+// most significant bit first into bytes, stored as int32 values in [0, 255]. This is the same layout as Cohere's ubinary embeddings.
+type BinaryQuantizer struct {
+	Dimension  int       `json:"dimension"`
+	Thresholds []float32 `json:"thresholds,omitempty"` // per dimension thresholds, zero if empty
+}
+
+var _ Quantizer = (*BinaryQuantizer)(nil)
+
+// NewBinaryQuantizer returns a binary quantizer for embeddings of the given dimension that thresholds at zero.
+func NewBinaryQuantizer(dimension int) (*BinaryQuantizer, error) {
+	if dimension < 1 {
+		return nil, fmt.Errorf("dimension must be greater than 0")
+	}
+	return &BinaryQuantizer{Dimension: dimension}, nil
+}
+
+// NewCalibratedBinaryQuantizer returns a binary quantizer that thresholds each dimension at its median in the sample embeddings.
+func NewCalibratedBinaryQuantizer(sample []*types.Embedding) (*BinaryQuantizer, error) {
+	scalar, err := NewScalarQuantizer(sample, WithPercentiles(50, 100))
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryQuantizer{Dimension: len(scalar.Min), Thresholds: scalar.Min}, nil
+}
+
+// Quantize returns the packed binary representation of the embedding.
+func (q *BinaryQuantizer) Quantize(embedding *types.Embedding) (*types.Embedding, error) {
+	if embedding == nil || !embedding.IsDefined() {
+		return nil, &types.EmptyEmbeddingError{}
+	}
+	if embedding.Len() != q.Dimension {
+		return nil, &types.DimensionMismatchError{Expected: q.Dimension, Actual: embedding.Len()}
+	}
+	v := embedding.ToFloat32()
+	packed := make([]byte, (len(v)+7)/8)
+	for d, x := range v {
+		var threshold float32
+		if len(q.Thresholds) > 0 {
+			threshold = q.Thresholds[d]
+		}
+		if x > threshold {
+			packed[d/8] |= 0x80 >> (d % 8)
+		}
+	}
+	return BinaryEmbedding(packed), nil
+}
+
+// Dequantize unpacks a binary embedding to +1/-1 float values.
+func (q *BinaryQuantizer) Dequantize(embedding *types.Embedding) (*types.Embedding, error) {
+	packed, err := PackedBits(embedding)
+	if err != nil {
+		return nil, err
+	}
+	if len(packed) != (q.Dimension+7)/8 {
+		return nil, &types.DimensionMismatchError{Expected: (q.Dimension + 7) / 8, Actual: len(packed)}
+	}
+	out := make([]float32, q.Dimension)
+	for d := range out {
+		out[d] = -1
+		if packed[d/8]&(0x80>>(d%8)) != 0 {
+			out[d] = 1
+		}
+	}
+	return types.NewEmbeddingFromFloat32(out), nil
+}
+
+// PackedBits returns the bytes of a binary embedding.
+func PackedBits(embedding *types.Embedding) ([]byte, error) {
+	if embedding == nil || embedding.ArrayOfInt32 == nil || len(*embedding.ArrayOfInt32) == 0 {
+		return nil, fmt.Errorf("binary embeddings must be non-empty int32 embeddings")
+	}
+	packed := make([]byte, len(*embedding.ArrayOfInt32))
+	for i, v := range *embedding.ArrayOfInt32 {
+		if v < 0 || v > math.MaxUint8 {
+			return nil, fmt.Errorf("binary embedding value %d at %d is not a byte", v, i)
+		}
+		packed[i] = byte(v)
+	}
+	return packed, nil
+}
+
+// HammingDistance returns the number of differing bits between two binary embeddings.
+func HammingDistance(a, b *types.Embedding) (int, error) {
+	pa, err := PackedBits(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := PackedBits(b)
+	if err != nil {
+		return 0, err
+	}
+	if len(pa) != len(pb) {
+		return 0, &types.DimensionMismatchError{Expected: len(pa), Actual: len(pb)}
+	}
+	return HammingDistanceBytes(pa, pb), nil
+}
+
+// HammingDistanceBytes returns the number of differing bits between a and b, which must have the same length.
+func HammingDistanceBytes(a, b []byte) int {
+	b = b[:len(a)]
+	distance := 0
+	for i := range a {
+		distance += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return distance
+}
+
+// BinaryEmbedding wraps packed bits (ubinary) as an embedding.
+func BinaryEmbedding(packed []uint8) *types.Embedding {
+	out := make([]int32, len(packed))
+	for i, v := range packed {
+		out[i] = int32(v)
+	}
+	return types.NewEmbeddingFromInt32(out)
+}
+
+// SignedBinaryEmbedding converts signed packed bits (Cohere's binary type, which is ubinary - 128) to the unsigned layout used by BinaryEmbedding,
+// so that both types are stored and compared the same way.
+func SignedBinaryEmbedding(packed []int8) *types.Embedding {
+	out := make([]int32, len(packed))
+	for i, v := range packed {
+		out[i] = int32(v) + 128
+	}
+	return types.NewEmbeddingFromInt32(out)
+}
+
+// Int8Embedding wraps int8 values as an embedding.
+func Int8Embedding(values []int8) *types.Embedding {
+	out := make([]int32, len(values))
+	for i, v := range values {
+		out[i] = int32(v)
+	}
+	return types.NewEmbeddingFromInt32(out)
+}
+
+// Uint8Embedding wraps uint8 values as an embedding.
+func Uint8Embedding(values []uint8) *types.Embedding {
+	out := make([]int32, len(values))
+	for i, v := range values {
+		out[i] = int32(v)
+	}
+	return types.NewEmbeddingFromInt32(out)
+}
+
+// QuantizingEmbeddingFunction quantizes the float embeddings of the wrapped embedding function, e.g. to store int8 or binary vectors
+// produced by the default embedding function.
+type QuantizingEmbeddingFunction struct {
+	ef        types.EmbeddingFunction
+	quantizer Quantizer
+}
+
+var _ types.EmbeddingFunction = (*QuantizingEmbeddingFunction)(nil)
+
+func NewQuantizingEmbeddingFunction(ef types.EmbeddingFunction, quantizer Quantizer) (*QuantizingEmbeddingFunction, error) {
+	if ef == nil {
+		return nil, fmt.Errorf("embedding function must not be nil")
+	}
+	if quantizer == nil {
+		return nil, fmt.Errorf("quantizer must not be nil")
+	}
+	return &QuantizingEmbeddingFunction{ef: ef, quantizer: quantizer}, nil
+}
+
+func (e *QuantizingEmbeddingFunction) EmbedDocuments(ctx context.Context, documents []string) ([]*types.Embedding, error) {
+	embeddings, err := e.ef.EmbedDocuments(ctx, documents)
+	if err != nil {
+		return nil, err
+	}
+	quantized := make([]*types.Embedding, len(embeddings))
+	for i, embedding := range embeddings {
+		quantized[i], err = e.quantizer.Quantize(embedding)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return quantized, nil
+}
+
+func (e *QuantizingEmbeddingFunction) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
+	embedding, err := e.ef.EmbedQuery(ctx, document)
+	if err != nil {
+		return nil, err
+	}
+	return e.quantizer.Quantize(embedding)
+}
+
+func (e *QuantizingEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
+}
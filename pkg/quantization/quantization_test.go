@@ -0,0 +1,121 @@
+//go:build basic
+
+package quantization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+func TestScalarQuantizer(t *testing.T) {
+	sample := types.NewEmbeddingsFromFloat32([][]float32{
+		{-1, 0, 10},
+		{0, 0.5, 20},
+		{1, 1, 30},
+	})
+
+	t.Run("Test min max calibration", func(t *testing.T) {
+		q, err := NewScalarQuantizer(sample)
+		require.NoError(t, err)
+		require.Equal(t, []float32{-1, 0, 10}, q.Min)
+		require.Equal(t, []float32{1, 1, 30}, q.Max)
+		quantized, err := q.Quantize(types.NewEmbeddingFromFloat32([]float32{-1, 1, 100}))
+		require.NoError(t, err)
+		require.Equal(t, []int32{-128, 127, 127}, *quantized.ArrayOfInt32, "values outside the range are clipped")
+	})
+
+	t.Run("Test round trip", func(t *testing.T) {
+		q, err := NewScalarQuantizer(sample)
+		require.NoError(t, err)
+		for _, e := range sample {
+			quantized, err := q.Quantize(e)
+			require.NoError(t, err)
+			for _, v := range *quantized.ArrayOfInt32 {
+				require.GreaterOrEqual(t, v, int32(-128))
+				require.LessOrEqual(t, v, int32(127))
+			}
+			restored, err := q.Dequantize(quantized)
+			require.NoError(t, err)
+			require.InDeltaSlice(t, *e.ArrayOfFloat32, *restored.ArrayOfFloat32, 0.05)
+		}
+	})
+
+	t.Run("Test percentile calibration", func(t *testing.T) {
+		q, err := NewScalarQuantizer(sample, WithPercentiles(25, 75))
+		require.NoError(t, err)
+		require.InDeltaSlice(t, []float32{-0.5, 0.25, 15}, q.Min, 1e-6)
+		require.InDeltaSlice(t, []float32{0.5, 0.75, 25}, q.Max, 1e-6)
+		_, err = NewScalarQuantizer(sample, WithPercentiles(80, 20))
+		require.Error(t, err)
+	})
+
+	t.Run("Test invalid input", func(t *testing.T) {
+		_, err := NewScalarQuantizer(nil)
+		require.Error(t, err)
+		q, err := NewScalarQuantizer(sample)
+		require.NoError(t, err)
+		_, err = q.Quantize(types.NewEmbeddingFromFloat32([]float32{1}))
+		var dimErr *types.DimensionMismatchError
+		require.ErrorAs(t, err, &dimErr)
+	})
+}
+
+func TestBinaryQuantizer(t *testing.T) {
+	t.Run("Test quantize packs bits msb first", func(t *testing.T) {
+		q, err := NewBinaryQuantizer(10)
+		require.NoError(t, err)
+		quantized, err := q.Quantize(types.NewEmbeddingFromFloat32([]float32{1, -1, 1, -1, -1, -1, -1, 1, 0.5, -0.5}))
+		require.NoError(t, err)
+		require.Equal(t, []int32{0b10100001, 0b10000000}, *quantized.ArrayOfInt32)
+		restored, err := q.Dequantize(quantized)
+		require.NoError(t, err)
+		require.Equal(t, []float32{1, -1, 1, -1, -1, -1, -1, 1, 1, -1}, *restored.ArrayOfFloat32)
+	})
+
+	t.Run("Test calibrated thresholds", func(t *testing.T) {
+		q, err := NewCalibratedBinaryQuantizer(types.NewEmbeddingsFromFloat32([][]float32{{1, 10}, {2, 20}, {3, 30}}))
+		require.NoError(t, err)
+		require.Equal(t, []float32{2, 20}, q.Thresholds)
+		quantized, err := q.Quantize(types.NewEmbeddingFromFloat32([]float32{2.5, 15}))
+		require.NoError(t, err)
+		require.Equal(t, []int32{0b10000000}, *quantized.ArrayOfInt32)
+	})
+
+	t.Run("Test hamming distance", func(t *testing.T) {
+		a := BinaryEmbedding([]uint8{0b11110000, 0xFF})
+		b := BinaryEmbedding([]uint8{0b00010000, 0x0F})
+		d, err := HammingDistance(a, b)
+		require.NoError(t, err)
+		require.Equal(t, 7, d)
+		_, err = HammingDistance(a, BinaryEmbedding([]uint8{1}))
+		require.Error(t, err)
+		_, err = HammingDistance(a, types.NewEmbeddingFromInt32([]int32{300, 1}))
+		require.Error(t, err)
+	})
+
+	t.Run("Test signed binary matches unsigned layout", func(t *testing.T) {
+		require.Equal(t, *BinaryEmbedding([]uint8{0, 128, 255}).ArrayOfInt32, *SignedBinaryEmbedding([]int8{-128, 0, 127}).ArrayOfInt32)
+	})
+}
+
+func TestQuantizingEmbeddingFunction(t *testing.T) {
+	q, err := NewBinaryQuantizer(378)
+	require.NoError(t, err)
+	ef, err := NewQuantizingEmbeddingFunction(types.NewConsistentHashEmbeddingFunction(), q)
+	require.NoError(t, err)
+	embeddings, err := ef.EmbedDocuments(context.Background(), []string{"hello", "world"})
+	require.NoError(t, err)
+	require.Len(t, embeddings, 2)
+	require.Equal(t, 48, embeddings[0].Len())
+	query, err := ef.EmbedQuery(context.Background(), "hello")
+	require.NoError(t, err)
+	d, err := HammingDistance(query, embeddings[0])
+	require.NoError(t, err)
+	require.Equal(t, 0, d)
+	_, err = NewQuantizingEmbeddingFunction(nil, q)
+	require.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 
 	chromago "github.com/szirtesitidom/chroma-go"
 	ccommons "github.com/szirtesitidom/chroma-go/pkg/commons/cohere"
@@ -75,7 +76,10 @@ func NewCohereRerankingFunction(opts ...Option) (*CohereRerankingFunction, error
 	return rf, nil
 }
 
-func (c CohereRerankingFunction) Rerank(ctx context.Context, query string, results []rerankings.Result) (map[string][]rerankings.RankedResult, error) {
+func (c CohereRerankingFunction) Rerank(ctx context.Context, query string, results []rerankings.Result) (_ map[string][]rerankings.RankedResult, err error) {
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "cohere", c.DefaultModel.String(), len(results), start, err)
+	}(time.Now())
 	docs := make([]any, 0)
 	for _, result := range results {
 		d, err := result.ToText()
@@ -121,7 +125,7 @@ func (c CohereRerankingFunction) Rerank(ctx context.Context, query string, resul
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
-			fmt.Printf("Error closing body: %v\n", err)
+			c.Logger().Warn("failed to close response body", "provider", "cohere", "error", err)
 		}
 	}(resp.Body)
 	var rerankResp RerankResponse
@@ -153,7 +157,11 @@ func (c CohereRerankingFunction) ID() string {
 	return fmt.Sprintf("cohere-%s", c.DefaultModel)
 }
 
-func (c CohereRerankingFunction) RerankResults(ctx context.Context, queryResults *chromago.QueryResults) (*rerankings.RerankedChromaResults, error) {
+func (c CohereRerankingFunction) RerankResults(ctx context.Context, queryResults *chromago.QueryResults) (_ *rerankings.RerankedChromaResults, err error) {
+	var documents int
+	defer func(start time.Time) {
+		c.LogRequest(ctx, "cohere", c.DefaultModel.String(), documents, start, err)
+	}(time.Now())
 	rerankedResults := &rerankings.RerankedChromaResults{
 		QueryResults: *queryResults,
 		Ranks:        map[string][][]float32{c.ID(): make([][]float32, len(queryResults.Ids))},
@@ -166,6 +174,7 @@ func (c CohereRerankingFunction) RerankResults(ctx context.Context, queryResults
 		for _, result := range queryResults.Documents[i] {
 			docs = append(docs, result)
 		}
+		documents += len(docs)
 		req := &RerankRequest{
 			Model:           c.DefaultModel.String(),
 			Query:           queryResults.QueryTexts[i],
@@ -205,7 +214,7 @@ func (c CohereRerankingFunction) RerankResults(ctx context.Context, queryResults
 		defer func(Body io.ReadCloser) {
 			err := Body.Close()
 			if err != nil {
-				fmt.Printf("Error closing body: %v\n", err)
+				c.Logger().Warn("failed to close response body", "provider", "cohere", "error", err)
 			}
 		}(resp.Body)
 		var rerankResp RerankResponse
@@ -1,6 +1,8 @@
 package cohere
 
 import (
+	"log/slog"
+
 	ccommons "github.com/szirtesitidom/chroma-go/pkg/commons/cohere"
 	httpc "github.com/szirtesitidom/chroma-go/pkg/commons/http"
 )
@@ -68,3 +70,10 @@ func WithRetryStrategy(retryStrategy httpc.RetryStrategy) Option {
 		return ccommons.WithRetryStrategy(retryStrategy)
 	}
 }
+
+// WithLogger logs the rerank requests with the model, number of documents and latency
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *CohereRerankingFunction) ccommons.Option {
+		return ccommons.WithLogger(logger)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	chromago "github.com/szirtesitidom/chroma-go"
 	chttp "github.com/szirtesitidom/chroma-go/pkg/commons/http"
@@ -43,6 +44,7 @@ type HFRerankingFunction struct {
 	apiKey            string
 	defaultModel      *types.RerankingModel
 	rerankingEndpoint string
+	types.RequestLogger
 }
 
 func NewHFRerankingFunction(opts ...Option) (*HFRerankingFunction, error) {
@@ -56,7 +58,14 @@ func NewHFRerankingFunction(opts ...Option) (*HFRerankingFunction, error) {
 	return ef, nil
 }
 
-func (r *HFRerankingFunction) sendRequest(ctx context.Context, req *RerankingRequest) (*RerankingResponse, error) {
+func (r *HFRerankingFunction) sendRequest(ctx context.Context, req *RerankingRequest) (_ *RerankingResponse, err error) {
+	defer func(start time.Time) {
+		var model string
+		if req.Model != nil {
+			model = *req.Model
+		}
+		r.LogRequest(ctx, "huggingface", model, len(req.Texts), start, err)
+	}(time.Now())
 	payload, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
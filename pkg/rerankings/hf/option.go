@@ -2,6 +2,7 @@ package huggingface
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/szirtesitidom/chroma-go/types"
@@ -39,3 +40,11 @@ func WithRerankingEndpoint(endpoint string) Option {
 		return nil
 	}
 }
+
+// WithLogger sets the logger of the rerank requests, see types.RequestLogger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *HFRerankingFunction) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	chromago "github.com/szirtesitidom/chroma-go"
 	chttp "github.com/szirtesitidom/chroma-go/pkg/commons/http"
@@ -63,6 +64,7 @@ type JinaRerankingFunction struct {
 	rerankingEndpoint string
 	returnDocuments   *bool
 	topN              *int
+	types.RequestLogger
 }
 
 func NewJinaRerankingFunction(opts ...Option) (*JinaRerankingFunction, error) {
@@ -76,7 +78,10 @@ func NewJinaRerankingFunction(opts ...Option) (*JinaRerankingFunction, error) {
 	return ef, nil
 }
 
-func (r *JinaRerankingFunction) sendRequest(ctx context.Context, req *RerankingRequest) (*RerankingResponse, error) {
+func (r *JinaRerankingFunction) sendRequest(ctx context.Context, req *RerankingRequest) (_ *RerankingResponse, err error) {
+	defer func(start time.Time) {
+		r.LogRequest(ctx, "jina", req.Model, len(req.Documents), start, err)
+	}(time.Now())
 	if req.TopN == nil {
 		var dlen = len(req.Documents)
 		req.TopN = &dlen
@@ -2,6 +2,7 @@ package jina
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/szirtesitidom/chroma-go/types"
@@ -56,3 +57,11 @@ func WithReturnDocuments(returnDocuments bool) Option {
 		return nil
 	}
 }
+
+// WithLogger logs the requests to the Jina reranker API at debug level and failed requests as warnings.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *JinaRerankingFunction) error {
+		c.SetLogger(logger)
+		return nil
+	}
+}
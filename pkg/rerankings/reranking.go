@@ -19,6 +19,15 @@ type RerankedChromaResults struct {
 	Ranks map[string][][]float32 // each reranker adds a rank for each result
 }
 
+// MarshalRows serializes the reranked results in the same shape as chromago.QueryResults.MarshalRows with the additional ranks.
+// json.Marshal keeps the column encoding of the struct fields.
+func (r *RerankedChromaResults) MarshalRows() ([]byte, error) {
+	return json.Marshal(struct {
+		Queries []chromago.QueryResult `json:"queries"`
+		Ranks   map[string][][]float32 `json:"ranks"`
+	}{Queries: r.QueryResults.Queries(), Ranks: r.Ranks})
+}
+
 type Result struct {
 	Text   *string
 	Object *any
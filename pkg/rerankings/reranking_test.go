@@ -2,6 +2,7 @@ package rerankings
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -89,4 +90,23 @@ func Test_reranking_function(t *testing.T) {
 		require.Equal(t, results.Documents, rerankedResults.Documents)
 		require.Equal(t, results.QueryTexts, rerankedResults.QueryTexts)
 	})
+	t.Run("Marshal reranked results keeps ranks", func(t *testing.T) {
+		results := &RerankedChromaResults{
+			QueryResults: chromago.QueryResults{
+				Ids:        [][]string{{"1"}},
+				Documents:  [][]string{{"hello"}},
+				QueryTexts: []string{"hello world"},
+			},
+			Ranks: map[string][][]float32{rerankingFunction.ID(): {{0.5}}},
+		}
+		data, err := results.MarshalRows()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"queries":[{"query_text":"hello world","rows":[{"id":"1","document":"hello"}]}],"ranks":{"dummy":[[0.5]]}}`, string(data))
+
+		data, err = json.Marshal(results)
+		require.NoError(t, err)
+		var decoded RerankedChromaResults
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, *results, decoded, "json.Marshal keeps the column encoding")
+	})
 }
@@ -0,0 +1,193 @@
+package chromago
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// ResultRow is a single record of a query or get result. Fields that were not included in the request are left empty.
+type ResultRow struct {
+	ID        string                 `json:"id"`
+	Document  string                 `json:"document,omitempty"`
+	URI       string                 `json:"uri,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Distance  *float32               `json:"distance,omitempty"`
+	Score     *float32               `json:"score,omitempty"`
+	Embedding *types.Embedding       `json:"embedding,omitempty"`
+}
+
+// GetString returns the metadata value for key if it is a string.
+func (r ResultRow) GetString(key string) (string, bool) {
+	v, ok := r.Metadata[key].(string)
+	return v, ok
+}
+
+// GetBool returns the metadata value for key if it is a bool.
+func (r ResultRow) GetBool(key string) (bool, bool) {
+	v, ok := r.Metadata[key].(bool)
+	return v, ok
+}
+
+// GetFloat returns the metadata value for key if it is a number.
+func (r ResultRow) GetFloat(key string) (float64, bool) {
+	return toFloat64(r.Metadata[key])
+}
+
+// GetInt returns the metadata value for key if it is a whole number. Chroma returns all numbers as JSON numbers, so 2.0 is returned as 2.
+func (r ResultRow) GetInt(key string) (int64, bool) {
+	f, ok := toFloat64(r.Metadata[key])
+	if !ok || f != math.Trunc(f) || f > math.MaxInt64 || f < math.MinInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// ToRecord converts the row to a types.Record.
+func (r ResultRow) ToRecord() *types.Record {
+	record := &types.Record{
+		ID:       r.ID,
+		Document: r.Document,
+		URI:      r.URI,
+		Metadata: r.Metadata,
+	}
+	if r.Embedding != nil {
+		record.Embedding = *r.Embedding
+	}
+	return record
+}
+
+// Rows returns the records of the query at queryIndex (the order of query embeddings followed by query texts). Returns nil if the index is out of range.
+func (r *QueryResults) Rows(queryIndex int) []ResultRow {
+	if queryIndex < 0 || queryIndex >= len(r.Ids) {
+		return nil
+	}
+	ids := r.Ids[queryIndex]
+	documents := valueAt(r.Documents, queryIndex)
+	metadatas := valueAt(r.Metadatas, queryIndex)
+	distances := valueAt(r.Distances, queryIndex)
+	vectorDistances := valueAt(r.VectorDistances, queryIndex)
+	embeddings := valueAt(r.Embeddings, queryIndex)
+	scores := valueAt(r.Scores, queryIndex)
+	uris := valueAt(r.Uris, queryIndex)
+	rows := make([]ResultRow, len(ids))
+	for i, id := range ids {
+		rows[i] = ResultRow{
+			ID:        id,
+			Document:  valueAt(documents, i),
+			URI:       valueAt(uris, i),
+			Metadata:  valueAt(metadatas, i),
+			Embedding: definedEmbedding(valueAt(embeddings, i)),
+		}
+		if i < len(vectorDistances) {
+			if vectorDistances[i] != nil {
+				distance := *vectorDistances[i]
+				rows[i].Distance = &distance
+			}
+		} else if i < len(distances) {
+			distance := distances[i]
+			rows[i].Distance = &distance
+		}
+		if i < len(scores) {
+			score := scores[i]
+			rows[i].Score = &score
+		}
+	}
+	return rows
+}
+
+// ToRecords converts the results to records, one slice per query.
+func (r *QueryResults) ToRecords() [][]*types.Record {
+	records := make([][]*types.Record, len(r.Ids))
+	for q := range r.Ids {
+		rows := r.Rows(q)
+		records[q] = make([]*types.Record, len(rows))
+		for i, row := range rows {
+			records[q][i] = row.ToRecord()
+		}
+	}
+	return records
+}
+
+// query returns the query used at queryIndex, without rows.
+// QueryWithOptions sends the provided query embeddings first, followed by the embeddings generated from the query texts and
+// from the query URIs.
+func (r *QueryResults) query(queryIndex int) QueryResult {
+	if queryIndex < len(r.QueryEmbeddings) {
+		return QueryResult{QueryEmbedding: r.QueryEmbeddings[queryIndex]}
+	}
+	textIndex := queryIndex - len(r.QueryEmbeddings)
+	if textIndex < len(r.QueryTexts) {
+		return QueryResult{QueryText: r.QueryTexts[textIndex], QueryEmbedding: valueAt(r.QueryTextsGeneratedEmbeddings, textIndex)}
+	}
+	uriIndex := textIndex - len(r.QueryTexts)
+	return QueryResult{QueryURI: valueAt(r.QueryURIs, uriIndex), QueryEmbedding: valueAt(r.QueryURIsGeneratedEmbeddings, uriIndex)}
+}
+
+// QueryResult is the JSON representation of the results of a single query.
+type QueryResult struct {
+	QueryText      string           `json:"query_text,omitempty"`
+	QueryURI       string           `json:"query_uri,omitempty"`
+	QueryEmbedding *types.Embedding `json:"query_embedding,omitempty"`
+	Rows           []ResultRow      `json:"rows"`
+}
+
+// Queries returns the results grouped by query.
+func (r *QueryResults) Queries() []QueryResult {
+	queries := make([]QueryResult, len(r.Ids))
+	for q := range r.Ids {
+		queries[q] = r.query(q)
+		queries[q].Rows = r.Rows(q)
+	}
+	return queries
+}
+
+// MarshalRows serializes the results grouped by query as {"queries": [{"query_text": ..., "rows": [{"id": ..., ...}]}]}.
+// json.Marshal keeps the column encoding of the struct fields.
+func (r *QueryResults) MarshalRows() ([]byte, error) {
+	return json.Marshal(struct {
+		Queries []QueryResult `json:"queries"`
+	}{Queries: r.Queries()})
+}
+
+// Rows returns the records of the get result.
+func (r *GetResults) Rows() []ResultRow {
+	rows := make([]ResultRow, len(r.Ids))
+	for i, id := range r.Ids {
+		rows[i] = ResultRow{
+			ID:        id,
+			Document:  valueAt(r.Documents, i),
+			URI:       valueAt(r.Uris, i),
+			Metadata:  valueAt(r.Metadatas, i),
+			Embedding: definedEmbedding(valueAt(r.Embeddings, i)),
+		}
+	}
+	return rows
+}
+
+// ToRecords converts the results to records.
+func (r *GetResults) ToRecords() []*types.Record {
+	rows := r.Rows()
+	records := make([]*types.Record, len(rows))
+	for i, row := range rows {
+		records[i] = row.ToRecord()
+	}
+	return records
+}
+
+// MarshalRows serializes the results as {"rows": [{"id": ..., ...}]}. json.Marshal keeps the column encoding of the struct
+// fields.
+func (r *GetResults) MarshalRows() ([]byte, error) {
+	return json.Marshal(struct {
+		Rows []ResultRow `json:"rows"`
+	}{Rows: r.Rows()})
+}
+
+// definedEmbedding returns nil for embeddings that were not included in the response.
+func definedEmbedding(embedding *types.Embedding) *types.Embedding {
+	if embedding == nil || !embedding.IsDefined() {
+		return nil
+	}
+	return embedding
+}
@@ -22,6 +22,7 @@ type AddEmbedding struct {
 	Embeddings []EmbeddingsInner        `json:"embeddings,omitempty"`
 	Metadatas  []map[string]interface{} `json:"metadatas,omitempty"`
 	Documents  []string                 `json:"documents,omitempty"`
+	Uris       []string                 `json:"uris,omitempty"`
 	Ids        []string                 `json:"ids"`
 }
 
@@ -163,6 +164,38 @@ func (o *AddEmbedding) SetIds(v []string) {
 	o.Ids = v
 }
 
+// GetUris returns the Uris field value if set, zero value otherwise.
+func (o *AddEmbedding) GetUris() []string {
+	if o == nil || IsNil(o.Uris) {
+		var ret []string
+		return ret
+	}
+	return o.Uris
+}
+
+// GetUrisOk returns a tuple with the Uris field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *AddEmbedding) GetUrisOk() ([]string, bool) {
+	if o == nil || IsNil(o.Uris) {
+		return nil, false
+	}
+	return o.Uris, true
+}
+
+// HasUris returns a boolean if a field has been set.
+func (o *AddEmbedding) HasUris() bool {
+	if o != nil && !IsNil(o.Uris) {
+		return true
+	}
+
+	return false
+}
+
+// SetUris gets a reference to the given []string and assigns it to the Uris field.
+func (o *AddEmbedding) SetUris(v []string) {
+	o.Uris = v
+}
+
 func (o AddEmbedding) MarshalJSON() ([]byte, error) {
 	toSerialize, err := o.ToMap()
 	if err != nil {
@@ -183,6 +216,9 @@ func (o AddEmbedding) ToMap() (map[string]interface{}, error) {
 		toSerialize["documents"] = o.Documents
 	}
 	toSerialize["ids"] = o.Ids
+	if !IsNil(o.Uris) {
+		toSerialize["uris"] = o.Uris
+	}
 	return toSerialize, nil
 }
 
@@ -19,9 +19,10 @@ var _ MappedNullable = &Collection{}
 
 // Collection struct for Collection
 type Collection struct {
-	Name     string               `json:"name"`
-	Id       string               `json:"id"`
-	Metadata *map[string]Metadata `json:"metadata,omitempty"`
+	Name              string                 `json:"name"`
+	Id                string                 `json:"id"`
+	Metadata          *map[string]Metadata   `json:"metadata,omitempty"`
+	ConfigurationJson map[string]interface{} `json:"configuration_json,omitempty"`
 }
 
 // NewCollection instantiates a new Collection object
@@ -138,6 +139,9 @@ func (o Collection) ToMap() (map[string]interface{}, error) {
 	if !IsNil(o.Metadata) {
 		toSerialize["metadata"] = o.Metadata
 	}
+	if !IsNil(o.ConfigurationJson) {
+		toSerialize["configuration_json"] = o.ConfigurationJson
+	}
 	return toSerialize, nil
 }
 
@@ -19,9 +19,10 @@ var _ MappedNullable = &CreateCollection{}
 
 // CreateCollection struct for CreateCollection
 type CreateCollection struct {
-	Name        string                 `json:"name"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
-	GetOrCreate *bool                  `json:"get_or_create,omitempty"`
+	Name          string                 `json:"name"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	Configuration map[string]interface{} `json:"configuration,omitempty"`
+	GetOrCreate   *bool                  `json:"get_or_create,omitempty"`
 }
 
 // NewCreateCollection instantiates a new CreateCollection object
@@ -148,6 +149,9 @@ func (o CreateCollection) ToMap() (map[string]interface{}, error) {
 	if !IsNil(o.Metadata) {
 		toSerialize["metadata"] = o.Metadata
 	}
+	if !IsNil(o.Configuration) {
+		toSerialize["configuration"] = o.Configuration
+	}
 	if !IsNil(o.GetOrCreate) {
 		toSerialize["get_or_create"] = o.GetOrCreate
 	}
@@ -23,6 +23,7 @@ type GetResult struct {
 	Embeddings []EmbeddingsInner        `json:"embeddings"`
 	Documents  []string                 `json:"documents"`
 	Metadatas  []map[string]interface{} `json:"metadatas"`
+	Uris       []string                 `json:"uris,omitempty"`
 }
 
 // NewGetResult instantiates a new GetResult object
@@ -142,6 +143,38 @@ func (o *GetResult) SetMetadatas(v []map[string]interface{}) {
 	o.Metadatas = v
 }
 
+// GetUris returns the Uris field value if set, zero value otherwise.
+func (o *GetResult) GetUris() []string {
+	if o == nil || IsNil(o.Uris) {
+		var ret []string
+		return ret
+	}
+	return o.Uris
+}
+
+// GetUrisOk returns a tuple with the Uris field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *GetResult) GetUrisOk() ([]string, bool) {
+	if o == nil || IsNil(o.Uris) {
+		return nil, false
+	}
+	return o.Uris, true
+}
+
+// HasUris returns a boolean if a field has been set.
+func (o *GetResult) HasUris() bool {
+	if o != nil && !IsNil(o.Uris) {
+		return true
+	}
+
+	return false
+}
+
+// SetUris gets a reference to the given []string and assigns it to the Uris field.
+func (o *GetResult) SetUris(v []string) {
+	o.Uris = v
+}
+
 func (o GetResult) MarshalJSON() ([]byte, error) {
 	toSerialize, err := o.ToMap()
 	if err != nil {
@@ -156,6 +189,9 @@ func (o GetResult) ToMap() (map[string]interface{}, error) {
 	toSerialize["embeddings"] = o.Embeddings
 	toSerialize["documents"] = o.Documents
 	toSerialize["metadatas"] = o.Metadatas
+	if !IsNil(o.Uris) {
+		toSerialize["uris"] = o.Uris
+	}
 	return toSerialize, nil
 }
 
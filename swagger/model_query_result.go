@@ -24,6 +24,7 @@ type QueryResult struct {
 	Documents  [][]string                 `json:"documents"`
 	Metadatas  [][]map[string]interface{} `json:"metadatas"`
 	Distances  [][]float32                `json:"distances"`
+	Uris       [][]string                 `json:"uris,omitempty"`
 }
 
 // NewQueryResult instantiates a new QueryResult object
@@ -168,6 +169,38 @@ func (o *QueryResult) SetDistances(v [][]float32) {
 	o.Distances = v
 }
 
+// GetUris returns the Uris field value if set, zero value otherwise.
+func (o *QueryResult) GetUris() [][]string {
+	if o == nil || IsNil(o.Uris) {
+		var ret [][]string
+		return ret
+	}
+	return o.Uris
+}
+
+// GetUrisOk returns a tuple with the Uris field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *QueryResult) GetUrisOk() ([][]string, bool) {
+	if o == nil || IsNil(o.Uris) {
+		return nil, false
+	}
+	return o.Uris, true
+}
+
+// HasUris returns a boolean if a field has been set.
+func (o *QueryResult) HasUris() bool {
+	if o != nil && !IsNil(o.Uris) {
+		return true
+	}
+
+	return false
+}
+
+// SetUris gets a reference to the given [][]string and assigns it to the Uris field.
+func (o *QueryResult) SetUris(v [][]string) {
+	o.Uris = v
+}
+
 func (o QueryResult) MarshalJSON() ([]byte, error) {
 	toSerialize, err := o.ToMap()
 	if err != nil {
@@ -183,6 +216,9 @@ func (o QueryResult) ToMap() (map[string]interface{}, error) {
 	toSerialize["documents"] = o.Documents
 	toSerialize["metadatas"] = o.Metadatas
 	toSerialize["distances"] = o.Distances
+	if !IsNil(o.Uris) {
+		toSerialize["uris"] = o.Uris
+	}
 	return toSerialize, nil
 }
 
@@ -0,0 +1,76 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+func TestRefreshingAuth(t *testing.T) {
+	var headers []string
+	revoked := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		headers = append(headers, header)
+		if header == "Bearer "+revoked {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"nanosecond heartbeat": 1}`))
+	}))
+	defer server.Close()
+
+	var fetches atomic.Int32
+	provider, err := types.NewCallbackCredentialsProvider(func(ctx context.Context) (*types.Token, error) {
+		return &types.Token{Value: fmt.Sprintf("token-%d", fetches.Add(1))}, nil
+	})
+	require.NoError(t, err)
+	client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithAuth(provider), chroma.WithDefaultHeaders(map[string]string{"X-Custom": "1"}))
+	require.NoError(t, err)
+
+	_, err = client.Heartbeat(context.Background())
+	require.NoError(t, err)
+	_, err = client.Heartbeat(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"Bearer token-1", "Bearer token-1"}, headers, "non-expiring tokens are cached")
+
+	revoked = "token-1"
+	_, err = client.Heartbeat(context.Background())
+	require.Error(t, err)
+	_, err = client.Heartbeat(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "Bearer token-2", headers[3], "a rejected token is fetched again")
+
+	t.Run("Test fetch error fails the request", func(t *testing.T) {
+		failing, err := types.NewCallbackCredentialsProvider(func(ctx context.Context) (*types.Token, error) {
+			return nil, fmt.Errorf("no credentials")
+		})
+		require.NoError(t, err)
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithAuth(failing))
+		require.NoError(t, err)
+		_, err = client.Heartbeat(context.Background())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no credentials")
+	})
+
+	t.Run("Test user HTTP client is not modified", func(t *testing.T) {
+		httpClient := &http.Client{}
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithHTTPClient(httpClient), chroma.WithAuth(types.NewTokenAuthCredentialsProvider("static", types.AuthorizationTokenHeader)))
+		require.NoError(t, err)
+		_, err = client.Heartbeat(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "Bearer static", headers[len(headers)-1])
+		require.Nil(t, httpClient.Transport)
+	})
+}
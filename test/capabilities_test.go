@@ -0,0 +1,140 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/collection"
+	"github.com/szirtesitidom/chroma-go/types"
+	wheredoc "github.com/szirtesitidom/chroma-go/where_document"
+)
+
+func TestCapabilities(t *testing.T) {
+	newClient := func(t *testing.T, v1Version string, options ...chroma.ClientOption) (*fakeChromaServer, *chroma.Client) {
+		fake := &fakeChromaServer{v1Version: v1Version}
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+		client, err := chroma.NewClient(append([]chroma.ClientOption{chroma.WithBasePath(server.URL)}, options...)...)
+		require.NoError(t, err)
+		return fake, client
+	}
+	newCollection := func(t *testing.T, v1Version string) (*fakeChromaServer, *chroma.Collection) {
+		fake, client := newClient(t, v1Version)
+		col, err := client.NewCollection(context.Background(), "test", collection.WithEmbeddingFunction(types.NewConsistentHashEmbeddingFunction()))
+		require.NoError(t, err)
+		return fake, col
+	}
+
+	t.Run("Test capabilities of a v1 server", func(t *testing.T) {
+		_, client := newClient(t, "0.5.5")
+		caps, err := client.Capabilities(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "0.5.5", caps.ServerVersion.String())
+		require.Equal(t, chroma.ServerAPIV1, caps.ServerAPI)
+		require.Equal(t, 100, caps.MaxBatchSize)
+		for _, feature := range []chroma.Feature{chroma.FeatureMultiTenancy, chroma.FeatureWhereDocumentNotContains, chroma.FeatureIncludeURIs, chroma.FeatureMaxBatchSize, chroma.FeatureCollectionConfiguration} {
+			require.True(t, caps.Supports(feature), feature)
+		}
+		require.False(t, caps.Supports(chroma.FeatureV2API))
+	})
+
+	t.Run("Test capabilities of a v2 server", func(t *testing.T) {
+		_, client := newClient(t, "")
+		caps, err := client.Capabilities(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, chroma.ServerAPIV2, caps.ServerAPI)
+		require.True(t, caps.Supports(chroma.FeatureV2API))
+		require.True(t, caps.Supports(chroma.FeatureCollectionConfiguration))
+	})
+
+	t.Run("Test tenants require multi-tenancy", func(t *testing.T) {
+		fake, client := newClient(t, "0.4.14")
+		caps, err := client.Capabilities(context.Background())
+		require.NoError(t, err)
+		require.False(t, caps.Supports(chroma.FeatureMultiTenancy))
+		require.False(t, caps.Supports(chroma.FeatureMaxBatchSize))
+		_, err = client.CreateTenant(context.Background(), "my_tenant")
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+		var unsupported *chroma.UnsupportedFeatureError
+		require.True(t, errors.As(err, &unsupported))
+		require.Equal(t, chroma.FeatureMultiTenancy, unsupported.Feature)
+		require.Equal(t, "0.4.14", unsupported.ServerVersion)
+		require.Equal(t, ">=0.4.15", unsupported.Requires)
+		require.Equal(t, []string{"GET /api/v1/version"}, fake.paths())
+
+		_, client = newClient(t, "0.4.14", chroma.WithTenant("my_tenant"))
+		_, err = client.ListCollections(context.Background())
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+	})
+
+	t.Run("Test $not_contains requires a newer server", func(t *testing.T) {
+		fake, col := newCollection(t, "0.4.17")
+		requests := len(fake.paths())
+		_, err := col.QueryWithOptions(context.Background(), types.WithQueryTexts([]string{"hello"}),
+			types.WithWhereDocument(wheredoc.And(wheredoc.Contains("hello"), wheredoc.NotContains("world"))))
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+		require.Contains(t, err.Error(), "$not_contains")
+		_, err = col.Delete(context.Background(), nil, nil, map[string]interface{}{"$not_contains": "world"})
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+		require.Len(t, fake.paths(), requests, "no request is sent")
+
+		_, err = col.QueryWithOptions(context.Background(), types.WithQueryTexts([]string{"hello"}), types.WithWhereDocument(wheredoc.Contains("hello")))
+		require.NoError(t, err)
+	})
+
+	t.Run("Test uris require a newer server", func(t *testing.T) {
+		_, col := newCollection(t, "0.4.15")
+		_, err := col.GetWithOptions(context.Background(), types.WithInclude(types.IURIs))
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+		_, err = col.QueryWithOptions(context.Background(), types.WithQueryTexts([]string{"hello"}), types.WithInclude(types.IURIs))
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+		_, err = col.AddWithURIs(context.Background(), nil, nil, []string{"doc"}, []string{"file:///tmp/a.png"}, []string{"1"})
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+	})
+
+	t.Run("Test collection configuration requires a newer server", func(t *testing.T) {
+		_, client := newClient(t, "0.5.0")
+		_, err := client.NewCollection(context.Background(), "test", collection.WithConfiguration(map[string]interface{}{"hnsw": map[string]interface{}{"space": "cosine"}}))
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+	})
+
+	t.Run("Test writes larger than the max batch size", func(t *testing.T) {
+		fake, col := newCollection(t, "0.5.5")
+		requests := len(fake.paths())
+		ids := make([]string, 101)
+		embeddings := make([]*types.Embedding, len(ids))
+		for i := range ids {
+			ids[i] = strconv.Itoa(i)
+			embeddings[i] = types.NewEmbeddingFromFloat32([]float32{1, 2, 3})
+		}
+		_, err := col.Add(context.Background(), embeddings, nil, nil, ids)
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+		require.Contains(t, err.Error(), "max batch size 100")
+		_, err = col.Modify(context.Background(), embeddings, nil, nil, ids)
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+		require.Len(t, fake.paths(), requests)
+		_, err = col.Add(context.Background(), embeddings[:100], nil, nil, ids[:100])
+		require.NoError(t, err)
+	})
+
+	t.Run("Test identity requires a v2 server", func(t *testing.T) {
+		_, client := newClient(t, "0.5.5")
+		_, err := client.GetIdentity(context.Background())
+		require.ErrorIs(t, err, chroma.ErrUnsupportedByServer)
+	})
+
+	t.Run("Test collections without a client are not checked", func(t *testing.T) {
+		_, client := newClient(t, "0.4.14")
+		col := chroma.NewCollection(client.ApiClient, "c1", "test", nil, types.NewConsistentHashEmbeddingFunction(), types.DefaultTenant, types.DefaultDatabase)
+		_, err := col.QueryWithOptions(context.Background(), types.WithQueryTexts([]string{"hello"}), types.WithWhereDocument(wheredoc.NotContains("world")))
+		require.NoError(t, err)
+	})
+}
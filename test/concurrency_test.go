@@ -0,0 +1,155 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+)
+
+func countRequests(paths []string, request string) int {
+	n := 0
+	for _, p := range paths {
+		if strings.HasPrefix(p, request) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestConcurrentClient(t *testing.T) {
+	t.Run("Test pre-flight checks run once for concurrent operations", func(t *testing.T) {
+		fake := &fakeChromaServer{v1Version: "0.5.5"}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithMaxIdleConnsPerHost(20))
+		require.NoError(t, err)
+		defer client.Close()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 100)
+		for i := 0; i < 50; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := client.CountCollections(context.Background())
+				errs <- err
+			}()
+			go func(i int) {
+				defer wg.Done()
+				client.SetTenant(fmt.Sprintf("tenant-%d", i%3))
+				client.SetDatabase(fmt.Sprintf("database-%d", i%3))
+				_, err := client.ListCollections(context.Background())
+				errs <- err
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		paths := fake.paths()
+		require.Equal(t, 1, countRequests(paths, "GET /api/v1/version"))
+		require.Equal(t, 1, countRequests(paths, "GET /api/v1/pre-flight-checks"))
+		require.Equal(t, 50, countRequests(paths, "GET /api/v1/count_collections"))
+		require.Equal(t, 50, countRequests(paths, "GET /api/v1/collections"))
+	})
+
+	t.Run("Test failed pre-flight checks are retried", func(t *testing.T) {
+		fake := &fakeChromaServer{v1Version: "0.5.5", versionFailures: 1}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+		require.NoError(t, err)
+		defer client.Close()
+
+		_, err = client.CountCollections(context.Background())
+		require.Error(t, err)
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 2, countRequests(fake.paths(), "GET /api/v1/version"))
+		require.Equal(t, "0.5.5", client.APIVersion.String())
+	})
+}
+
+func TestClientClose(t *testing.T) {
+	var open atomic.Int32
+	server := httptest.NewUnstartedServer(&fakeChromaServer{v1Version: "0.5.5"})
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			open.Add(1)
+		case http.StateClosed, http.StateHijacked:
+			open.Add(-1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+	client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+	require.NoError(t, err)
+	_, err = client.CountCollections(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(1), open.Load())
+
+	require.NoError(t, client.Close())
+	require.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, 10*time.Millisecond, "idle connections are closed")
+	_, err = client.CountCollections(context.Background())
+	require.ErrorIs(t, err, chroma.ErrClientClosed)
+	_, err = client.Heartbeat(context.Background())
+	require.ErrorIs(t, err, chroma.ErrClientClosed)
+	require.NoError(t, client.Close())
+}
+
+func TestTransportOptions(t *testing.T) {
+	t.Run("Test transport options", func(t *testing.T) {
+		server := httptest.NewServer(&fakeChromaServer{v1Version: "0.5.5"})
+		defer server.Close()
+		client, err := chroma.NewClient(
+			chroma.WithBasePath(server.URL),
+			chroma.WithMaxIdleConns(100),
+			chroma.WithMaxIdleConnsPerHost(10),
+			chroma.WithMaxConnsPerHost(10),
+			chroma.WithIdleConnTimeout(time.Minute),
+			chroma.WithDialTimeout(time.Second),
+			chroma.WithTLSHandshakeTimeout(time.Second),
+			chroma.WithResponseHeaderTimeout(time.Second),
+			chroma.WithKeepAlive(30*time.Second),
+			chroma.WithHTTP2(false),
+			chroma.WithDisableKeepAlives(),
+		)
+		require.NoError(t, err)
+		defer client.Close()
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("Test invalid transport options", func(t *testing.T) {
+		for name, option := range map[string]chroma.ClientOption{
+			"max idle conns":          chroma.WithMaxIdleConns(-1),
+			"max idle conns per host": chroma.WithMaxIdleConnsPerHost(-1),
+			"max conns per host":      chroma.WithMaxConnsPerHost(-1),
+			"idle conn timeout":       chroma.WithIdleConnTimeout(-time.Second),
+			"dial timeout":            chroma.WithDialTimeout(0),
+			"TLS handshake timeout":   chroma.WithTLSHandshakeTimeout(0),
+			"response header timeout": chroma.WithResponseHeaderTimeout(0),
+			"keep-alive":              chroma.WithKeepAlive(0),
+		} {
+			_, err := chroma.NewClient(option)
+			require.Error(t, err, name)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+func TestCollectionDimensionGuard(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	var updateBody map[string]interface{}
+	var failUpdate bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/collections/col-id":
+			if failUpdate {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, err = w.Write([]byte(`{"error":"InternalError"}`))
+				break
+			}
+			updateBody = map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(body, &updateBody))
+			_, err = w.Write([]byte(`{}`))
+		case "/api/v1/collections/col-id/add", "/api/v1/collections/col-id/upsert":
+			_, err = w.Write([]byte(`true`))
+		case "/api/v1/collections/col-id/query":
+			_, err = w.Write([]byte(`{"ids":[[]],"distances":[[]]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+	require.NoError(t, err)
+	newCollection := func() *chroma.Collection {
+		metadata := map[string]interface{}{types.HNSWSpace: "cosine"}
+		return chroma.NewCollection(client.ApiClient, "col-id", "guarded", &metadata, types.NewConsistentHashEmbeddingFunction(), types.DefaultTenant, types.DefaultDatabase)
+	}
+
+	t.Run("Test first insert records the dimension", func(t *testing.T) {
+		calls = nil
+		col := newCollection().EnableDimensionGuard()
+		_, ok := col.Dimension()
+		require.False(t, ok)
+		_, err := col.Add(context.Background(), types.NewEmbeddingsFromFloat32([][]float32{{1, 2, 3}}), nil, nil, []string{"1"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"POST /api/v1/collections/col-id/add", "PUT /api/v1/collections/col-id"}, calls)
+		require.Equal(t, map[string]interface{}{types.HNSWSpace: "cosine", types.EmbeddingDimension: float64(3)}, updateBody["new_metadata"], "the server replaces the whole metadata")
+		dim, ok := col.Dimension()
+		require.True(t, ok)
+		require.Equal(t, 3, dim)
+		require.Equal(t, map[string]interface{}{types.HNSWSpace: "cosine", types.EmbeddingDimension: 3}, col.Metadata)
+
+		calls = nil
+		_, err = col.Upsert(context.Background(), types.NewEmbeddingsFromFloat32([][]float32{{1, 2, 3}}), nil, nil, []string{"2"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"POST /api/v1/collections/col-id/upsert"}, calls, "dimension is recorded only once")
+
+		_, err = col.Update(context.Background(), "guarded", &map[string]interface{}{"owner": "jane"})
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"owner": "jane", types.EmbeddingDimension: float64(3)}, updateBody["new_metadata"])
+		dim, ok = col.Dimension()
+		require.True(t, ok, "update keeps the recorded dimension")
+		require.Equal(t, 3, dim)
+	})
+
+	t.Run("Test mismatched embeddings are rejected before calling the API", func(t *testing.T) {
+		col := newCollection()
+		col.Metadata[types.EmbeddingDimension] = float64(3)
+		calls = nil
+		_, err := col.Add(context.Background(), types.NewEmbeddingsFromFloat32([][]float32{{1, 2}}), nil, nil, []string{"1"})
+		require.Error(t, err)
+		var dimErr *types.DimensionMismatchError
+		require.ErrorAs(t, err, &dimErr)
+		require.Equal(t, 3, dimErr.Expected)
+		require.Equal(t, 2, dimErr.Actual)
+		_, err = col.Upsert(context.Background(), types.NewEmbeddingsFromFloat32([][]float32{{1, 2, 3, 4}}), nil, nil, []string{"1"})
+		require.ErrorAs(t, err, &dimErr)
+		_, err = col.QueryWithOptions(context.Background(), types.WithQueryEmbedding(types.NewEmbeddingFromFloat32([]float32{1})), types.WithNResults(1))
+		require.ErrorAs(t, err, &dimErr)
+		_, err = col.QueryWithOptions(context.Background(), types.WithQueryText("generated embedding has 378 dimensions"), types.WithNResults(1))
+		require.ErrorAs(t, err, &dimErr)
+		require.Empty(t, calls)
+
+		_, err = col.QueryWithOptions(context.Background(), types.WithQueryEmbedding(types.NewEmbeddingFromFloat32([]float32{1, 2, 3})), types.WithNResults(1))
+		require.NoError(t, err)
+	})
+
+	t.Run("Test mixed dimensions in a batch are rejected", func(t *testing.T) {
+		col := newCollection()
+		calls = nil
+		_, err := col.Add(context.Background(), types.NewEmbeddingsFromFloat32([][]float32{{1, 2}, {1, 2, 3}}), nil, nil, []string{"1", "2"})
+		var dimErr *types.DimensionMismatchError
+		require.ErrorAs(t, err, &dimErr)
+		require.Empty(t, calls)
+	})
+
+	t.Run("Test failure to record the dimension does not fail the write", func(t *testing.T) {
+		col := newCollection().EnableDimensionGuard()
+		failUpdate = true
+		defer func() { failUpdate = false }()
+		_, err := col.Add(context.Background(), types.NewEmbeddingsFromFloat32([][]float32{{1, 2, 3}}), nil, nil, []string{"1"})
+		require.NoError(t, err)
+		_, ok := col.Dimension()
+		require.False(t, ok)
+	})
+
+	t.Run("Test concurrent inserts record the dimension once", func(t *testing.T) {
+		col := newCollection().EnableDimensionGuard()
+		mu.Lock()
+		calls = nil
+		mu.Unlock()
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := col.Add(context.Background(), types.NewEmbeddingsFromFloat32([][]float32{{1, 2, 3}}), nil, nil, []string{strconv.Itoa(i)})
+				require.NoError(t, err)
+				_, err = col.QueryWithOptions(context.Background(), types.WithQueryEmbedding(types.NewEmbeddingFromFloat32([]float32{1, 2, 3})), types.WithNResults(1))
+				require.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+		dim, ok := col.Dimension()
+		require.True(t, ok)
+		require.Equal(t, 3, dim)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, 1, countRequests(calls, "PUT /api/v1/collections/col-id"))
+	})
+
+	t.Run("Test without guard the dimension is not recorded", func(t *testing.T) {
+		col := newCollection()
+		calls = nil
+		_, err := col.Add(context.Background(), types.NewEmbeddingsFromFloat32([][]float32{{1, 2, 3}}), nil, nil, []string{"1"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"POST /api/v1/collections/col-id/add"}, calls)
+		_, ok := col.Dimension()
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,138 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/collection"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+func newFakeEndpoints(n int) ([]*fakeChromaServer, []*httptest.Server, []string) {
+	fakes := make([]*fakeChromaServer, n)
+	servers := make([]*httptest.Server, n)
+	urls := make([]string, n)
+	for i := range fakes {
+		fakes[i] = &fakeChromaServer{v1Version: "0.5.5"}
+		servers[i] = httptest.NewServer(fakes[i])
+		urls[i] = servers[i].URL
+	}
+	return fakes, servers, urls
+}
+
+func TestEndpoints(t *testing.T) {
+	embeddings := []*types.Embedding{types.NewEmbeddingFromFloat32([]float32{1, 2, 3})}
+
+	newCollection := func(t *testing.T, options ...chroma.ClientOption) (*chroma.Client, *chroma.Collection) {
+		client, err := chroma.NewClient(options...)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = client.Close() })
+		col, err := client.NewCollection(context.Background(), "test", collection.WithEmbeddingFunction(types.NewConsistentHashEmbeddingFunction()))
+		require.NoError(t, err)
+		return client, col
+	}
+
+	t.Run("Test reads round-robin and writes to the primary", func(t *testing.T) {
+		fakes, servers, urls := newFakeEndpoints(3)
+		for _, server := range servers {
+			defer server.Close()
+		}
+		_, col := newCollection(t, chroma.WithEndpoints(urls...))
+		for i := 0; i < 6; i++ {
+			_, err := col.Count(context.Background())
+			require.NoError(t, err)
+			_, err = col.Add(context.Background(), embeddings, nil, nil, []string{"1"})
+			require.NoError(t, err)
+		}
+		for i, fake := range fakes {
+			require.Equal(t, 2, countRequests(fake.paths(), "GET /api/v1/collections/c1/count"), "endpoint %d", i)
+		}
+		require.Equal(t, 6, countRequests(fakes[0].paths(), "POST /api/v1/collections/c1/add"))
+	})
+
+	t.Run("Test failover on connection errors", func(t *testing.T) {
+		fakes, servers, urls := newFakeEndpoints(3)
+		for _, server := range servers[1:] {
+			defer server.Close()
+		}
+		client, col := newCollection(t, chroma.WithEndpoints(urls...), chroma.WithFailoverCooldown(time.Minute))
+		servers[0].Close()
+		for i := 0; i < 4; i++ {
+			_, err := col.Count(context.Background())
+			require.NoError(t, err)
+		}
+		require.Equal(t, 4, countRequests(fakes[1].paths(), "GET /api/v1/collections/c1/count")+countRequests(fakes[2].paths(), "GET /api/v1/collections/c1/count"))
+
+		health := client.EndpointHealth()
+		require.Len(t, health, 3)
+		require.Equal(t, urls[0], health[0].BasePath)
+		require.True(t, health[0].Primary)
+		require.False(t, health[0].Healthy)
+		require.Error(t, health[0].LastError)
+		require.WithinDuration(t, time.Now().Add(time.Minute), health[0].RetryAt, 5*time.Second)
+		require.True(t, health[1].Healthy)
+		require.False(t, health[1].Primary)
+		require.True(t, health[1].RetryAt.IsZero())
+	})
+
+	t.Run("Test writes stay on the primary", func(t *testing.T) {
+		fakes, servers, urls := newFakeEndpoints(3)
+		for _, server := range servers[1:] {
+			defer server.Close()
+		}
+		_, col := newCollection(t, chroma.WithEndpoints(urls...), chroma.WithFailoverCooldown(time.Minute))
+		servers[0].Close()
+		for i := 0; i < 2; i++ {
+			_, err := col.Add(context.Background(), embeddings, nil, nil, []string{"1"})
+			require.Error(t, err, "the connection error of the primary is returned")
+			_, err = col.Delete(context.Background(), []string{"1"}, nil, nil)
+			require.Error(t, err)
+		}
+		for _, fake := range fakes[1:] {
+			require.Zero(t, countRequests(fake.paths(), "POST /api/v1/collections/c1/add"))
+			require.Zero(t, countRequests(fake.paths(), "POST /api/v1/collections/c1/delete"))
+		}
+	})
+
+	t.Run("Test heartbeats", func(t *testing.T) {
+		_, servers, urls := newFakeEndpoints(2)
+		defer servers[0].Close()
+		client, err := chroma.NewClient(chroma.WithEndpoints(urls...), chroma.WithHealthCheckInterval(20*time.Millisecond), chroma.WithFailoverCooldown(0))
+		require.NoError(t, err)
+		defer client.Close()
+		require.Eventually(t, func() bool {
+			health := client.EndpointHealth()
+			return !health[0].LastHeartbeat.IsZero() && !health[1].LastHeartbeat.IsZero() && health[1].LastError == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		servers[1].Close()
+		require.Eventually(t, func() bool {
+			return client.EndpointHealth()[1].LastError != nil
+		}, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, client.EndpointHealth()[0].LastError)
+	})
+
+	t.Run("Test single base path", func(t *testing.T) {
+		client, err := chroma.NewClient(chroma.WithBasePath("http://localhost:8000"))
+		require.NoError(t, err)
+		require.Nil(t, client.EndpointHealth())
+	})
+
+	t.Run("Test invalid options", func(t *testing.T) {
+		for _, option := range []chroma.ClientOption{
+			chroma.WithEndpoints(),
+			chroma.WithEndpoints("http://localhost:8000", "not a url"),
+			chroma.WithHealthCheckInterval(0),
+			chroma.WithFailoverCooldown(-time.Second),
+		} {
+			_, err := chroma.NewClient(option)
+			require.Error(t, err)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/pkg/bm25"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+func TestHybridSearch(t *testing.T) {
+	var queryBody, getBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/collections/col-id/query":
+			queryBody = map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(body, &queryBody))
+			_, err = w.Write([]byte(`{"ids":[["v1","v2","v3"]],"documents":[["semantic match about animals","pets and their owners","a lazy fox sleeps"]],"metadatas":[[{"n":1},{"n":2},{"n":3}]],"distances":[[0.1,0.2,0.9]]}`))
+		case "/api/v1/collections/col-id/get":
+			getBody = map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(body, &getBody))
+			_, err = w.Write([]byte(`{"ids":["v1","k1"],"documents":["semantic match about animals","the lazy fox and the lazy dog"],"metadatas":[{"n":1},{"n":4}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+	require.NoError(t, err)
+	col := chroma.NewCollection(client.ApiClient, "col-id", "docs", nil, types.NewConsistentHashEmbeddingFunction(), types.DefaultTenant, types.DefaultDatabase)
+
+	t.Run("Test alpha fusion", func(t *testing.T) {
+		results, err := col.HybridSearch(context.Background(), []string{"lazy fox"}, 2, chroma.WithHybridAlpha(0.3), chroma.WithHybridFetchK(3))
+		require.NoError(t, err)
+		require.Equal(t, float64(3), queryBody["n_results"])
+		require.Equal(t, [][]string{{"v3", "v1"}}, results.Ids)
+		require.Equal(t, []string{"lazy fox"}, results.QueryTexts)
+		require.Len(t, results.Scores[0], 2)
+		require.InDelta(t, 0.7, results.Scores[0][0], 1e-6)
+		require.InDelta(t, 0.3, results.Scores[0][1], 1e-6)
+		require.InDelta(t, 0.9, results.Distances[0][0], 1e-6)
+		rows := results.Rows(0)
+		require.NotNil(t, rows[0].Score)
+		require.Equal(t, "a lazy fox sleeps", rows[0].Document)
+	})
+
+	t.Run("Test pure vector alpha keeps vector order", func(t *testing.T) {
+		results, err := col.HybridSearch(context.Background(), []string{"lazy fox"}, 3, chroma.WithHybridAlpha(1))
+		require.NoError(t, err)
+		require.Equal(t, [][]string{{"v1", "v2", "v3"}}, results.Ids)
+	})
+
+	t.Run("Test rrf fusion", func(t *testing.T) {
+		results, err := col.HybridSearch(context.Background(), []string{"lazy fox"}, 3, chroma.WithHybridRRF(60))
+		require.NoError(t, err)
+		require.Equal(t, "v3", results.Ids[0][0], "document ranked by both lists wins")
+		require.InDelta(t, 1.0/63+1.0/61, results.Scores[0][0], 1e-6)
+	})
+
+	t.Run("Test candidate pool adds keyword matches", func(t *testing.T) {
+		results, err := col.HybridSearch(context.Background(), []string{"lazy fox"}, 2,
+			chroma.WithHybridAlpha(0.2),
+			chroma.WithHybridCandidatePool(100),
+			chroma.WithHybridQueryOptions(types.WithWhereMap(map[string]interface{}{"n": map[string]interface{}{"$gt": 0}})),
+		)
+		require.NoError(t, err)
+		require.Equal(t, float64(100), getBody["limit"])
+		require.Equal(t, map[string]interface{}{"n": map[string]interface{}{"$gt": float64(0)}}, getBody["where"])
+		require.Contains(t, results.Ids[0], "k1")
+		rows := results.Rows(0)
+		for i, row := range rows {
+			if row.ID == "k1" {
+				require.Nil(t, row.Distance)
+				require.Nil(t, results.VectorDistances[0][i])
+			} else {
+				require.NotNil(t, row.Distance)
+			}
+		}
+		require.Nil(t, results.Distances[0], "keyword matches have no distance")
+		_, err = json.Marshal(results)
+		require.NoError(t, err)
+	})
+
+	t.Run("Test sparse embedding function scores candidates", func(t *testing.T) {
+		encoder, err := bm25.NewSparseEncoder()
+		require.NoError(t, err)
+		// corpus statistics of the whole collection rather than the candidates
+		encoder.Fit([]string{"semantic match about animals", "pets and their owners", "a lazy fox sleeps", "the lazy fox and the lazy dog", "owners"})
+		results, err := col.HybridSearch(context.Background(), []string{"pets owners"}, 1, chroma.WithHybridAlpha(0), chroma.WithHybridSparseEmbeddingFunction(encoder))
+		require.NoError(t, err)
+		require.Equal(t, [][]string{{"v2"}}, results.Ids)
+		require.InDelta(t, 1, results.Scores[0][0], 1e-6)
+		_, err = col.HybridSearch(context.Background(), []string{"q"}, 2, chroma.WithHybridSparseEmbeddingFunction(nil))
+		require.Error(t, err)
+	})
+
+	t.Run("Test invalid options", func(t *testing.T) {
+		_, err := col.HybridSearch(context.Background(), nil, 2)
+		require.Error(t, err)
+		_, err = col.HybridSearch(context.Background(), []string{"q"}, 2, chroma.WithHybridAlpha(1.5))
+		require.Error(t, err)
+		_, err = col.HybridSearch(context.Background(), []string{"q"}, 2, chroma.WithHybridQueryOptions(types.WithQueryText("other")))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,219 @@
+//go:build basic
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/collection"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// newHeaderRecordingServer returns a fake server that records the given header of each request by path.
+func newHeaderRecordingServer(header string) (*httptest.Server, func(path string) string) {
+	var mu sync.Mutex
+	headers := map[string]string{}
+	fake := &fakeChromaServer{v1Version: "0.5.5"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers[r.URL.Path] = r.Header.Get(header)
+		mu.Unlock()
+		fake.ServeHTTP(w, r)
+	}))
+	return server, func(path string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return headers[path]
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("Test middleware chain and operations", func(t *testing.T) {
+		server, header := newHeaderRecordingServer("X-Tag")
+		defer server.Close()
+		var mu sync.Mutex
+		var calls []string
+		var operations []chroma.Operation
+		record := func(name string) chroma.Middleware {
+			return func(next chroma.Handler) chroma.Handler {
+				return func(req *http.Request) (*http.Response, error) {
+					mu.Lock()
+					calls = append(calls, name+" "+req.URL.Path)
+					if name == "outer" {
+						operation, ok := chroma.OperationFromContext(req.Context())
+						require.True(t, ok)
+						operations = append(operations, operation)
+					}
+					mu.Unlock()
+					req.Header.Set("X-Tag", req.Header.Get("X-Tag")+name)
+					return next(req)
+				}
+			}
+		}
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithMiddleware(record("outer"), record("inner")))
+		require.NoError(t, err)
+		col, err := client.NewCollection(context.Background(), "test", collection.WithEmbeddingFunction(types.NewConsistentHashEmbeddingFunction()))
+		require.NoError(t, err)
+		_, err = col.Add(context.Background(), nil, nil, []string{"doc 1", "doc 2"}, []string{"1", "2"})
+		require.NoError(t, err)
+
+		require.Equal(t, "outerinner", header("/api/v1/collections/c1/add"))
+		require.Equal(t, []string{"outer /api/v1/collections/c1/add", "inner /api/v1/collections/c1/add"}, calls[len(calls)-2:])
+		require.Equal(t, chroma.OperationPreFlightChecks, operations[0].Type)
+		require.Equal(t, chroma.Operation{Type: chroma.OperationCreateCollection, Collection: "test", Tenant: types.DefaultTenant, Database: types.DefaultDatabase}, operations[len(operations)-2])
+		require.Equal(t, chroma.Operation{Type: chroma.OperationAdd, Collection: "test", CollectionID: "c1", Tenant: types.DefaultTenant, Database: types.DefaultDatabase, Records: 2}, operations[len(operations)-1])
+	})
+
+	t.Run("Test nil middleware", func(t *testing.T) {
+		_, err := chroma.NewClient(chroma.WithMiddleware(nil))
+		require.Error(t, err)
+	})
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	server, header := newHeaderRecordingServer("X-Correlation-ID")
+	defer server.Close()
+	client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithMiddleware(chroma.RequestIDMiddleware("X-Correlation-ID")))
+	require.NoError(t, err)
+
+	_, err = client.CountCollections(chroma.ContextWithRequestID(context.Background(), "req-1"))
+	require.NoError(t, err)
+	require.Equal(t, "req-1", header("/api/v1/count_collections"))
+	_, err = client.CountCollections(context.Background())
+	require.NoError(t, err)
+	generated := header("/api/v1/count_collections")
+	require.NotEmpty(t, generated)
+	require.NotEqual(t, "req-1", generated)
+}
+
+// logRecords decodes the JSON log lines.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	server := httptest.NewServer(&fakeChromaServer{v1Version: "0.5.5"})
+	defer server.Close()
+
+	t.Run("Test structured logs with redacted documents", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		client, err := chroma.NewClient(
+			chroma.WithBasePath(server.URL),
+			chroma.WithAuth(types.NewTokenAuthCredentialsProvider("secret-token", types.AuthorizationTokenHeader)),
+			chroma.WithMiddleware(chroma.RequestIDMiddleware(""), chroma.LoggingMiddleware(logger, chroma.WithLogBodies())),
+		)
+		require.NoError(t, err)
+		col, err := client.NewCollection(context.Background(), "test", collection.WithEmbeddingFunction(types.NewConsistentHashEmbeddingFunction()))
+		require.NoError(t, err)
+		_, err = col.Add(chroma.ContextWithRequestID(context.Background(), "req-1"), nil, []map[string]interface{}{{"source": "a"}}, []string{"my private document"}, []string{"1"})
+		require.NoError(t, err)
+		_, err = col.Query(context.Background(), []string{"query"}, 1, nil, map[string]interface{}{"$contains": "private"}, nil)
+		require.NoError(t, err)
+
+		require.NotContains(t, buf.String(), "my private document")
+		require.NotContains(t, buf.String(), "private")
+		require.NotContains(t, buf.String(), "secret-token")
+		records := logRecords(t, &buf)
+		var add, query map[string]interface{}
+		for _, record := range records {
+			switch record["path"] {
+			case "/api/v1/collections/c1/add":
+				add = record
+			case "/api/v1/collections/c1/query":
+				query = record
+			}
+		}
+		require.NotNil(t, add)
+		require.Equal(t, "DEBUG", add["level"])
+		require.Equal(t, "POST", add["method"])
+		require.Equal(t, float64(201), add["status"])
+		require.Equal(t, "req-1", add["request_id"])
+		require.Equal(t, map[string]interface{}{
+			"type": "add", "collection": "test", "collection_id": "c1", "tenant": types.DefaultTenant, "database": types.DefaultDatabase, "records": float64(1),
+		}, add["operation"])
+		require.Contains(t, add["request_body"], `"documents":"[REDACTED]"`)
+		require.Contains(t, add["request_body"], `"source":"a"`)
+		require.Contains(t, query["request_body"], `"where_document":"[REDACTED]"`)
+		require.Contains(t, query["response_body"], `"documents":"[REDACTED]"`)
+		require.NotEmpty(t, query["request_id"])
+	})
+
+	t.Run("Test failed requests are logged as warnings", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithMiddleware(chroma.LoggingMiddleware(logger)))
+		require.NoError(t, err)
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, buf.String(), "successful requests are logged at debug level")
+		_, err = client.GetCollection(context.Background(), "missing", nil)
+		require.Error(t, err)
+		records := logRecords(t, &buf)
+		require.Len(t, records, 1)
+		require.Equal(t, "WARN", records[0]["level"])
+		require.Equal(t, float64(404), records[0]["status"])
+		require.Nil(t, records[0]["request_body"])
+	})
+
+	t.Run("Test debug option logs with the default logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		defaultLogger := slog.Default()
+		slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+		defer slog.SetDefault(defaultLogger)
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithDebug(true))
+		require.NoError(t, err)
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		records := logRecords(t, &buf)
+		require.Equal(t, "/api/v1/count_collections", records[len(records)-1]["path"])
+		require.Equal(t, "1", records[len(records)-1]["response_body"])
+	})
+
+	t.Run("Test client logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithLogger(logger), chroma.WithDebug(true))
+		require.NoError(t, err)
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, client.Close())
+		var messages []interface{}
+		for _, record := range logRecords(t, &buf) {
+			messages = append(messages, record["msg"])
+		}
+		require.Contains(t, messages, "chroma request", "debug requests are logged with the client logger")
+		require.Contains(t, messages, "pre-flight checks completed")
+		require.Equal(t, "client closed", messages[len(messages)-1])
+	})
+
+	t.Run("Test client logs nothing by default", func(t *testing.T) {
+		var buf bytes.Buffer
+		defaultLogger := slog.Default()
+		slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+		defer slog.SetDefault(defaultLogger)
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+		require.NoError(t, err)
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, client.Close())
+		require.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,70 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+func TestQueryWithMMR(t *testing.T) {
+	var lastBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		lastBody = map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(body, &lastBody))
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write([]byte(`{
+			"ids":[["c1","c2","c3","c4"]],
+			"documents":[["one","one again","three","four"]],
+			"metadatas":[[{"n":1},{"n":2},{"n":3},{"n":4}]],
+			"distances":[[0.0,0.00005,0.29,1.0]],
+			"embeddings":[[[1,0],[0.99,0.01],[0.7,0.7],[0,1]]]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+	require.NoError(t, err)
+	metadata := map[string]interface{}{types.HNSWSpace: "cosine"}
+	col := chroma.NewCollection(client.ApiClient, "col-id", "docs", &metadata, types.NewConsistentHashEmbeddingFunction(), types.DefaultTenant, types.DefaultDatabase)
+	query := types.NewEmbeddingFromFloat32([]float32{1, 0})
+
+	t.Run("Test diverse results", func(t *testing.T) {
+		results, err := col.QueryWithOptions(context.Background(), types.WithQueryEmbedding(query), types.WithNResults(2), types.WithMMR(4, 0.3))
+		require.NoError(t, err)
+		require.Equal(t, float64(4), lastBody["n_results"])
+		require.Contains(t, lastBody["include"], "embeddings")
+		require.Equal(t, [][]string{{"c1", "c4"}}, results.Ids)
+		require.Equal(t, [][]string{{"one", "four"}}, results.Documents)
+		require.Equal(t, [][]float32{{0, 1}}, results.Distances)
+		require.Equal(t, float64(4), results.Metadatas[0][1]["n"])
+		require.Nil(t, results.Embeddings, "embeddings were not requested")
+	})
+
+	t.Run("Test lambda 1 keeps relevance order", func(t *testing.T) {
+		results, err := col.QueryWithOptions(context.Background(), types.WithQueryEmbedding(query), types.WithNResults(2), types.WithMMR(4, 1),
+			types.WithInclude(types.IDocuments, types.IEmbeddings))
+		require.NoError(t, err)
+		require.Equal(t, [][]string{{"c1", "c2"}}, results.Ids)
+		require.Len(t, results.Embeddings[0], 2)
+	})
+
+	t.Run("Test invalid options", func(t *testing.T) {
+		_, err := col.QueryWithOptions(context.Background(), types.WithQueryEmbedding(query), types.WithNResults(2), types.WithMMR(0, 0.5))
+		require.Error(t, err)
+		_, err = col.QueryWithOptions(context.Background(), types.WithQueryEmbedding(query), types.WithNResults(2), types.WithMMR(4, 1.5))
+		require.Error(t, err)
+		_, err = col.QueryWithOptions(context.Background(), types.WithQueryEmbedding(query), types.WithMMR(4, 0.5))
+		require.Error(t, err)
+	})
+}
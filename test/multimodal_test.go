@@ -0,0 +1,136 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/pkg/dataloader"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// imageEF embeds texts as [len, 0] and images as [0, len].
+type imageEF struct{}
+
+func (imageEF) EmbedDocuments(_ context.Context, documents []string) ([]*types.Embedding, error) {
+	embeddings := make([]*types.Embedding, len(documents))
+	for i, d := range documents {
+		embeddings[i] = types.NewEmbeddingFromFloat32([]float32{float32(len(d)), 0})
+	}
+	return embeddings, nil
+}
+
+func (e imageEF) EmbedQuery(ctx context.Context, document string) (*types.Embedding, error) {
+	embeddings, err := e.EmbedDocuments(ctx, []string{document})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e imageEF) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
+}
+
+func (imageEF) EmbedImages(_ context.Context, images [][]byte) ([]*types.Embedding, error) {
+	embeddings := make([]*types.Embedding, len(images))
+	for i, image := range images {
+		embeddings[i] = types.NewEmbeddingFromFloat32([]float32{0, float32(len(image))})
+	}
+	return embeddings, nil
+}
+
+func TestMultimodal(t *testing.T) {
+	var lastBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		lastBody = map[string]interface{}{}
+		if len(body) > 0 {
+			require.NoError(t, json.Unmarshal(body, &lastBody))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/collections/col-id/add", "/api/v1/collections/col-id/upsert":
+			_, err = w.Write([]byte(`true`))
+		case "/api/v1/collections/col-id/get":
+			_, err = w.Write([]byte(`{"ids":["i1","i2"],"documents":[null,"a caption"],"metadatas":[null,null],"uris":["data:,abc","file:///tmp/b.png"]}`))
+		case "/api/v1/collections/col-id/query":
+			_, err = w.Write([]byte(`{"ids":[["i2","i1"]],"distances":[[0.1,0.2]],"uris":[["file:///tmp/b.png","data:,abc"]]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+	require.NoError(t, err)
+	loader, err := dataloader.NewFileDataLoader()
+	require.NoError(t, err)
+	col := chroma.NewCollection(client.ApiClient, "col-id", "images", nil, imageEF{}, types.DefaultTenant, types.DefaultDatabase)
+	col.DataLoader = loader
+
+	t.Run("Test AddWithURIs embeds uri-only records from loaded data", func(t *testing.T) {
+		_, err := col.AddWithURIs(context.Background(), nil, nil, []string{"", "a caption"}, []string{"data:,abc", "data:,xy"}, []string{"i1", "i2"})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"data:,abc", "data:,xy"}, lastBody["uris"])
+		require.Equal(t, []interface{}{[]interface{}{float64(0), float64(3)}, []interface{}{float64(9), float64(0)}}, lastBody["embeddings"])
+	})
+
+	t.Run("Test Add does not send uris", func(t *testing.T) {
+		_, err := col.Add(context.Background(), nil, nil, []string{"doc"}, []string{"i1"})
+		require.NoError(t, err)
+		require.NotContains(t, lastBody, "uris")
+	})
+
+	t.Run("Test UpsertRecords sends uris", func(t *testing.T) {
+		rs, err := types.NewRecordSet(types.WithEmbeddingFunction(imageEF{}), types.WithDataLoader(loader))
+		require.NoError(t, err)
+		rs.WithRecord(types.WithID("i1"), types.WithURI("data:,abcd"))
+		_, err = rs.BuildAndValidate(context.Background())
+		require.NoError(t, err)
+		_, err = col.UpsertRecords(context.Background(), rs)
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"data:,abcd"}, lastBody["uris"])
+		require.Equal(t, []interface{}{[]interface{}{float64(0), float64(4)}}, lastBody["embeddings"])
+	})
+
+	t.Run("Test uri-only records require a data loader", func(t *testing.T) {
+		noLoader := chroma.NewCollection(client.ApiClient, "col-id", "images", nil, imageEF{}, types.DefaultTenant, types.DefaultDatabase)
+		_, err := noLoader.AddWithURIs(context.Background(), nil, nil, nil, []string{"data:,abc"}, []string{"i1"})
+		require.Error(t, err)
+		textOnly := chroma.NewCollection(client.ApiClient, "col-id", "images", nil, types.NewConsistentHashEmbeddingFunction(), types.DefaultTenant, types.DefaultDatabase)
+		textOnly.DataLoader = loader
+		_, err = textOnly.AddWithURIs(context.Background(), nil, nil, nil, []string{"data:,abc"}, []string{"i1"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not support images")
+	})
+
+	t.Run("Test Get with include uris", func(t *testing.T) {
+		res, err := col.GetWithOptions(context.Background(), types.WithInclude(types.IURIs, types.IDocuments))
+		require.NoError(t, err)
+		require.ElementsMatch(t, []interface{}{"uris", "documents"}, lastBody["include"])
+		require.Equal(t, []string{"data:,abc", "file:///tmp/b.png"}, res.Uris)
+		rows := res.Rows()
+		require.Equal(t, "data:,abc", rows[0].URI)
+		require.Equal(t, "data:,abc", rows[0].ToRecord().URI)
+	})
+
+	t.Run("Test Query by uri", func(t *testing.T) {
+		res, err := col.QueryWithOptions(context.Background(), types.WithQueryURIs("data:,abcde"), types.WithNResults(2), types.WithInclude(types.IURIs, types.IDistances))
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{[]interface{}{float64(0), float64(5)}}, lastBody["query_embeddings"])
+		require.Equal(t, [][]string{{"file:///tmp/b.png", "data:,abc"}}, res.Uris)
+		queries := res.Queries()
+		require.Equal(t, "data:,abcde", queries[0].QueryURI)
+		require.Equal(t, "file:///tmp/b.png", queries[0].Rows[0].URI)
+	})
+}
@@ -0,0 +1,233 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/collection"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// cacheServer counts the data requests of a collection. Queries wait for release if it is set.
+type cacheServer struct {
+	mu      sync.Mutex
+	counts  map[string]int
+	release chan struct{}
+}
+
+func (s *cacheServer) count(operation string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[operation]
+}
+
+func (s *cacheServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	operation := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	s.mu.Lock()
+	s.counts[operation]++
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/api/v1/version":
+		_, _ = w.Write([]byte(`"0.5.5"`))
+	case r.URL.Path == "/api/v1/pre-flight-checks":
+		_, _ = w.Write([]byte(`{"max_batch_size":100}`))
+	case strings.HasPrefix(r.URL.Path, "/api/v1/tenants/"):
+		_, _ = w.Write([]byte(`{"name":"default_tenant"}`))
+	case strings.HasPrefix(r.URL.Path, "/api/v1/databases/"):
+		_, _ = w.Write([]byte(`{"id":"00000000-0000-0000-0000-000000000000","name":"default_database","tenant":"default_tenant"}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/collections":
+		_, _ = w.Write([]byte(`{"id":"c1","name":"test","tenant":"default_tenant","database":"default_database"}`))
+	case operation == "query":
+		if s.release != nil {
+			<-s.release
+		}
+		_, _ = w.Write([]byte(`{"ids":[["1"]],"distances":[[0.1]],"documents":[["doc"]],"metadatas":[[{"n":1}]]}`))
+	case operation == "get":
+		_, _ = w.Write([]byte(`{"ids":["1"],"documents":["doc"],"metadatas":[{"n":1}]}`))
+	case operation == "add" || operation == "upsert":
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`true`))
+	case operation == "update":
+		_, _ = w.Write([]byte(`true`))
+	case operation == "delete":
+		_, _ = w.Write([]byte(`["1"]`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestResultCache(t *testing.T) {
+	embeddings := []*types.Embedding{types.NewEmbeddingFromFloat32([]float32{1, 2, 3})}
+
+	newCollection := func(t *testing.T, options ...chroma.ClientOption) (*cacheServer, *chroma.Collection) {
+		fake := &cacheServer{counts: map[string]int{}}
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+		client, err := chroma.NewClient(append([]chroma.ClientOption{chroma.WithBasePath(server.URL)}, options...)...)
+		require.NoError(t, err)
+		col, err := client.NewCollection(context.Background(), "test", collection.WithEmbeddingFunction(types.NewConsistentHashEmbeddingFunction()))
+		require.NoError(t, err)
+		return fake, col
+	}
+
+	query := func(col *chroma.Collection, options ...types.CollectionQueryOption) (*chroma.QueryResults, error) {
+		return col.QueryWithOptions(context.Background(), append([]types.CollectionQueryOption{types.WithQueryTexts([]string{"hello"}), types.WithNResults(1)}, options...)...)
+	}
+
+	t.Run("Test repeated queries and gets are served from the cache", func(t *testing.T) {
+		fake, col := newCollection(t, chroma.WithResultCache(time.Minute, 10))
+		for i := 0; i < 3; i++ {
+			results, err := query(col)
+			require.NoError(t, err)
+			require.Equal(t, [][]string{{"1"}}, results.Ids)
+			require.Equal(t, []string{"hello"}, results.QueryTexts)
+			results.Ids[0][0] = "modified"
+			got, err := col.GetWithOptions(context.Background(), types.WithIds([]string{"1"}))
+			require.NoError(t, err)
+			require.Equal(t, []string{"doc"}, got.Documents)
+		}
+		require.Equal(t, 1, fake.count("query"))
+		require.Equal(t, 1, fake.count("get"))
+
+		_, err := query(col, types.WithInclude(types.IMetadatas, types.IDocuments, types.IDistances))
+		require.NoError(t, err)
+		require.Equal(t, 1, fake.count("query"), "include order does not matter")
+		_, err = query(col, types.WithNResults(2))
+		require.NoError(t, err)
+		_, err = query(col, types.WithWhereMap(map[string]interface{}{"n": 1}))
+		require.NoError(t, err)
+		require.Equal(t, 3, fake.count("query"))
+	})
+
+	t.Run("Test writes invalidate the cache", func(t *testing.T) {
+		fake, col := newCollection(t, chroma.WithResultCache(time.Minute, 10))
+		writes := []func() error{
+			func() error {
+				_, err := col.Add(context.Background(), embeddings, nil, nil, []string{"1"})
+				return err
+			},
+			func() error {
+				_, err := col.Upsert(context.Background(), embeddings, nil, nil, []string{"1"})
+				return err
+			},
+			func() error {
+				_, err := col.Modify(context.Background(), embeddings, nil, nil, []string{"1"})
+				return err
+			},
+			func() error {
+				_, err := col.Delete(context.Background(), []string{"1"}, nil, nil)
+				return err
+			},
+			func() error {
+				col.ClearResultCache()
+				return nil
+			},
+		}
+		for i, write := range writes {
+			_, err := query(col)
+			require.NoError(t, err)
+			_, err = query(col)
+			require.NoError(t, err)
+			require.Equal(t, i+1, fake.count("query"))
+			require.NoError(t, write())
+		}
+	})
+
+	t.Run("Test entries expire", func(t *testing.T) {
+		fake, col := newCollection(t)
+		require.NoError(t, col.EnableResultCache(50*time.Millisecond, 10))
+		_, err := query(col)
+		require.NoError(t, err)
+		_, err = query(col)
+		require.NoError(t, err)
+		require.Equal(t, 1, fake.count("query"))
+		time.Sleep(100 * time.Millisecond)
+		_, err = query(col)
+		require.NoError(t, err)
+		require.Equal(t, 2, fake.count("query"))
+	})
+
+	t.Run("Test least recently used entries are evicted", func(t *testing.T) {
+		fake, col := newCollection(t, chroma.WithResultCache(time.Minute, 2))
+		for _, n := range []int32{1, 2, 1, 3, 1, 2} {
+			_, err := query(col, types.WithNResults(n))
+			require.NoError(t, err)
+		}
+		require.Equal(t, 4, fake.count("query"))
+	})
+
+	t.Run("Test concurrent identical queries are sent once", func(t *testing.T) {
+		fake, col := newCollection(t, chroma.WithResultCache(time.Minute, 10))
+		fake.release = make(chan struct{})
+		var wg sync.WaitGroup
+		errs := make([]error, 5)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = query(col)
+			}(i)
+		}
+		require.Eventually(t, func() bool { return fake.count("query") == 1 }, 5*time.Second, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		close(fake.release)
+		wg.Wait()
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+		require.Equal(t, 1, fake.count("query"))
+	})
+
+	t.Run("Test canceled caller does not cancel the shared request", func(t *testing.T) {
+		fake, col := newCollection(t, chroma.WithResultCache(time.Minute, 10))
+		fake.release = make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			_, err := col.QueryWithOptions(ctx, types.WithQueryTexts([]string{"hello"}), types.WithNResults(1))
+			done <- err
+		}()
+		require.Eventually(t, func() bool { return fake.count("query") == 1 }, 5*time.Second, 10*time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+		close(fake.release)
+		require.Eventually(t, func() bool {
+			_, err := query(col)
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, 1, fake.count("query"))
+	})
+
+	t.Run("Test no cache by default", func(t *testing.T) {
+		fake, col := newCollection(t)
+		for i := 0; i < 2; i++ {
+			_, err := query(col)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, fake.count("query"))
+	})
+
+	t.Run("Test invalid options", func(t *testing.T) {
+		for _, option := range []chroma.ClientOption{
+			chroma.WithResultCache(0, 10),
+			chroma.WithResultCache(time.Minute, 0),
+		} {
+			_, err := chroma.NewClient(option)
+			require.Error(t, err)
+		}
+		col := &chroma.Collection{}
+		require.Error(t, col.EnableResultCache(-time.Second, 1))
+		col.ClearResultCache()
+	})
+}
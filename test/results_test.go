@@ -0,0 +1,146 @@
+//go:build basic
+
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+func TestQueryResultsRows(t *testing.T) {
+	results := &chroma.QueryResults{
+		Ids:       [][]string{{"id1", "id2"}, {"id3"}},
+		Documents: [][]string{{"doc1", "doc2"}, {"doc3"}},
+		Metadatas: [][]map[string]interface{}{
+			{{"name": "first", "count": float64(2), "ratio": 0.5, "active": true}, nil},
+			{{"name": "third"}},
+		},
+		Distances:       [][]float32{{0.1, 0.2}, {0.3}},
+		Embeddings:      [][]*types.Embedding{{types.NewEmbeddingFromFloat32([]float32{1, 2}), {}}, {{}}},
+		QueryTexts:      []string{"second query"},
+		QueryEmbeddings: []*types.Embedding{types.NewEmbeddingFromFloat32([]float32{0.5, 0.5})},
+	}
+
+	t.Run("Test rows", func(t *testing.T) {
+		rows := results.Rows(0)
+		require.Len(t, rows, 2)
+		require.Equal(t, "id1", rows[0].ID)
+		require.Equal(t, "doc1", rows[0].Document)
+		require.NotNil(t, rows[0].Distance)
+		require.InDelta(t, 0.1, *rows[0].Distance, 1e-6)
+		require.NotNil(t, rows[0].Embedding)
+		require.Nil(t, rows[1].Embedding)
+		require.Nil(t, rows[1].Metadata)
+		require.Nil(t, results.Rows(2))
+		require.Nil(t, results.Rows(-1))
+	})
+
+	t.Run("Test typed metadata getters", func(t *testing.T) {
+		row := results.Rows(0)[0]
+		name, ok := row.GetString("name")
+		require.True(t, ok)
+		require.Equal(t, "first", name)
+		count, ok := row.GetInt("count")
+		require.True(t, ok)
+		require.Equal(t, int64(2), count)
+		_, ok = row.GetInt("ratio")
+		require.False(t, ok)
+		ratio, ok := row.GetFloat("ratio")
+		require.True(t, ok)
+		require.InDelta(t, 0.5, ratio, 1e-9)
+		active, ok := row.GetBool("active")
+		require.True(t, ok)
+		require.True(t, active)
+		_, ok = row.GetString("missing")
+		require.False(t, ok)
+		_, ok = row.GetBool("name")
+		require.False(t, ok)
+	})
+
+	t.Run("Test to records", func(t *testing.T) {
+		records := results.ToRecords()
+		require.Len(t, records, 2)
+		require.Len(t, records[0], 2)
+		require.Equal(t, "id1", records[0][0].ID)
+		require.Equal(t, []float32{1, 2}, *records[0][0].Embedding.ArrayOfFloat32)
+		require.Equal(t, "third", records[1][0].Metadata["name"])
+	})
+
+	t.Run("Test rows JSON", func(t *testing.T) {
+		data, err := results.MarshalRows()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"queries":[
+			{"query_embedding":[0.5,0.5],"rows":[
+				{"id":"id1","document":"doc1","metadata":{"name":"first","count":2,"ratio":0.5,"active":true},"distance":0.1,"embedding":[1,2]},
+				{"id":"id2","document":"doc2","distance":0.2}]},
+			{"query_text":"second query","rows":[
+				{"id":"id3","document":"doc3","metadata":{"name":"third"},"distance":0.3}]}
+		]}`, string(data))
+	})
+
+	t.Run("Test JSON round trip", func(t *testing.T) {
+		data, err := json.Marshal(results)
+		require.NoError(t, err)
+		var decoded chroma.QueryResults
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, results.Ids, decoded.Ids)
+		require.Equal(t, results.Documents, decoded.Documents)
+		require.Equal(t, results.Distances, decoded.Distances)
+		require.Equal(t, results.QueryTexts, decoded.QueryTexts)
+		require.Equal(t, []float32{1, 2}, *decoded.Embeddings[0][0].ArrayOfFloat32)
+		require.Equal(t, []float32{0.5, 0.5}, *decoded.QueryEmbeddings[0].ArrayOfFloat32)
+	})
+}
+
+func TestGetResultsRows(t *testing.T) {
+	results := &chroma.GetResults{
+		Ids:        []string{"id1", "id2"},
+		Documents:  []string{"doc1"},
+		Metadatas:  []map[string]interface{}{{"tag": "a"}, {"tag": "b"}},
+		Embeddings: []*types.Embedding{types.NewEmbeddingFromInt32([]int32{1, 2}), types.NewEmbeddingFromInt32([]int32{3, 4})},
+	}
+
+	t.Run("Test rows", func(t *testing.T) {
+		rows := results.Rows()
+		require.Len(t, rows, 2)
+		require.Equal(t, "doc1", rows[0].Document)
+		require.Equal(t, "", rows[1].Document)
+		tag, ok := rows[1].GetString("tag")
+		require.True(t, ok)
+		require.Equal(t, "b", tag)
+		require.Nil(t, rows[0].Distance)
+	})
+
+	t.Run("Test to records", func(t *testing.T) {
+		records := results.ToRecords()
+		require.Len(t, records, 2)
+		require.Equal(t, "id2", records[1].ID)
+		require.Equal(t, []int32{3, 4}, *records[1].Embedding.ArrayOfInt32)
+	})
+
+	t.Run("Test rows JSON", func(t *testing.T) {
+		data, err := results.MarshalRows()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"rows":[
+			{"id":"id1","document":"doc1","metadata":{"tag":"a"},"embedding":[1,2]},
+			{"id":"id2","metadata":{"tag":"b"},"embedding":[3,4]}
+		]}`, string(data))
+	})
+
+	t.Run("Test JSON round trip", func(t *testing.T) {
+		data, err := json.Marshal(results)
+		require.NoError(t, err)
+		var decoded chroma.GetResults
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, results.Ids, decoded.Ids)
+		require.Equal(t, results.Documents, decoded.Documents)
+		require.Equal(t, results.Metadatas, decoded.Metadatas)
+		require.Len(t, decoded.Embeddings, 2)
+		require.Equal(t, []float32{3, 4}, *decoded.Embeddings[1].ArrayOfFloat32)
+	})
+}
@@ -0,0 +1,129 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/collection"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// blockingEmbeddingFunction blocks until the context is done.
+type blockingEmbeddingFunction struct{}
+
+func (b *blockingEmbeddingFunction) EmbedDocuments(ctx context.Context, texts []string) ([]*types.Embedding, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (b *blockingEmbeddingFunction) EmbedQuery(ctx context.Context, text string) (*types.Embedding, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (b *blockingEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(b, ctx, records, force)
+}
+
+// newSlowServer returns a fake server that answers add requests after the delay.
+func newSlowServer(delay time.Duration) *httptest.Server {
+	fake := &fakeChromaServer{v1Version: "0.5.5"}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/add") {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		fake.ServeHTTP(w, r)
+	}))
+}
+
+func TestTimeouts(t *testing.T) {
+	server := newSlowServer(200 * time.Millisecond)
+	defer server.Close()
+	embeddings := []*types.Embedding{types.NewEmbeddingFromFloat32([]float32{1, 2, 3})}
+
+	newCollection := func(t *testing.T, options ...chroma.ClientOption) *chroma.Collection {
+		client, err := chroma.NewClient(append([]chroma.ClientOption{chroma.WithBasePath(server.URL)}, options...)...)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = client.Close() })
+		col, err := client.NewCollection(context.Background(), "test", collection.WithEmbeddingFunction(&blockingEmbeddingFunction{}))
+		require.NoError(t, err)
+		return col
+	}
+
+	t.Run("Test default timeouts", func(t *testing.T) {
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+		require.NoError(t, err)
+		require.Equal(t, chroma.Timeouts{Read: types.DefaultTimeout, Write: types.DefaultTimeout, Embedding: types.DefaultTimeout}, client.Timeouts())
+	})
+
+	t.Run("Test write timeout", func(t *testing.T) {
+		col := newCollection(t, chroma.WithWriteTimeout(50*time.Millisecond))
+		_, err := col.Add(context.Background(), embeddings, nil, nil, []string{"1"})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		// reads are not limited by the write timeout
+		_, err = col.Count(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("Test per-call timeout", func(t *testing.T) {
+		col := newCollection(t, chroma.WithTimeout(50*time.Millisecond))
+		_, err := col.Add(chroma.ContextWithTimeout(context.Background(), time.Second), embeddings, nil, nil, []string{"1"})
+		require.NoError(t, err)
+		_, err = col.Add(chroma.ContextWithoutTimeout(context.Background()), embeddings, nil, nil, []string{"1"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Test shorter caller deadline applies", func(t *testing.T) {
+		col := newCollection(t, chroma.WithTimeout(0))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := col.Add(ctx, embeddings, nil, nil, []string{"1"})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		ctx, cancel = context.WithTimeout(chroma.ContextWithTimeout(context.Background(), time.Minute), 50*time.Millisecond)
+		defer cancel()
+		_, err = col.Add(ctx, embeddings, nil, nil, []string{"1"})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Test no timeout", func(t *testing.T) {
+		col := newCollection(t, chroma.WithTimeout(0))
+		_, err := col.Add(context.Background(), embeddings, nil, nil, []string{"1"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Test embedding timeout", func(t *testing.T) {
+		col := newCollection(t, chroma.WithEmbeddingTimeout(50*time.Millisecond))
+		start := time.Now()
+		_, err := col.Add(context.Background(), nil, nil, []string{"document"}, []string{"1"})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		_, err = col.Query(context.Background(), []string{"query"}, 1, nil, nil, nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Test invalid timeouts", func(t *testing.T) {
+		for _, option := range []chroma.ClientOption{
+			chroma.WithTimeout(-time.Second),
+			chroma.WithReadTimeout(-time.Second),
+			chroma.WithWriteTimeout(-time.Second),
+			chroma.WithEmbeddingTimeout(-time.Second),
+		} {
+			_, err := chroma.NewClient(option)
+			require.Error(t, err)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key signed by the CA.
+func (ca *testCA) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, ca.pem, 0o600))
+	certPath, keyPath := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	writeClientCert := func(commonName string, modTime time.Time) {
+		cert, key := ca.issue(t, commonName, x509.ExtKeyUsageClientAuth)
+		require.NoError(t, os.WriteFile(certPath, cert, 0o600))
+		require.NoError(t, os.WriteFile(keyPath, key, 0o600))
+		require.NoError(t, os.Chtimes(certPath, modTime, modTime))
+		require.NoError(t, os.Chtimes(keyPath, modTime, modTime))
+	}
+	writeClientCert("client-1", time.Now().Add(-time.Minute))
+
+	serverCertPEM, serverKeyPEM := ca.issue(t, "chroma", x509.ExtKeyUsageServerAuth)
+	serverCert, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	var mu sync.Mutex
+	var clients []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		clients = append(clients, r.TLS.PeerCertificates[0].Subject.CommonName)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"nanosecond heartbeat": 1}`))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	t.Run("Test client certificate and reload", func(t *testing.T) {
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithSSLCert(caPath), chroma.WithClientCertificate(certPath, keyPath), chroma.WithMinTLSVersion(tls.VersionTLS12))
+		require.NoError(t, err)
+		_, err = client.Heartbeat(context.Background())
+		require.NoError(t, err)
+
+		writeClientCert("client-2", time.Now())
+		server.CloseClientConnections()
+		_, err = client.Heartbeat(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"client-1", "client-2"}, clients)
+	})
+
+	t.Run("Test without client certificate", func(t *testing.T) {
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithSSLCert(caPath))
+		require.NoError(t, err)
+		_, err = client.Heartbeat(context.Background())
+		require.Error(t, err)
+	})
+
+	t.Run("Test TLS config and version constraints", func(t *testing.T) {
+		clientCert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		require.NoError(t, err)
+		config := &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{clientCert}, MinVersion: tls.VersionTLS12}
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithTLSConfig(config), chroma.WithCipherSuites(tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256))
+		require.NoError(t, err)
+		_, err = client.Heartbeat(context.Background())
+		require.NoError(t, err)
+		require.Nil(t, config.CipherSuites, "the config is cloned")
+
+		server.CloseClientConnections()
+		client, err = chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithTLSConfig(config), chroma.WithMinTLSVersion(tls.VersionTLS13))
+		require.NoError(t, err)
+		_, err = client.Heartbeat(context.Background())
+		require.NoError(t, err, "the test server supports TLS 1.3")
+	})
+
+	t.Run("Test invalid options", func(t *testing.T) {
+		_, err := chroma.NewClient(chroma.WithClientCertificate(certPath, filepath.Join(dir, "missing.pem")))
+		require.Error(t, err)
+		_, err = chroma.NewClient(chroma.WithClientCertificate(caPath, keyPath))
+		require.Error(t, err, "certificate does not match the key")
+		_, err = chroma.NewClient(chroma.WithMinTLSVersion(tls.VersionTLS10))
+		require.Error(t, err)
+		_, err = chroma.NewClient(chroma.WithCipherSuites(tls.TLS_RSA_WITH_RC4_128_SHA))
+		require.Error(t, err)
+		_, err = chroma.NewClient(chroma.WithTLSConfig(nil))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,156 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+type article struct {
+	ID       string    `chroma:"id"`
+	Body     string    `chroma:"document"`
+	Author   string    `chroma:"metadata,name=author,omitempty"`
+	Year     int       `chroma:"metadata,name=year"`
+	Score    *float32  `chroma:"metadata"`
+	Vector   []float32 `chroma:"embedding"`
+	Internal string
+	Skipped  string `chroma:"-"`
+}
+
+func TestTypedCollection(t *testing.T) {
+	var lastBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		lastBody = map[string]interface{}{}
+		if len(body) > 0 {
+			require.NoError(t, json.Unmarshal(body, &lastBody))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/collections/col-id/add", "/api/v1/collections/col-id/upsert":
+			_, err = w.Write([]byte(`true`))
+		case "/api/v1/collections/col-id/get":
+			_, err = w.Write([]byte(`{"ids":["a1","a2"],"documents":["first","second"],"metadatas":[{"author":"jane","year":2021,"Score":0.5},null],"embeddings":[[0.1,0.2],[0.3,0.4]]}`))
+		case "/api/v1/collections/col-id/query":
+			_, err = w.Write([]byte(`{"ids":[["a2","a1"]],"documents":[["second","first"]],"metadatas":[[{"author":"joe","year":1999},{"author":"jane","year":2021}]],"distances":[[0.1,0.7]],"embeddings":null}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+	require.NoError(t, err)
+	col := chroma.NewCollection(client.ApiClient, "col-id", "articles", nil, types.NewConsistentHashEmbeddingFunction(), types.DefaultTenant, types.DefaultDatabase)
+
+	t.Run("Test Add maps tagged fields", func(t *testing.T) {
+		tc, err := chroma.NewTypedCollection[article](col)
+		require.NoError(t, err)
+		score := float32(0.5)
+		err = tc.Add(context.Background(),
+			article{ID: "a1", Body: "first", Author: "jane", Year: 2021, Score: &score, Vector: []float32{0.1, 0.2}, Internal: "x"},
+			article{ID: "a2", Body: "second", Author: "joe", Year: 1999, Vector: []float32{0.3, 0.4}},
+		)
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"a1", "a2"}, lastBody["ids"])
+		require.Equal(t, []interface{}{"first", "second"}, lastBody["documents"])
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"author": "jane", "year": float64(2021), "Score": float64(0.5)},
+			map[string]interface{}{"author": "joe", "year": float64(1999)},
+		}, lastBody["metadatas"])
+		require.Len(t, lastBody["embeddings"], 2)
+	})
+
+	t.Run("Test Upsert embeds documents without embeddings", func(t *testing.T) {
+		tc, err := chroma.NewTypedCollection[article](col)
+		require.NoError(t, err)
+		err = tc.Upsert(context.Background(), article{ID: "a1", Body: "first"})
+		require.NoError(t, err)
+		require.Len(t, lastBody["embeddings"], 1)
+		require.Equal(t, []interface{}{map[string]interface{}{"year": float64(0)}}, lastBody["metadatas"])
+	})
+
+	t.Run("Test Add with mixed embeddings fails", func(t *testing.T) {
+		tc, err := chroma.NewTypedCollection[article](col)
+		require.NoError(t, err)
+		err = tc.Add(context.Background(), article{ID: "a1", Vector: []float32{1}}, article{ID: "a2", Body: "second"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "either all or none")
+	})
+
+	t.Run("Test Get decodes records", func(t *testing.T) {
+		tc, err := chroma.NewTypedCollection[article](col)
+		require.NoError(t, err)
+		items, err := tc.Get(context.Background(), types.WithIds([]string{"a1", "a2"}))
+		require.NoError(t, err)
+		require.ElementsMatch(t, []interface{}{"documents", "metadatas", "embeddings"}, lastBody["include"])
+		require.Len(t, items, 2)
+		require.Equal(t, "a1", items[0].ID)
+		require.Equal(t, "first", items[0].Body)
+		require.Equal(t, "jane", items[0].Author)
+		require.Equal(t, 2021, items[0].Year)
+		require.NotNil(t, items[0].Score)
+		require.InDelta(t, 0.5, *items[0].Score, 1e-6)
+		require.Equal(t, []float32{0.1, 0.2}, items[0].Vector)
+		require.Equal(t, "a2", items[1].ID)
+		require.Nil(t, items[1].Score)
+	})
+
+	t.Run("Test Query returns items with distances", func(t *testing.T) {
+		tc, err := chroma.NewTypedCollection[article](col)
+		require.NoError(t, err)
+		results, err := tc.Query(context.Background(), types.WithQueryText("hello"), types.WithNResults(2))
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, []float32{0.1, 0.7}, results[0].Distances)
+		require.Equal(t, "a2", results[0].Items[0].ID)
+		require.Equal(t, "joe", results[0].Items[0].Author)
+		require.Equal(t, 1999, results[0].Items[0].Year)
+		require.Nil(t, results[0].Items[0].Vector)
+	})
+
+	t.Run("Test invalid mappings", func(t *testing.T) {
+		type noID struct {
+			Body string `chroma:"document"`
+		}
+		_, err := chroma.NewTypedCollection[noID](col)
+		require.Error(t, err)
+
+		type badMetadata struct {
+			ID    string  `chroma:"id"`
+			Ratio float64 `chroma:"metadata"`
+		}
+		_, err = chroma.NewTypedCollection[badMetadata](col)
+		require.Error(t, err)
+		var invalidMetadata *types.InvalidMetadataValueError
+		require.ErrorAs(t, err, &invalidMetadata)
+
+		type badEmbedding struct {
+			ID     string    `chroma:"id"`
+			Vector []float64 `chroma:"embedding"`
+		}
+		_, err = chroma.NewTypedCollection[badEmbedding](col)
+		require.Error(t, err)
+
+		type unknownTag struct {
+			ID   string `chroma:"id"`
+			Name string `chroma:"name"`
+		}
+		_, err = chroma.NewTypedCollection[unknownTag](col)
+		require.Error(t, err)
+
+		_, err = chroma.NewTypedCollection[*article](col)
+		require.Error(t, err)
+	})
+}
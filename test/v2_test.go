@@ -0,0 +1,234 @@
+//go:build basic
+
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	chroma "github.com/szirtesitidom/chroma-go"
+	"github.com/szirtesitidom/chroma-go/collection"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// fakeChromaServer answers the routes used by the tests. v1Version is the response of /api/v1/version, an empty string
+// makes the v1 API unavailable (410). The first versionFailures version requests fail with 500.
+type fakeChromaServer struct {
+	mu              sync.Mutex
+	requests        []string
+	v1Version       string
+	versionFailures int
+}
+
+func (f *fakeChromaServer) paths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func (f *fakeChromaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	request := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" {
+		request += "?" + r.URL.RawQuery
+	}
+	f.requests = append(f.requests, request)
+	failVersion := strings.HasSuffix(r.URL.Path, "/version") && f.versionFailures > 0
+	if failVersion {
+		f.versionFailures--
+	}
+	f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	respond := func(body string) {
+		_, _ = w.Write([]byte(body))
+	}
+	path := r.URL.Path
+	switch {
+	case failVersion:
+		w.WriteHeader(http.StatusInternalServerError)
+		respond(`{"error":"InternalError"}`)
+	case path == "/api/v1/version":
+		if f.v1Version == "" {
+			w.WriteHeader(http.StatusGone)
+			respond(`{"error":"Unimplemented","message":"The v1 API is deprecated. Please use /v2 apis"}`)
+			return
+		}
+		respond(`"` + f.v1Version + `"`)
+	case path == "/api/v2/version":
+		respond(`"1.0.0"`)
+	case path == "/api/v2/tenants/default_tenant" || path == "/api/v2/tenants/my_tenant" || r.Method == http.MethodGet && strings.HasPrefix(path, "/api/v1/tenants/"):
+		respond(`{"name":"default_tenant"}`)
+	case path == "/api/v2/tenants/default_tenant/databases/default_database" || r.Method == http.MethodGet && strings.HasPrefix(path, "/api/v1/databases/"):
+		respond(`{"id":"00000000-0000-0000-0000-000000000000","name":"default_database","tenant":"default_tenant"}`)
+	case strings.HasSuffix(path, "/pre-flight-checks"):
+		respond(`{"max_batch_size":100}`)
+	case path == "/api/v2/auth/identity":
+		respond(`{"user_id":"user-1","tenant":"default_tenant","databases":["default_database"]}`)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/tenants"):
+		respond(`{}`)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/databases"):
+		respond(`{"id":"00000000-0000-0000-0000-000000000001","name":"my_database","tenant":"my_tenant"}`)
+	case strings.HasSuffix(path, "/collections_count") || strings.HasSuffix(path, "/count_collections") || strings.HasSuffix(path, "/count"):
+		respond(`1`)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/collections"):
+		respond(`[]`)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/collections"):
+		respond(`{"id":"c1","name":"test","tenant":"my_tenant","database":"my_database","configuration_json":{"hnsw":{"space":"cosine"}}}`)
+	case strings.HasSuffix(path, "/add"):
+		w.WriteHeader(http.StatusCreated)
+		respond(`true`)
+	case strings.HasSuffix(path, "/query"):
+		respond(`{"ids":[["1"]],"distances":[[0.1]],"documents":[["doc"]],"metadatas":[[null]]}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestServerAPIVersion(t *testing.T) {
+	t.Run("Test auto-detect v2 when v1 is unavailable", func(t *testing.T) {
+		fake := &fakeChromaServer{}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+		require.NoError(t, err)
+		require.Equal(t, chroma.ServerAPIV1, client.ServerAPIVersion())
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, chroma.ServerAPIV2, client.ServerAPIVersion())
+		require.Equal(t, "1.0.0", client.APIVersion.String())
+		require.Equal(t, []string{
+			"GET /api/v1/version",
+			"GET /api/v2/version",
+			"GET /api/v2/tenants/default_tenant",
+			"GET /api/v2/tenants/default_tenant/databases/default_database",
+			"GET /api/v2/pre-flight-checks",
+			"GET /api/v2/tenants/default_tenant/databases/default_database/collections_count",
+		}, fake.paths())
+	})
+
+	t.Run("Test auto-detect v2 from server version", func(t *testing.T) {
+		fake := &fakeChromaServer{v1Version: "1.0.0"}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+		require.NoError(t, err)
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, chroma.ServerAPIV2, client.ServerAPIVersion())
+		require.Equal(t, "GET /api/v2/tenants/default_tenant/databases/default_database/collections_count", fake.paths()[len(fake.paths())-1])
+	})
+
+	t.Run("Test auto-detect v1 for older servers", func(t *testing.T) {
+		fake := &fakeChromaServer{v1Version: "0.5.5"}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+		require.NoError(t, err)
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, chroma.ServerAPIV1, client.ServerAPIVersion())
+		require.Equal(t, "GET /api/v1/count_collections?database=default_database&tenant=default_tenant", fake.paths()[len(fake.paths())-1])
+	})
+
+	t.Run("Test forced v1 does not fall back", func(t *testing.T) {
+		fake := &fakeChromaServer{}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithServerAPIVersion(chroma.ServerAPIV1))
+		require.NoError(t, err)
+		_, err = client.CountCollections(context.Background())
+		require.Error(t, err)
+		require.Equal(t, []string{"GET /api/v1/version"}, fake.paths())
+	})
+
+	t.Run("Test forced v2", func(t *testing.T) {
+		fake := &fakeChromaServer{v1Version: "0.5.5"}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL), chroma.WithServerAPIVersion(chroma.ServerAPIV2))
+		require.NoError(t, err)
+		require.Equal(t, chroma.ServerAPIV2, client.ServerAPIVersion())
+		_, err = client.CountCollections(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "GET /api/v2/version", fake.paths()[0])
+	})
+
+	t.Run("Test invalid server API version", func(t *testing.T) {
+		_, err := chroma.NewClient(chroma.WithBasePath("http://localhost:8000"), chroma.WithServerAPIVersion("v3"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid server API version v3")
+	})
+}
+
+func TestV2Routes(t *testing.T) {
+	fake := &fakeChromaServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	// the API is selected by the pre-flight checks
+	_, err = client.CountCollections(ctx)
+	require.NoError(t, err)
+	preFlight := len(fake.paths())
+
+	_, err = client.CreateTenant(ctx, "my_tenant")
+	require.NoError(t, err)
+	tenant := "my_tenant"
+	_, err = client.CreateDatabase(ctx, "my_database", &tenant)
+	require.NoError(t, err)
+	client.SetTenant("my_tenant")
+	client.SetDatabase("my_database")
+	col, err := client.NewCollection(ctx, "test",
+		collection.WithEmbeddingFunction(types.NewConsistentHashEmbeddingFunction()),
+		collection.WithConfiguration(map[string]interface{}{"hnsw": map[string]interface{}{"space": "cosine"}}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"hnsw": map[string]interface{}{"space": "cosine"}}, col.Configuration)
+	// the collection keeps its scope when the client defaults change
+	client.SetTenant("default_tenant")
+	client.SetDatabase("default_database")
+	_, err = col.Add(ctx, nil, nil, []string{"doc"}, []string{"1"})
+	require.NoError(t, err)
+	_, err = col.Query(ctx, []string{"doc"}, 1, nil, nil, nil)
+	require.NoError(t, err)
+	_, err = col.Count(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"POST /api/v2/tenants",
+		"POST /api/v2/tenants/my_tenant/databases",
+		"POST /api/v2/tenants/my_tenant/databases/my_database/collections",
+		"POST /api/v2/tenants/my_tenant/databases/my_database/collections/c1/add",
+		"POST /api/v2/tenants/my_tenant/databases/my_database/collections/c1/query",
+		"GET /api/v2/tenants/my_tenant/databases/my_database/collections/c1/count",
+	}, fake.paths()[preFlight:])
+}
+
+func TestGetIdentity(t *testing.T) {
+	t.Run("Test identity with v2", func(t *testing.T) {
+		server := httptest.NewServer(&fakeChromaServer{})
+		defer server.Close()
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+		require.NoError(t, err)
+		identity, err := client.GetIdentity(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, &chroma.Identity{UserID: "user-1", Tenant: "default_tenant", Databases: []string{"default_database"}}, identity)
+	})
+
+	t.Run("Test identity requires v2", func(t *testing.T) {
+		server := httptest.NewServer(&fakeChromaServer{v1Version: "0.5.5"})
+		defer server.Close()
+		client, err := chroma.NewClient(chroma.WithBasePath(server.URL))
+		require.NoError(t, err)
+		_, err = client.GetIdentity(context.Background())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only available with the v2 API")
+	})
+}
@@ -0,0 +1,101 @@
+package chromago
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+// Timeouts are the client timeouts of operations. A zero timeout does not limit the operations, the deadline of the caller's
+// context always applies.
+type Timeouts struct {
+	// Read limits operations that do not modify data, e.g. Get, Query, Count and ListCollections.
+	Read time.Duration
+	// Write limits operations that modify data, e.g. Add, Upsert, Delete and creating tenants, databases and collections.
+	Write time.Duration
+	// Embedding limits embedding documents and queries with the collection's embedding function. It is applied separately
+	// from the read or write timeout of the operation.
+	Embedding time.Duration
+}
+
+// defaultTimeouts limits all operations to types.DefaultTimeout.
+func defaultTimeouts() Timeouts {
+	return Timeouts{Read: types.DefaultTimeout, Write: types.DefaultTimeout, Embedding: types.DefaultTimeout}
+}
+
+// WithTimeout sets the read, write and embedding timeouts. Zero disables the client timeouts. Defaults to types.DefaultTimeout.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("timeout must not be negative")
+		}
+		c.timeouts = Timeouts{Read: timeout, Write: timeout, Embedding: timeout}
+		return nil
+	}
+}
+
+// WithReadTimeout sets the timeout of operations that do not modify data. Zero disables the timeout.
+func WithReadTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("read timeout must not be negative")
+		}
+		c.timeouts.Read = timeout
+		return nil
+	}
+}
+
+// WithWriteTimeout sets the timeout of operations that modify data, e.g. bulk upserts. Zero disables the timeout.
+func WithWriteTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("write timeout must not be negative")
+		}
+		c.timeouts.Write = timeout
+		return nil
+	}
+}
+
+// WithEmbeddingTimeout sets the timeout of embedding documents and queries with the collection's embedding function. Zero
+// disables the timeout.
+func WithEmbeddingTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("embedding timeout must not be negative")
+		}
+		c.timeouts.Embedding = timeout
+		return nil
+	}
+}
+
+// Timeouts returns the client timeouts of operations.
+func (c *Client) Timeouts() Timeouts {
+	return c.timeouts
+}
+
+type timeoutContextKey struct{}
+
+// ContextWithTimeout overrides the client timeouts of the operations called with the returned context, e.g. for a bulk upsert
+// that takes longer than the write timeout. Zero disables the client timeouts. A shorter deadline of ctx still applies.
+func ContextWithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutContextKey{}, timeout)
+}
+
+// ContextWithoutTimeout disables the client timeouts of the operations called with the returned context, only the deadline
+// of ctx applies.
+func ContextWithoutTimeout(ctx context.Context) context.Context {
+	return ContextWithTimeout(ctx, 0)
+}
+
+// withOperationTimeout limits ctx to the timeout of the operation, or to the timeout set with ContextWithTimeout.
+func withOperationTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if override, ok := ctx.Value(timeoutContextKey{}).(time.Duration); ok {
+		timeout = override
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
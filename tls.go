@@ -0,0 +1,69 @@
+package chromago
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// certificateReloader loads a client certificate and reloads it when the certificate or key file changes, e.g. when
+// cert-manager or Vault rotates them.
+type certificateReloader struct {
+	certPath string
+	keyPath  string
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	certMod  time.Time
+	keyMod   time.Time
+}
+
+func newCertificateReloader(certPath string, keyPath string) (*certificateReloader, error) {
+	if certPath == "" || keyPath == "" {
+		return nil, fmt.Errorf("certificate and key paths are required")
+	}
+	r := &certificateReloader{certPath: certPath, keyPath: keyPath}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// modTimes returns the modification times of the certificate and key files.
+func (r *certificateReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certPath)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.keyPath)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+func (r *certificateReloader) reload() error {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return fmt.Errorf("invalid client certificate: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+	if err != nil {
+		return fmt.Errorf("invalid client certificate: %w", err)
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+	return nil
+}
+
+// GetClientCertificate returns the current certificate, reloading it if the files changed. If the files cannot be loaded,
+// e.g. while the certificate was written but the key not yet, the previous certificate is used.
+func (r *certificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	certMod, keyMod, err := r.modTimes()
+	if err == nil && (!certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod)) {
+		_ = r.reload()
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,188 @@
+package chromago
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrClientClosed is returned by operations of a client after Close.
+var ErrClientClosed = errors.New("chroma client is closed")
+
+// Close releases the idle connections of the client and stops the heartbeats of WithEndpoints. Operations after Close return
+// ErrClientClosed, in-flight requests are not interrupted. The connections of a client set with WithHTTPClient are left to its
+// owner. Close is safe to call multiple times.
+func (c *Client) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	if c.endpoints != nil {
+		c.endpoints.stop()
+	}
+	if c.userHTTPClient == nil && c.httpTransport != nil {
+		c.httpTransport.CloseIdleConnections()
+	}
+	c.log().Debug("client closed", "base_path", c.BasePath)
+	return nil
+}
+
+// closedRoundTripper rejects requests of a closed client.
+type closedRoundTripper struct {
+	next   http.RoundTripper
+	client *Client
+}
+
+func (l *closedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if l.client.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	return l.next.RoundTrip(req)
+}
+
+// withClosedCheck returns a copy of the HTTP client that fails requests after the client was closed.
+func withClosedCheck(client *http.Client, c *Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	checked := *client
+	checked.Transport = &closedRoundTripper{next: next, client: c}
+	return &checked
+}
+
+// WithMaxIdleConns sets the maximum number of idle (keep-alive) connections across all hosts. Zero means no limit.
+// The option is mutually exclusive with WithHTTPClient.
+func WithMaxIdleConns(n int) ClientOption {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("max idle connections must not be negative")
+		}
+		c.transport().MaxIdleConns = n
+		return nil
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle (keep-alive) connections to the Chroma server. The Go default of 2
+// is low for clients shared by many goroutines. The option is mutually exclusive with WithHTTPClient.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("max idle connections per host must not be negative")
+		}
+		c.transport().MaxIdleConnsPerHost = n
+		return nil
+	}
+}
+
+// WithMaxConnsPerHost limits the number of connections to the Chroma server, including connections in use. Requests wait for a
+// free connection when the limit is reached. Zero means no limit. The option is mutually exclusive with WithHTTPClient.
+func WithMaxConnsPerHost(n int) ClientOption {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("max connections per host must not be negative")
+		}
+		c.transport().MaxConnsPerHost = n
+		return nil
+	}
+}
+
+// WithIdleConnTimeout sets how long idle connections are kept open. Zero means no limit. The option is mutually exclusive with WithHTTPClient.
+func WithIdleConnTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("idle connection timeout must not be negative")
+		}
+		c.transport().IdleConnTimeout = timeout
+		return nil
+	}
+}
+
+// WithDialTimeout sets the timeout for establishing TCP connections. The option is mutually exclusive with WithHTTPClient.
+func WithDialTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return fmt.Errorf("dial timeout must be greater than 0")
+		}
+		c.dialer().Timeout = timeout
+		return nil
+	}
+}
+
+// WithTLSHandshakeTimeout sets the timeout for TLS handshakes. The option is mutually exclusive with WithHTTPClient.
+func WithTLSHandshakeTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return fmt.Errorf("TLS handshake timeout must be greater than 0")
+		}
+		c.transport().TLSHandshakeTimeout = timeout
+		return nil
+	}
+}
+
+// WithResponseHeaderTimeout sets how long to wait for the response headers after the request was sent. Long-running operations,
+// e.g. large queries, need a generous timeout. The option is mutually exclusive with WithHTTPClient.
+func WithResponseHeaderTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return fmt.Errorf("response header timeout must be greater than 0")
+		}
+		c.transport().ResponseHeaderTimeout = timeout
+		return nil
+	}
+}
+
+// WithHTTP2 enables or disables HTTP/2 for TLS connections. HTTP/2 multiplexes concurrent requests over a single connection.
+// The option is mutually exclusive with WithHTTPClient.
+func WithHTTP2(enabled bool) ClientOption {
+	return func(c *Client) error {
+		t := c.transport()
+		t.ForceAttemptHTTP2 = enabled
+		if enabled {
+			t.TLSNextProto = nil
+		} else {
+			// a non-nil empty map disables HTTP/2
+			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+		return nil
+	}
+}
+
+// WithKeepAlive sets the interval of TCP keep-alive probes. A negative interval disables the probes.
+// The option is mutually exclusive with WithHTTPClient.
+func WithKeepAlive(interval time.Duration) ClientOption {
+	return func(c *Client) error {
+		if interval == 0 {
+			return fmt.Errorf("keep-alive interval must not be 0, use a negative interval to disable keep-alive probes")
+		}
+		c.dialer().KeepAlive = interval
+		return nil
+	}
+}
+
+// WithDisableKeepAlives uses a new connection for every request. The option is mutually exclusive with WithHTTPClient.
+func WithDisableKeepAlives() ClientOption {
+	return func(c *Client) error {
+		c.transport().DisableKeepAlives = true
+		return nil
+	}
+}
+
+// transport returns the transport of the client, creating it if needed.
+func (c *Client) transport() *http.Transport {
+	if c.httpTransport == nil {
+		c.httpTransport = &http.Transport{}
+	}
+	return c.httpTransport
+}
+
+// dialer returns the dialer of the transport, creating it if needed.
+func (c *Client) dialer() *net.Dialer {
+	if c.netDialer == nil {
+		c.netDialer = &net.Dialer{}
+		c.transport().DialContext = c.netDialer.DialContext
+	}
+	return c.netDialer
+}
@@ -0,0 +1,467 @@
+package chromago
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/szirtesitidom/chroma-go/metadata"
+	"github.com/szirtesitidom/chroma-go/types"
+)
+
+const chromaTag = "chroma"
+
+var (
+	embeddingType    = reflect.TypeOf(types.Embedding{})
+	embeddingPtrType = reflect.TypeOf(&types.Embedding{})
+	float32SliceType = reflect.TypeOf([]float32{})
+	int32SliceType   = reflect.TypeOf([]int32{})
+)
+
+// TypedCollection maps Go structs to Chroma records using `chroma` struct tags. The supported tags are:
+//
+//	type Article struct {
+//		ID     string           `chroma:"id"`
+//		Body   string           `chroma:"document"`
+//		Author string           `chroma:"metadata,name=author"`
+//		Year   int              `chroma:"metadata"` // stored under the field name "Year"
+//		Vector *types.Embedding `chroma:"embedding"`
+//		Notes  string           `chroma:"-"`
+//	}
+//
+// The id field is required. Metadata fields must be of a type accepted by metadata.WithMetadata (or a pointer to one,
+// in which case nil values are omitted). Zero values are stored unless the tag has the omitempty flag, e.g.
+// `chroma:"metadata,name=author,omitempty"`. The embedding field can be types.Embedding, *types.Embedding, []float32
+// or []int32. If the embedding field is empty the collection's embedding function is used to embed the document.
+type TypedCollection[T any] struct {
+	Collection *Collection
+	mapping    *recordMapping
+}
+
+// TypedQueryResult holds the items and their distances for a single query text or embedding.
+type TypedQueryResult[T any] struct {
+	Items     []T
+	Distances []float32
+}
+
+// NewTypedCollection wraps the collection and validates the `chroma` tags of T.
+func NewTypedCollection[T any](collection *Collection) (*TypedCollection[T], error) {
+	if collection == nil {
+		return nil, fmt.Errorf("collection cannot be nil")
+	}
+	mapping, err := newRecordMapping(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &TypedCollection[T]{Collection: collection, mapping: mapping}, nil
+}
+
+// Add adds the items to the collection.
+func (tc *TypedCollection[T]) Add(ctx context.Context, items ...T) error {
+	embeddings, metadatas, documents, ids, err := tc.encode(items)
+	if err != nil {
+		return err
+	}
+	_, err = tc.Collection.Add(ctx, embeddings, metadatas, documents, ids)
+	return err
+}
+
+// Upsert adds or updates the items in the collection.
+func (tc *TypedCollection[T]) Upsert(ctx context.Context, items ...T) error {
+	embeddings, metadatas, documents, ids, err := tc.encode(items)
+	if err != nil {
+		return err
+	}
+	_, err = tc.Collection.Upsert(ctx, embeddings, metadatas, documents, ids)
+	return err
+}
+
+// Get returns the items matching the options. Unless overridden with types.WithInclude, the fields mapped on T are included.
+func (tc *TypedCollection[T]) Get(ctx context.Context, options ...types.CollectionQueryOption) ([]T, error) {
+	options = append([]types.CollectionQueryOption{types.WithInclude(tc.mapping.include(false)...)}, options...)
+	res, err := tc.Collection.GetWithOptions(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]T, len(res.Ids))
+	for i, id := range res.Ids {
+		if err := tc.mapping.decode(reflect.ValueOf(&items[i]).Elem(), id, valueAt(res.Documents, i), valueAt(res.Metadatas, i), valueAt(res.Embeddings, i)); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// Query returns one TypedQueryResult per query text or embedding. Unless overridden with types.WithInclude, the fields mapped on T and the distances are included.
+func (tc *TypedCollection[T]) Query(ctx context.Context, options ...types.CollectionQueryOption) ([]TypedQueryResult[T], error) {
+	options = append([]types.CollectionQueryOption{types.WithInclude(tc.mapping.include(true)...)}, options...)
+	res, err := tc.Collection.QueryWithOptions(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TypedQueryResult[T], len(res.Ids))
+	for q, ids := range res.Ids {
+		items := make([]T, len(ids))
+		documents := valueAt(res.Documents, q)
+		metadatas := valueAt(res.Metadatas, q)
+		embeddings := valueAt(res.Embeddings, q)
+		for i, id := range ids {
+			if err := tc.mapping.decode(reflect.ValueOf(&items[i]).Elem(), id, valueAt(documents, i), valueAt(metadatas, i), valueAt(embeddings, i)); err != nil {
+				return nil, err
+			}
+		}
+		results[q] = TypedQueryResult[T]{Items: items, Distances: valueAt(res.Distances, q)}
+	}
+	return results, nil
+}
+
+func (tc *TypedCollection[T]) encode(items []T) ([]*types.Embedding, []map[string]interface{}, []string, []string, error) {
+	if len(items) == 0 {
+		return nil, nil, nil, nil, fmt.Errorf("at least one item is required")
+	}
+	ids := make([]string, len(items))
+	documents := make([]string, len(items))
+	metadatas := make([]map[string]interface{}, len(items))
+	embeddings := make([]*types.Embedding, 0, len(items))
+	hasMetadata := false
+	for i := range items {
+		rv := reflect.ValueOf(&items[i]).Elem()
+		id, document, md, embedding, err := tc.mapping.encode(rv)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		ids[i] = id
+		documents[i] = document
+		if len(md) > 0 {
+			metadatas[i] = md
+			hasMetadata = true
+		}
+		if embedding != nil {
+			embeddings = append(embeddings, embedding)
+		}
+	}
+	if len(embeddings) != 0 && len(embeddings) != len(items) {
+		return nil, nil, nil, nil, fmt.Errorf("either all or none of the items must have an embedding")
+	}
+	if !hasMetadata {
+		metadatas = nil
+	}
+	if tc.mapping.document == nil {
+		documents = nil
+	}
+	return embeddings, metadatas, documents, ids, nil
+}
+
+type metadataField struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+// recordMapping describes how the fields of a struct type map to the parts of a Chroma record.
+type recordMapping struct {
+	typ       reflect.Type
+	id        *int
+	document  *int
+	embedding *int
+	metadata  []metadataField
+}
+
+func newRecordMapping(typ reflect.Type) (*recordMapping, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("typed collection requires a struct type, got %v", typ)
+	}
+	m := &recordMapping{typ: typ}
+	names := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, ok := field.Tag.Lookup(chromaTag)
+		if !ok || tag == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			return nil, fmt.Errorf("field %s: chroma tag on unexported field", field.Name)
+		}
+		kind, params := parseChromaTag(tag)
+		index := i
+		switch kind {
+		case "id":
+			if m.id != nil {
+				return nil, fmt.Errorf("field %s: duplicate id field", field.Name)
+			}
+			if field.Type.Kind() != reflect.String {
+				return nil, fmt.Errorf("field %s: id field must be a string, got %v", field.Name, field.Type)
+			}
+			m.id = &index
+		case "document":
+			if m.document != nil {
+				return nil, fmt.Errorf("field %s: duplicate document field", field.Name)
+			}
+			if field.Type.Kind() != reflect.String {
+				return nil, fmt.Errorf("field %s: document field must be a string, got %v", field.Name, field.Type)
+			}
+			m.document = &index
+		case "embedding":
+			if m.embedding != nil {
+				return nil, fmt.Errorf("field %s: duplicate embedding field", field.Name)
+			}
+			switch field.Type {
+			case embeddingType, embeddingPtrType, float32SliceType, int32SliceType:
+			default:
+				return nil, fmt.Errorf("field %s: embedding field must be types.Embedding, *types.Embedding, []float32 or []int32, got %v", field.Name, field.Type)
+			}
+			m.embedding = &index
+		case "metadata":
+			name := params["name"]
+			if name == "" {
+				name = field.Name
+			}
+			if names[name] {
+				return nil, fmt.Errorf("field %s: duplicate metadata key %s", field.Name, name)
+			}
+			if !isMetadataType(field.Type) {
+				return nil, &types.InvalidMetadataValueError{Key: name, Value: reflect.Zero(field.Type).Interface()}
+			}
+			names[name] = true
+			_, omitEmpty := params["omitempty"]
+			m.metadata = append(m.metadata, metadataField{name: name, index: index, omitEmpty: omitEmpty})
+		default:
+			return nil, fmt.Errorf("field %s: unknown chroma tag %q", field.Name, tag)
+		}
+	}
+	if m.id == nil {
+		return nil, fmt.Errorf("type %v has no field tagged with `chroma:\"id\"`", typ)
+	}
+	return m, nil
+}
+
+// parseChromaTag splits a tag such as `metadata,name=author` into its kind and parameters.
+func parseChromaTag(tag string) (string, map[string]string) {
+	parts := strings.Split(tag, ",")
+	params := make(map[string]string)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		params[k] = v
+	}
+	return strings.TrimSpace(parts[0]), params
+}
+
+func isMetadataType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint32, reflect.Uint64, reflect.Float32:
+		return true
+	default:
+		return false
+	}
+}
+
+// include returns the include enums needed to populate the mapped fields.
+func (m *recordMapping) include(distances bool) []types.QueryEnum {
+	include := make([]types.QueryEnum, 0, 4)
+	if m.document != nil {
+		include = append(include, types.IDocuments)
+	}
+	if len(m.metadata) > 0 {
+		include = append(include, types.IMetadatas)
+	}
+	if m.embedding != nil {
+		include = append(include, types.IEmbeddings)
+	}
+	if distances {
+		include = append(include, types.IDistances)
+	}
+	return include
+}
+
+func (m *recordMapping) encode(rv reflect.Value) (string, string, map[string]interface{}, *types.Embedding, error) {
+	id := rv.Field(*m.id).String()
+	if id == "" {
+		return "", "", nil, nil, fmt.Errorf("id cannot be empty")
+	}
+	var document string
+	if m.document != nil {
+		document = rv.Field(*m.document).String()
+	}
+	builder := metadata.NewMetadataBuilder(nil)
+	for _, f := range m.metadata {
+		fv := rv.Field(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if err := metadata.WithMetadata(f.name, metadataValue(fv))(builder); err != nil {
+			return "", "", nil, nil, err
+		}
+	}
+	var embedding *types.Embedding
+	if m.embedding != nil {
+		embedding = embeddingFromField(rv.Field(*m.embedding))
+	}
+	return id, document, builder.Metadata, embedding, nil
+}
+
+// metadataValue converts a (possibly named) scalar value to the plain Go type accepted by metadata.WithMetadata.
+func metadataValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int:
+		return int(v.Int())
+	case reflect.Int32:
+		return int32(v.Int())
+	case reflect.Int64:
+		return v.Int()
+	case reflect.Uint32:
+		return uint32(v.Uint())
+	case reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32:
+		return float32(v.Float())
+	default:
+		return v.Interface()
+	}
+}
+
+func embeddingFromField(v reflect.Value) *types.Embedding {
+	var embedding *types.Embedding
+	switch v.Type() {
+	case embeddingType:
+		e := v.Interface().(types.Embedding)
+		embedding = &e
+	case embeddingPtrType:
+		embedding = v.Interface().(*types.Embedding)
+	case float32SliceType:
+		embedding = types.NewEmbeddingFromFloat32(v.Interface().([]float32))
+	case int32SliceType:
+		embedding = types.NewEmbeddingFromInt32(v.Interface().([]int32))
+	}
+	if embedding == nil || !embedding.IsDefined() {
+		return nil
+	}
+	return embedding
+}
+
+func (m *recordMapping) decode(rv reflect.Value, id string, document string, md map[string]interface{}, embedding *types.Embedding) error {
+	rv.Field(*m.id).SetString(id)
+	if m.document != nil {
+		rv.Field(*m.document).SetString(document)
+	}
+	for _, f := range m.metadata {
+		value, ok := md[f.name]
+		if !ok || value == nil {
+			continue
+		}
+		fv := rv.Field(f.index)
+		if fv.Kind() == reflect.Ptr {
+			fv.Set(reflect.New(fv.Type().Elem()))
+			fv = fv.Elem()
+		}
+		if err := setMetadataValue(fv, value); err != nil {
+			return fmt.Errorf("record %s: metadata key %s: %w", id, f.name, err)
+		}
+	}
+	if m.embedding != nil && embedding != nil {
+		fv := rv.Field(*m.embedding)
+		switch fv.Type() {
+		case embeddingType:
+			fv.Set(reflect.ValueOf(*embedding))
+		case embeddingPtrType:
+			fv.Set(reflect.ValueOf(embedding))
+		case float32SliceType:
+			fv.Set(reflect.ValueOf(embedding.ToFloat32()))
+		case int32SliceType:
+			if embedding.ArrayOfInt32 == nil {
+				return fmt.Errorf("record %s: cannot decode a float32 embedding into []int32", id)
+			}
+			fv.Set(reflect.ValueOf(*embedding.ArrayOfInt32))
+		}
+	}
+	return nil
+}
+
+// setMetadataValue assigns a metadata value as decoded from the API (JSON numbers are float64) to the field.
+func setMetadataValue(fv reflect.Value, value interface{}) error {
+	switch fv.Kind() {
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %T", value)
+		}
+		fv.SetString(s)
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool, got %T", value)
+		}
+		fv.SetBool(b)
+	case reflect.Float32:
+		f, ok := toFloat64(value)
+		if !ok {
+			return fmt.Errorf("expected number, got %T", value)
+		}
+		fv.SetFloat(f)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		f, ok := toFloat64(value)
+		if !ok || f != math.Trunc(f) {
+			return fmt.Errorf("expected integer, got %v", value)
+		}
+		if fv.OverflowInt(int64(f)) {
+			return fmt.Errorf("value %v overflows %v", value, fv.Type())
+		}
+		fv.SetInt(int64(f))
+	case reflect.Uint32, reflect.Uint64:
+		f, ok := toFloat64(value)
+		if !ok || f != math.Trunc(f) || f < 0 {
+			return fmt.Errorf("expected unsigned integer, got %v", value)
+		}
+		if fv.OverflowUint(uint64(f)) {
+			return fmt.Errorf("value %v overflows %v", value, fv.Type())
+		}
+		fv.SetUint(uint64(f))
+	default:
+		return fmt.Errorf("unsupported field type %v", fv.Type())
+	}
+	return nil
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// valueAt returns the i-th element of the slice or the zero value if the slice is too short (e.g. the field was not included).
+func valueAt[E any](s []E, i int) E {
+	var zero E
+	if i < 0 || i >= len(s) {
+		return zero
+	}
+	return s[i]
+}
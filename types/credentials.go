@@ -0,0 +1,304 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	openapi "github.com/szirtesitidom/chroma-go/swagger"
+)
+
+// RequestAuthenticator sets credentials on every request sent by the client. See WithAuth.
+type RequestAuthenticator interface {
+	AuthenticateRequest(req *http.Request) error
+}
+
+var _ RequestAuthenticator = (*BasicAuthCredentialsProvider)(nil)
+var _ RequestAuthenticator = (*TokenAuthCredentialsProvider)(nil)
+var _ RequestAuthenticator = (*RefreshingCredentialsProvider)(nil)
+
+const (
+	// DefaultRefreshBefore is how long before expiry a token is refreshed.
+	DefaultRefreshBefore = time.Minute
+	// DefaultTokenFileReloadInterval is how often a token file without a JWT expiry is read again.
+	DefaultTokenFileReloadInterval = time.Minute
+)
+
+// Token is an access token. A zero Expiry means the token does not expire.
+type Token struct {
+	Value  string
+	Expiry time.Time
+}
+
+// TokenFetcher returns a new token. It is called when there is no token yet or the current token is about to expire.
+type TokenFetcher func(ctx context.Context) (*Token, error)
+
+// RefreshingCredentialsProvider caches a token from a TokenFetcher and refreshes it before it expires. If a refresh fails while the
+// cached token is still valid, the cached token is used and the refresh is retried with the next request. It is safe for concurrent use.
+type RefreshingCredentialsProvider struct {
+	fetch         TokenFetcher
+	header        TokenTransportHeader
+	refreshBefore time.Duration
+	now           func() time.Time
+	group         singleflight.Group
+
+	mu         sync.Mutex
+	token      *Token
+	generation uint64 // incremented by Invalidate, so fetches started before do not cache their token
+}
+
+type RefreshingCredentialsOption func(p *RefreshingCredentialsProvider) error
+
+// WithTokenHeader sets the header the token is sent in. Defaults to AuthorizationTokenHeader (as Bearer token).
+func WithTokenHeader(header TokenTransportHeader) RefreshingCredentialsOption {
+	return func(p *RefreshingCredentialsProvider) error {
+		if _, err := header.value(""); err != nil {
+			return err
+		}
+		p.header = header
+		return nil
+	}
+}
+
+// WithRefreshBefore sets how long before expiry a token is refreshed. Defaults to DefaultRefreshBefore.
+func WithRefreshBefore(d time.Duration) RefreshingCredentialsOption {
+	return func(p *RefreshingCredentialsProvider) error {
+		if d < 0 {
+			return fmt.Errorf("refresh before must not be negative")
+		}
+		p.refreshBefore = d
+		return nil
+	}
+}
+
+// NewCallbackCredentialsProvider returns a provider that gets tokens from the callback, e.g. from a cloud IAM SDK or a secrets manager.
+func NewCallbackCredentialsProvider(fetch TokenFetcher, opts ...RefreshingCredentialsOption) (*RefreshingCredentialsProvider, error) {
+	if fetch == nil {
+		return nil, fmt.Errorf("token fetcher cannot be nil")
+	}
+	p := &RefreshingCredentialsProvider{
+		fetch:         fetch,
+		header:        AuthorizationTokenHeader,
+		refreshBefore: DefaultRefreshBefore,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Token returns the cached token, fetching a new one if there is none or it expires within the refresh window. Concurrent callers
+// share a single fetch, which runs without holding the lock and is not canceled if one of the callers gives up. While the cached
+// token is still valid, it is returned if the fetch fails or the context of the caller is done before the fetch completes.
+func (p *RefreshingCredentialsProvider) Token(ctx context.Context) (*Token, error) {
+	p.mu.Lock()
+	token, generation, now := p.token, p.generation, p.now()
+	p.mu.Unlock()
+	if token != nil && (token.Expiry.IsZero() || now.Add(p.refreshBefore).Before(token.Expiry)) {
+		return token, nil
+	}
+	shared := context.WithoutCancel(ctx)
+	ch := p.group.DoChan(strconv.FormatUint(generation, 10), func() (interface{}, error) {
+		fetched, err := p.fetch(shared)
+		if err != nil {
+			return nil, err
+		}
+		if fetched == nil || fetched.Value == "" {
+			return nil, fmt.Errorf("empty token")
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.generation == generation {
+			p.token = fetched
+		}
+		return fetched, nil
+	})
+	valid := token != nil && now.Before(token.Expiry)
+	select {
+	case <-ctx.Done():
+		if valid {
+			return token, nil
+		}
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			if valid {
+				return token, nil
+			}
+			return nil, fmt.Errorf("failed to fetch token: %w", res.Err)
+		}
+		return res.Val.(*Token), nil
+	}
+}
+
+// Invalidate drops the cached token, e.g. after the server rejected it, so the next request fetches a new one.
+func (p *RefreshingCredentialsProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = nil
+	p.generation++
+}
+
+func (p *RefreshingCredentialsProvider) AuthenticateRequest(req *http.Request) error {
+	token, err := p.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	value, err := p.header.value(token.Value)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(string(p.header), value)
+	return nil
+}
+
+// Authenticate sets the current token as a static header. Use the provider with WithAuth to refresh the token per request instead.
+func (p *RefreshingCredentialsProvider) Authenticate(config *openapi.Configuration) error {
+	token, err := p.Token(context.Background())
+	if err != nil {
+		return err
+	}
+	value, err := p.header.value(token.Value)
+	if err != nil {
+		return err
+	}
+	config.DefaultHeader[string(p.header)] = value
+	return nil
+}
+
+// OAuth2ClientCredentialsConfig configures the OAuth2 client credentials grant (RFC 6749 section 4.4).
+type OAuth2ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// EndpointParams are additional form parameters of the token request, e.g. audience.
+	EndpointParams url.Values
+	// HTTPClient is used for token requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// NewOAuth2ClientCredentialsProvider returns a provider that gets tokens from the token endpoint with the client credentials grant
+// and refreshes them before they expire.
+func NewOAuth2ClientCredentialsProvider(config OAuth2ClientCredentialsConfig, opts ...RefreshingCredentialsOption) (*RefreshingCredentialsProvider, error) {
+	if config.TokenURL == "" {
+		return nil, fmt.Errorf("token URL is required")
+	}
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("client ID is required")
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return NewCallbackCredentialsProvider(config.fetchToken, opts...)
+}
+
+func (c OAuth2ClientCredentialsConfig) fetchToken(ctx context.Context) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	for k, v := range c.EndpointParams {
+		form[k] = v
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	requested := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected code [%v] from token endpoint: %v", resp.Status, string(body))
+	}
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return nil, err
+	}
+	if tokenResponse.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned no access token")
+	}
+	token := &Token{Value: tokenResponse.AccessToken}
+	if tokenResponse.ExpiresIn > 0 {
+		token.Expiry = requested.Add(time.Duration(tokenResponse.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// NewTokenFileCredentialsProvider returns a provider that reads the token from a file, e.g. a Kubernetes projected service account token.
+// The file is read again before the JWT expiry of the token, or every DefaultTokenFileReloadInterval if the token is not a JWT,
+// so rotated tokens are picked up.
+func NewTokenFileCredentialsProvider(path string, opts ...RefreshingCredentialsOption) (*RefreshingCredentialsProvider, error) {
+	if path == "" {
+		return nil, fmt.Errorf("token file path is required")
+	}
+	var p *RefreshingCredentialsProvider
+	p, err := NewCallbackCredentialsProvider(func(ctx context.Context) (*Token, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		value := string(bytes.TrimSpace(data))
+		if value == "" {
+			return nil, fmt.Errorf("token file %s is empty", path)
+		}
+		// refresh at the earlier of the JWT expiry and the reload interval, the file may be rotated before the token expires
+		expiry := p.now().Add(DefaultTokenFileReloadInterval + p.refreshBefore)
+		if jwtExpiry, ok := JWTExpiry(value); ok && jwtExpiry.Before(expiry) {
+			expiry = jwtExpiry
+		}
+		return &Token{Value: value, Expiry: expiry}, nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// JWTExpiry returns the exp claim of a JWT without verifying the signature.
+func JWTExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(*claims.Exp), 0), true
+}
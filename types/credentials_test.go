@@ -0,0 +1,254 @@
+//go:build basic
+
+package types
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func jwtWithExpiry(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"chroma","exp":%d}`, exp.Unix())))
+	return "eyJhbGciOiJub25lIn0." + payload + ".sig"
+}
+
+func authHeader(t *testing.T, p RequestAuthenticator) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	require.NoError(t, err)
+	err = p.AuthenticateRequest(req)
+	return req.Header.Get("Authorization") + req.Header.Get("X-Chroma-Token"), err
+}
+
+func TestRefreshingCredentialsProvider(t *testing.T) {
+	t.Run("Test refreshes before expiry", func(t *testing.T) {
+		now := time.Unix(1000, 0)
+		var fetches int
+		p, err := NewCallbackCredentialsProvider(func(ctx context.Context) (*Token, error) {
+			fetches++
+			return &Token{Value: fmt.Sprintf("token-%d", fetches), Expiry: now.Add(5 * time.Minute)}, nil
+		})
+		require.NoError(t, err)
+		p.now = func() time.Time { return now }
+		header, err := authHeader(t, p)
+		require.NoError(t, err)
+		require.Equal(t, "Bearer token-1", header)
+
+		now = now.Add(3 * time.Minute)
+		header, _ = authHeader(t, p)
+		require.Equal(t, "Bearer token-1", header, "still valid outside the refresh window")
+
+		now = now.Add(90 * time.Second)
+		header, _ = authHeader(t, p)
+		require.Equal(t, "Bearer token-2", header, "refreshed within DefaultRefreshBefore of the expiry")
+		require.Equal(t, 2, fetches)
+	})
+
+	t.Run("Test failed refresh keeps valid token", func(t *testing.T) {
+		now := time.Unix(1000, 0)
+		fail := false
+		p, err := NewCallbackCredentialsProvider(func(ctx context.Context) (*Token, error) {
+			if fail {
+				return nil, fmt.Errorf("identity provider unavailable")
+			}
+			return &Token{Value: "token", Expiry: now.Add(time.Minute)}, nil
+		}, WithTokenHeader(XChromaTokenHeader), WithRefreshBefore(30*time.Second))
+		require.NoError(t, err)
+		p.now = func() time.Time { return now }
+		header, err := authHeader(t, p)
+		require.NoError(t, err)
+		require.Equal(t, "token", header)
+
+		fail = true
+		now = now.Add(45 * time.Second)
+		header, err = authHeader(t, p)
+		require.NoError(t, err)
+		require.Equal(t, "token", header)
+
+		now = now.Add(30 * time.Second)
+		_, err = authHeader(t, p)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "identity provider unavailable")
+	})
+
+	t.Run("Test concurrent requests fetch once", func(t *testing.T) {
+		var fetches atomic.Int32
+		p, err := NewCallbackCredentialsProvider(func(ctx context.Context) (*Token, error) {
+			fetches.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			return &Token{Value: "token", Expiry: time.Now().Add(time.Hour)}, nil
+		})
+		require.NoError(t, err)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				header, err := authHeader(t, p)
+				require.NoError(t, err)
+				require.Equal(t, "Bearer token", header)
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, int32(1), fetches.Load())
+		p.Invalidate()
+		_, err = authHeader(t, p)
+		require.NoError(t, err)
+		require.Equal(t, int32(2), fetches.Load())
+	})
+
+	t.Run("Test slow fetch does not block requests", func(t *testing.T) {
+		now := time.Unix(1000, 0)
+		release := make(chan struct{})
+		var fetches atomic.Int32
+		p, err := NewCallbackCredentialsProvider(func(ctx context.Context) (*Token, error) {
+			n := fetches.Add(1)
+			if n > 1 {
+				<-release
+			}
+			return &Token{Value: fmt.Sprintf("token-%d", n), Expiry: now.Add(5 * time.Minute)}, nil
+		})
+		require.NoError(t, err)
+		p.now = func() time.Time { return now }
+		header, err := authHeader(t, p)
+		require.NoError(t, err)
+		require.Equal(t, "Bearer token-1", header)
+
+		now = now.Add(270 * time.Second)
+		done := make(chan string)
+		go func() {
+			header, err := authHeader(t, p)
+			require.NoError(t, err)
+			done <- header
+		}()
+		require.Eventually(t, func() bool { return fetches.Load() == 2 }, 5*time.Second, time.Millisecond)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		token, err := p.Token(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "token-1", token.Value, "the valid token is used while the refresh is pending")
+
+		p.Invalidate()
+		_, err = p.Token(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Eventually(t, func() bool { return fetches.Load() == 3 }, 5*time.Second, time.Millisecond)
+		close(release)
+		require.Equal(t, "Bearer token-2", <-done)
+		header, err = authHeader(t, p)
+		require.NoError(t, err)
+		require.Equal(t, "Bearer token-3", header, "the fetch of a canceled request is not canceled and the invalidated fetch is not cached")
+		require.Equal(t, int32(3), fetches.Load())
+	})
+
+	t.Run("Test invalid options", func(t *testing.T) {
+		_, err := NewCallbackCredentialsProvider(nil)
+		require.Error(t, err)
+		fetch := func(ctx context.Context) (*Token, error) { return &Token{Value: "t"}, nil }
+		_, err = NewCallbackCredentialsProvider(fetch, WithTokenHeader("X-Other"))
+		require.Error(t, err)
+		_, err = NewCallbackCredentialsProvider(fetch, WithRefreshBefore(-time.Second))
+		require.Error(t, err)
+	})
+}
+
+func TestOAuth2ClientCredentialsProvider(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		require.NoError(t, r.ParseForm())
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "client" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		require.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+		require.Equal(t, "read write", r.Form.Get("scope"))
+		require.Equal(t, "chroma", r.Form.Get("audience"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"access_token":"access-%d","token_type":"Bearer","expires_in":3600}`, requests.Load())))
+	}))
+	defer server.Close()
+
+	config := OAuth2ClientCredentialsConfig{
+		TokenURL:       server.URL,
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		Scopes:         []string{"read", "write"},
+		EndpointParams: map[string][]string{"audience": {"chroma"}},
+	}
+	p, err := NewOAuth2ClientCredentialsProvider(config)
+	require.NoError(t, err)
+	token, err := p.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "access-1", token.Value)
+	require.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, 5*time.Second)
+	header, err := authHeader(t, p)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer access-1", header)
+	require.Equal(t, int32(1), requests.Load())
+
+	config.ClientSecret = "wrong"
+	p, err = NewOAuth2ClientCredentialsProvider(config)
+	require.NoError(t, err)
+	_, err = authHeader(t, p)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid_client")
+
+	_, err = NewOAuth2ClientCredentialsProvider(OAuth2ClientCredentialsConfig{ClientID: "client"})
+	require.Error(t, err)
+}
+
+func TestTokenFileCredentialsProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	now := time.Unix(1000, 0)
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+	p, err := NewTokenFileCredentialsProvider(path)
+	require.NoError(t, err)
+	p.now = func() time.Time { return now }
+	header, err := authHeader(t, p)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer first", header)
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o600))
+	header, _ = authHeader(t, p)
+	require.Equal(t, "Bearer first", header, "cached until the reload interval")
+	now = now.Add(DefaultTokenFileReloadInterval)
+	header, _ = authHeader(t, p)
+	require.Equal(t, "Bearer second", header)
+
+	now = now.Add(DefaultTokenFileReloadInterval)
+	require.NoError(t, os.WriteFile(path, []byte(jwtWithExpiry(now.Add(90*time.Second))), 0o600))
+	token, err := p.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, now.Add(90*time.Second).Unix(), token.Expiry.Unix(), "JWT expiry is earlier than the reload interval")
+
+	require.NoError(t, os.WriteFile(path, []byte(" "), 0o600))
+	p.Invalidate()
+	_, err = authHeader(t, p)
+	require.Error(t, err)
+
+	_, err = NewTokenFileCredentialsProvider("")
+	require.Error(t, err)
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Unix(1700000000, 0)
+	got, ok := JWTExpiry(jwtWithExpiry(exp))
+	require.True(t, ok)
+	require.Equal(t, exp, got)
+	_, ok = JWTExpiry("not-a-jwt")
+	require.False(t, ok)
+	_, ok = JWTExpiry("a." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"x"}`)) + ".c")
+	require.False(t, ok)
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// discardHandler drops all records.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
+
+var discardLogger = slog.New(discardHandler{})
+
+// DiscardLogger returns a logger that drops all records, used when no logger is configured.
+func DiscardLogger() *slog.Logger {
+	return discardLogger
+}
+
+// RequestLogger logs the provider requests of embedding functions and rerankers with consistent attributes. Embed it in the
+// client of a provider; the zero value logs nothing.
+type RequestLogger struct {
+	logger *slog.Logger
+}
+
+// SetLogger sets the logger, nil disables logging. It is meant to be called by the options of the provider client, before
+// the client is used.
+func (l *RequestLogger) SetLogger(logger *slog.Logger) {
+	l.logger = logger
+}
+
+// Logger returns the logger, or DiscardLogger if none is set.
+func (l *RequestLogger) Logger() *slog.Logger {
+	if l.logger != nil {
+		return l.logger
+	}
+	return discardLogger
+}
+
+// LogRequest logs a provider request that started at start with the provider, model, batch size (number of inputs) and
+// latency at debug level, or as a warning with the error if the request failed.
+func (l *RequestLogger) LogRequest(ctx context.Context, provider string, model string, batchSize int, start time.Time, err error) {
+	logger := l.Logger()
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("provider", provider),
+		slog.String("model", model),
+		slog.Int("batch_size", batchSize),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		logger.LogAttrs(ctx, level, "provider request failed", attrs...)
+		return
+	}
+	logger.LogAttrs(ctx, level, "provider request", attrs...)
+}
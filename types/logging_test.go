@@ -0,0 +1,45 @@
+//go:build basic
+
+package types
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger(t *testing.T) {
+	t.Run("Test zero value logs nothing", func(t *testing.T) {
+		var l RequestLogger
+		require.Same(t, DiscardLogger(), l.Logger())
+		require.False(t, l.Logger().Enabled(context.Background(), slog.LevelError))
+		l.LogRequest(context.Background(), "openai", "model", 1, time.Now(), nil)
+	})
+
+	t.Run("Test successful and failed requests", func(t *testing.T) {
+		var buf bytes.Buffer
+		var l RequestLogger
+		l.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+		l.LogRequest(context.Background(), "cohere", "embed-english-v3.0", 3, time.Now(), nil)
+		require.Contains(t, buf.String(), `level=DEBUG msg="provider request" provider=cohere model=embed-english-v3.0 batch_size=3 latency=`)
+		buf.Reset()
+		l.LogRequest(context.Background(), "cohere", "embed-english-v3.0", 3, time.Now(), fmt.Errorf("unexpected code 429"))
+		require.Contains(t, buf.String(), `level=WARN msg="provider request failed" provider=cohere`)
+		require.Contains(t, buf.String(), `error="unexpected code 429"`)
+	})
+
+	t.Run("Test level of the logger applies", func(t *testing.T) {
+		var buf bytes.Buffer
+		var l RequestLogger
+		l.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+		l.LogRequest(context.Background(), "jina", "model", 1, time.Now(), nil)
+		require.Empty(t, buf.String())
+		l.SetLogger(nil)
+		require.Same(t, DiscardLogger(), l.Logger())
+	})
+}
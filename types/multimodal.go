@@ -0,0 +1,107 @@
+package types
+
+import (
+	"context"
+	"fmt"
+)
+
+const IURIs QueryEnum = "uris"
+
+// DataLoader resolves URIs stored with records into the raw data they point to, e.g. image bytes.
+type DataLoader interface {
+	// LoadData returns the data of each URI, in the same order.
+	LoadData(ctx context.Context, uris []string) ([][]byte, error)
+}
+
+// ImageEmbeddingFunction is an EmbeddingFunction that can also embed images into the same vector space as texts.
+type ImageEmbeddingFunction interface {
+	EmbeddingFunction
+	// EmbedImages returns a vector for each encoded image (e.g. PNG or JPEG bytes).
+	EmbedImages(ctx context.Context, images [][]byte) ([]*Embedding, error)
+}
+
+// EmbedURIs loads the data of the URIs with the loader and embeds it with the embedding function, which must implement ImageEmbeddingFunction.
+func EmbedURIs(ctx context.Context, ef EmbeddingFunction, loader DataLoader, uris []string) ([]*Embedding, error) {
+	if len(uris) == 0 {
+		return []*Embedding{}, nil
+	}
+	if loader == nil {
+		return nil, fmt.Errorf("a data loader is required to embed uris")
+	}
+	imageEf, ok := ef.(ImageEmbeddingFunction)
+	if !ok {
+		return nil, fmt.Errorf("embedding function %T does not support images", ef)
+	}
+	data, err := loader.LoadData(ctx, uris)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != len(uris) {
+		return nil, fmt.Errorf("data loader returned %d items for %d uris", len(data), len(uris))
+	}
+	embeddings, err := imageEf.EmbedImages(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(uris) {
+		return nil, fmt.Errorf("embedding function returned %d embeddings for %d images", len(embeddings), len(uris))
+	}
+	return embeddings, nil
+}
+
+// EmbedDocumentsOrURIs embeds each item from its document or, if the document is empty, from the data its URI points to.
+func EmbedDocumentsOrURIs(ctx context.Context, ef EmbeddingFunction, loader DataLoader, documents []string, uris []string) ([]*Embedding, error) {
+	count := max(len(documents), len(uris))
+	if ef == nil {
+		return nil, fmt.Errorf("embedding function is not set")
+	}
+	var textIndices, uriIndices []int
+	var texts, uriValues []string
+	for i := 0; i < count; i++ {
+		switch {
+		case i < len(documents) && documents[i] != "":
+			textIndices = append(textIndices, i)
+			texts = append(texts, documents[i])
+		case i < len(uris) && uris[i] != "":
+			uriIndices = append(uriIndices, i)
+			uriValues = append(uriValues, uris[i])
+		default:
+			return nil, fmt.Errorf("document or uri must be provided for item %d", i)
+		}
+	}
+	if len(uriIndices) == 0 {
+		return ef.EmbedDocuments(ctx, documents)
+	}
+	embeddings := make([]*Embedding, count)
+	if len(texts) > 0 {
+		textEmbeddings, err := ef.EmbedDocuments(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range textIndices {
+			embeddings[i] = textEmbeddings[j]
+		}
+	}
+	uriEmbeddings, err := EmbedURIs(ctx, ef, loader, uriValues)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range uriIndices {
+		embeddings[i] = uriEmbeddings[j]
+	}
+	return embeddings, nil
+}
+
+// WithQueryURIs queries with the embeddings of the data the URIs point to. Requires a DataLoader on the collection and an
+// ImageEmbeddingFunction.
+func WithQueryURIs(uris ...string) CollectionQueryOption {
+	return func(c *CollectionQueryBuilder) error {
+		for _, uri := range uris {
+			if uri == "" {
+				return fmt.Errorf("uri must not be empty")
+			}
+		}
+		c.QueryURIs = append(c.QueryURIs, uris...)
+		return nil
+	}
+}
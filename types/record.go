@@ -94,8 +94,8 @@ func (r *Record) Validate() error {
 		return fmt.Errorf("id cannot be empty")
 	}
 
-	if !r.Embedding.IsDefined() && r.Document == "" {
-		return fmt.Errorf("document or embedding must be provided")
+	if !r.Embedding.IsDefined() && r.Document == "" && r.URI == "" {
+		return fmt.Errorf("document, uri or embedding must be provided")
 	}
 	return nil
 }
@@ -109,6 +109,14 @@ func WithIDGenerator(idGenerator IDGenerator) RecordSetOption {
 	}
 }
 
+// WithDataLoader sets the data loader used to embed records that only have a URI. The embedding function must implement ImageEmbeddingFunction.
+func WithDataLoader(loader DataLoader) RecordSetOption {
+	return func(p *RecordSet) error {
+		p.DataLoader = loader
+		return nil
+	}
+}
+
 // WithEmbeddingFunction sets the embedding function to be used for in place embedding.
 func WithEmbeddingFunction(embeddingFunction EmbeddingFunction) RecordSetOption {
 	return func(p *RecordSet) error {
@@ -121,6 +129,7 @@ type RecordSet struct {
 	Records           []*Record
 	IDGenerator       IDGenerator
 	EmbeddingFunction EmbeddingFunction
+	DataLoader        DataLoader
 }
 
 func NewRecordSet(opts ...RecordSetOption) (*RecordSet, error) {
@@ -218,6 +227,9 @@ func (rs *RecordSet) BuildAndValidate(ctx context.Context) ([]*Record, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := rs.embedURIRecords(ctx); err != nil {
+		return nil, err
+	}
 	err = rs.EmbeddingFunction.EmbedRecords(ctx, rs.Records, false)
 
 	if err != nil {
@@ -229,3 +241,23 @@ func (rs *RecordSet) BuildAndValidate(ctx context.Context) ([]*Record, error) {
 	}
 	return rs.Records, nil
 }
+
+// embedURIRecords embeds the records that have a URI but neither a document nor an embedding.
+func (rs *RecordSet) embedURIRecords(ctx context.Context) error {
+	var records []*Record
+	var uris []string
+	for _, record := range rs.Records {
+		if record.URI != "" && record.Document == "" && !record.Embedding.IsDefined() {
+			records = append(records, record)
+			uris = append(uris, record.URI)
+		}
+	}
+	embeddings, err := EmbedURIs(ctx, rs.EmbeddingFunction, rs.DataLoader, uris)
+	if err != nil {
+		return err
+	}
+	for i, record := range records {
+		record.Embedding = *embeddings[i]
+	}
+	return nil
+}
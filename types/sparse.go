@@ -0,0 +1,248 @@
+package types
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SparseEmbedding is a sparse vector stored as parallel slices of dimension indices and their values. Indices are kept
+// sorted in ascending order and are unique.
+type SparseEmbedding struct {
+	Indices []int     `json:"indices"`
+	Values  []float32 `json:"values"`
+}
+
+// SparseEmbeddingFunction produces sparse embeddings, e.g. term weights of a BM25 or SPLADE encoder. Documents and queries are
+// encoded differently by most sparse models, so they have separate methods.
+type SparseEmbeddingFunction interface {
+	EmbedDocumentsSparse(ctx context.Context, documents []string) ([]*SparseEmbedding, error)
+	EmbedQuerySparse(ctx context.Context, query string) (*SparseEmbedding, error)
+}
+
+// NewSparseEmbedding creates a sparse embedding, sorting the entries by index. Returns an error if the slices have different
+// lengths or an index is negative or duplicated.
+func NewSparseEmbedding(indices []int, values []float32) (*SparseEmbedding, error) {
+	if len(indices) != len(values) {
+		return nil, fmt.Errorf("indices and values must have the same length, got %d and %d", len(indices), len(values))
+	}
+	e := &SparseEmbedding{Indices: append([]int{}, indices...), Values: append([]float32{}, values...)}
+	sort.Sort(sparseEntries{e})
+	for i, index := range e.Indices {
+		if index < 0 {
+			return nil, fmt.Errorf("sparse index must not be negative, got %d", index)
+		}
+		if i > 0 && e.Indices[i-1] == index {
+			return nil, fmt.Errorf("duplicate sparse index %d", index)
+		}
+	}
+	return e, nil
+}
+
+// NewSparseEmbeddingFromMap creates a sparse embedding from an index to value map.
+func NewSparseEmbeddingFromMap(m map[int]float32) (*SparseEmbedding, error) {
+	indices := make([]int, 0, len(m))
+	values := make([]float32, 0, len(m))
+	for index, value := range m {
+		indices = append(indices, index)
+		values = append(values, value)
+	}
+	return NewSparseEmbedding(indices, values)
+}
+
+// UnmarshalJSON validates and sorts the entries.
+func (e *SparseEmbedding) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Indices []int     `json:"indices"`
+		Values  []float32 `json:"values"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := NewSparseEmbedding(raw.Indices, raw.Values)
+	if err != nil {
+		return err
+	}
+	*e = *parsed
+	return nil
+}
+
+// Len returns the number of non-zero entries.
+func (e *SparseEmbedding) Len() int {
+	return len(e.Indices)
+}
+
+// Get returns the value at index, or 0 if the entry is not set.
+func (e *SparseEmbedding) Get(index int) float32 {
+	i := sort.SearchInts(e.Indices, index)
+	if i < len(e.Indices) && e.Indices[i] == index {
+		return e.Values[i]
+	}
+	return 0
+}
+
+// Dot returns the dot product of the two sparse embeddings.
+func (e *SparseEmbedding) Dot(other *SparseEmbedding) float64 {
+	var sum float64
+	i, j := 0, 0
+	for i < len(e.Indices) && j < len(other.Indices) {
+		switch {
+		case e.Indices[i] < other.Indices[j]:
+			i++
+		case e.Indices[i] > other.Indices[j]:
+			j++
+		default:
+			sum += float64(e.Values[i]) * float64(other.Values[j])
+			i++
+			j++
+		}
+	}
+	return sum
+}
+
+// Norm returns the L2 norm of the embedding.
+func (e *SparseEmbedding) Norm() float64 {
+	var sum float64
+	for _, v := range e.Values {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum)
+}
+
+// Normalize returns a copy of the embedding scaled to unit L2 norm.
+func (e *SparseEmbedding) Normalize() (*SparseEmbedding, error) {
+	norm := e.Norm()
+	if norm == 0 {
+		return nil, fmt.Errorf("cannot normalize a zero vector")
+	}
+	values := make([]float32, len(e.Values))
+	for i, v := range e.Values {
+		values[i] = float32(float64(v) / norm)
+	}
+	return &SparseEmbedding{Indices: append([]int{}, e.Indices...), Values: values}, nil
+}
+
+// ToDense converts the embedding to a dense embedding of the given dimension.
+func (e *SparseEmbedding) ToDense(dimension int) (*Embedding, error) {
+	dense := make([]float32, dimension)
+	for i, index := range e.Indices {
+		if index >= dimension {
+			return nil, fmt.Errorf("sparse index %d is out of range for dimension %d", index, dimension)
+		}
+		dense[index] = e.Values[i]
+	}
+	return NewEmbeddingFromFloat32(dense), nil
+}
+
+// ScoredNeighbor is a search result of a SparseIndex. Higher scores are better.
+type ScoredNeighbor struct {
+	Index int // index of the embedding in the indexed slice
+	Score float64
+}
+
+// SparseIndex is an in-memory inverted index that scores sparse queries against sparse documents by dot product.
+type SparseIndex struct {
+	postings map[int][]posting
+	size     int
+}
+
+type posting struct {
+	doc   int
+	value float32
+}
+
+func NewSparseIndex(embeddings []*SparseEmbedding) (*SparseIndex, error) {
+	index := &SparseIndex{postings: make(map[int][]posting)}
+	for _, e := range embeddings {
+		if err := index.Add(e); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
+
+// Add appends an embedding to the index. Its position is the number of embeddings added before it.
+func (s *SparseIndex) Add(embedding *SparseEmbedding) error {
+	if embedding == nil {
+		return &EmptyEmbeddingError{}
+	}
+	for i, index := range embedding.Indices {
+		s.postings[index] = append(s.postings[index], posting{doc: s.size, value: embedding.Values[i]})
+	}
+	s.size++
+	return nil
+}
+
+// Len returns the number of indexed embeddings.
+func (s *SparseIndex) Len() int {
+	return s.size
+}
+
+// Scores returns the dot product of the query with every indexed embedding, in index order.
+func (s *SparseIndex) Scores(query *SparseEmbedding) []float64 {
+	scores := make([]float64, s.size)
+	for i, index := range query.Indices {
+		q := float64(query.Values[i])
+		for _, p := range s.postings[index] {
+			scores[p.doc] += q * float64(p.value)
+		}
+	}
+	return scores
+}
+
+// Search returns the k embeddings with the highest dot product with the query, best first. Embeddings that share no entries with
+// the query are not returned.
+func (s *SparseIndex) Search(query *SparseEmbedding, k int) ([]ScoredNeighbor, error) {
+	if query == nil {
+		return nil, &EmptyEmbeddingError{}
+	}
+	if k < 1 {
+		return nil, fmt.Errorf("k must be greater than 0")
+	}
+	matched := make(map[int]bool)
+	for _, index := range query.Indices {
+		for _, p := range s.postings[index] {
+			matched[p.doc] = true
+		}
+	}
+	scores := s.Scores(query)
+	// reuse the distance heap with negated scores
+	h := &neighborHeap{}
+	for doc := range matched {
+		d := -scores[doc]
+		if h.Len() < k {
+			heap.Push(h, Neighbor{Index: doc, Distance: d})
+		} else if d < (*h)[0].Distance || (d == (*h)[0].Distance && doc < (*h)[0].Index) {
+			(*h)[0] = Neighbor{Index: doc, Distance: d}
+			heap.Fix(h, 0)
+		}
+	}
+	neighbors := make([]ScoredNeighbor, h.Len())
+	for i := len(neighbors) - 1; i >= 0; i-- {
+		n := heap.Pop(h).(Neighbor)
+		neighbors[i] = ScoredNeighbor{Index: n.Index, Score: -n.Distance}
+	}
+	return neighbors, nil
+}
+
+// SparseTopK returns the k embeddings with the highest dot product with the query.
+func SparseTopK(query *SparseEmbedding, embeddings []*SparseEmbedding, k int) ([]ScoredNeighbor, error) {
+	index, err := NewSparseIndex(embeddings)
+	if err != nil {
+		return nil, err
+	}
+	return index.Search(query, k)
+}
+
+// sparseEntries sorts the indices and values of a sparse embedding together.
+type sparseEntries struct{ e *SparseEmbedding }
+
+func (s sparseEntries) Len() int           { return len(s.e.Indices) }
+func (s sparseEntries) Less(i, j int) bool { return s.e.Indices[i] < s.e.Indices[j] }
+func (s sparseEntries) Swap(i, j int) {
+	s.e.Indices[i], s.e.Indices[j] = s.e.Indices[j], s.e.Indices[i]
+	s.e.Values[i], s.e.Values[j] = s.e.Values[j], s.e.Values[i]
+}
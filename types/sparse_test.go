@@ -0,0 +1,82 @@
+//go:build basic
+
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparseEmbedding(t *testing.T) {
+	t.Run("Test create sorts entries", func(t *testing.T) {
+		e, err := NewSparseEmbedding([]int{5, 1, 3}, []float32{0.5, 0.1, 0.3})
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 3, 5}, e.Indices)
+		require.Equal(t, []float32{0.1, 0.3, 0.5}, e.Values)
+		require.Equal(t, float32(0.3), e.Get(3))
+		require.Equal(t, float32(0), e.Get(4))
+		require.Equal(t, 3, e.Len())
+	})
+
+	t.Run("Test invalid entries", func(t *testing.T) {
+		_, err := NewSparseEmbedding([]int{1, 2}, []float32{1})
+		require.Error(t, err)
+		_, err = NewSparseEmbedding([]int{1, 1}, []float32{1, 2})
+		require.Error(t, err)
+		_, err = NewSparseEmbedding([]int{-1}, []float32{1})
+		require.Error(t, err)
+		var e SparseEmbedding
+		require.Error(t, json.Unmarshal([]byte(`{"indices":[2,2],"values":[1,1]}`), &e))
+	})
+
+	t.Run("Test math", func(t *testing.T) {
+		a, _ := NewSparseEmbeddingFromMap(map[int]float32{0: 1, 2: 2, 7: 3})
+		b, _ := NewSparseEmbeddingFromMap(map[int]float32{2: 4, 3: 1, 7: 1})
+		require.InDelta(t, 11, a.Dot(b), 1e-6)
+		require.InDelta(t, 11, b.Dot(a), 1e-6)
+		n, err := b.Normalize()
+		require.NoError(t, err)
+		require.InDelta(t, 1, n.Norm(), 1e-6)
+		_, err = (&SparseEmbedding{}).Normalize()
+		require.Error(t, err)
+		dense, err := a.ToDense(8)
+		require.NoError(t, err)
+		require.Equal(t, []float32{1, 0, 2, 0, 0, 0, 0, 3}, dense.ToFloat32())
+		_, err = a.ToDense(5)
+		require.Error(t, err)
+	})
+
+	t.Run("Test JSON round trip", func(t *testing.T) {
+		a, _ := NewSparseEmbedding([]int{4, 2}, []float32{1, 2})
+		data, err := json.Marshal(a)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"indices":[2,4],"values":[2,1]}`, string(data))
+		var b SparseEmbedding
+		require.NoError(t, json.Unmarshal(data, &b))
+		require.Equal(t, *a, b)
+	})
+
+	t.Run("Test sparse search", func(t *testing.T) {
+		docs := make([]*SparseEmbedding, 0)
+		for _, m := range []map[int]float32{{0: 1}, {1: 2, 2: 1}, {2: 3}, {3: 1}, {1: 1}} {
+			e, err := NewSparseEmbeddingFromMap(m)
+			require.NoError(t, err)
+			docs = append(docs, e)
+		}
+		query, _ := NewSparseEmbeddingFromMap(map[int]float32{1: 1, 2: 1})
+		index, err := NewSparseIndex(docs)
+		require.NoError(t, err)
+		require.Equal(t, 5, index.Len())
+		require.Equal(t, []float64{0, 3, 3, 0, 1}, index.Scores(query))
+		neighbors, err := index.Search(query, 10)
+		require.NoError(t, err)
+		require.Equal(t, []ScoredNeighbor{{Index: 1, Score: 3}, {Index: 2, Score: 3}, {Index: 4, Score: 1}}, neighbors)
+		neighbors, err = SparseTopK(query, docs, 1)
+		require.NoError(t, err)
+		require.Equal(t, []ScoredNeighbor{{Index: 1, Score: 3}}, neighbors)
+		_, err = index.Search(query, 0)
+		require.Error(t, err)
+	})
+}
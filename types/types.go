@@ -5,9 +5,11 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/rand"
+	"net/http"
 	"strconv"
 	"strings"
 	time "time"
@@ -43,6 +45,7 @@ const (
 	HNSWSearchEF                        = "hnsw:search_ef"
 	HNSWNumThreads                      = "hnsw:num_threads"
 	HNSWResizeFactor                    = "hnsw:resize_factor"
+	EmbeddingDimension                  = "embedding_dimension"
 	DefaultTimeout                      = 30 * time.Second
 	EmbeddingsEpsilon                   = 1e-6
 )
@@ -167,6 +170,32 @@ func (e *Embedding) String() string {
 	}
 	return ""
 }
+
+// MarshalJSON serializes the embedding as a plain array of numbers or null if the embedding is not set. It has a value receiver,
+// so embeddings held by value are encoded the same way.
+func (e Embedding) MarshalJSON() ([]byte, error) {
+	switch {
+	case e.ArrayOfFloat32 != nil && (len(*e.ArrayOfFloat32) > 0 || e.ArrayOfInt32 == nil):
+		return json.Marshal(*e.ArrayOfFloat32)
+	case e.ArrayOfInt32 != nil:
+		return json.Marshal(*e.ArrayOfInt32)
+	default:
+		return []byte("null"), nil
+	}
+}
+
+// UnmarshalJSON decodes an embedding encoded by MarshalJSON. Numbers are decoded as float32, JSON does not distinguish
+// integer embeddings.
+func (e *Embedding) UnmarshalJSON(data []byte) error {
+	var values *[]float32
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	e.ArrayOfFloat32 = values
+	e.ArrayOfInt32 = nil
+	return nil
+}
+
 func (e *Embedding) GetFloat32() *[]float32 {
 	return e.ArrayOfFloat32
 }
@@ -408,6 +437,7 @@ func NewConsistentHashEmbeddingFunction() EmbeddingFunction {
 type CollectionQueryBuilder struct {
 	QueryTexts      []string
 	QueryEmbeddings []*Embedding
+	QueryURIs       []string
 	Where           map[string]interface{}
 	WhereDocument   map[string]interface{}
 	NResults        int32
@@ -415,6 +445,8 @@ type CollectionQueryBuilder struct {
 	Offset          int32
 	Limit           int32
 	Ids             []string
+	MMRFetchK       int32   // number of candidates to fetch for maximal marginal relevance, 0 disables MMR
+	MMRLambda       float32 // trade-off between relevance (1) and diversity (0)
 }
 
 type CollectionQueryOption func(*CollectionQueryBuilder) error
@@ -508,6 +540,22 @@ func WithQueryEmbedding(queryEmbedding *Embedding) CollectionQueryOption {
 	}
 }
 
+// WithMMR enables maximal marginal relevance selection for queries. fetchK candidates are fetched (including their embeddings) and NResults
+// diverse results are selected client-side. lambda trades off relevance (1) against diversity (0).
+func WithMMR(fetchK int32, lambda float32) CollectionQueryOption {
+	return func(c *CollectionQueryBuilder) error {
+		if fetchK < 1 {
+			return fmt.Errorf("fetchK must be greater than 0")
+		}
+		if lambda < 0 || lambda > 1 {
+			return fmt.Errorf("lambda must be between 0 and 1")
+		}
+		c.MMRFetchK = fetchK
+		c.MMRLambda = lambda
+		return nil
+	}
+}
+
 func WithInclude(include ...QueryEnum) CollectionQueryOption {
 	return func(c *CollectionQueryBuilder) error {
 		c.Include = include
@@ -542,6 +590,8 @@ func WithIds(ids []string) CollectionQueryOption {
 	}
 }
 
+// CredentialsProvider sets credentials on the client configuration. Providers that also implement RequestAuthenticator are
+// applied to every request instead, so that the credentials can change over the lifetime of the client.
 type CredentialsProvider interface {
 	Authenticate(apiClient *openapi.Configuration) error
 }
@@ -559,12 +609,20 @@ func NewBasicAuthCredentialsProvider(username, password string) *BasicAuthCreden
 }
 
 func (b *BasicAuthCredentialsProvider) Authenticate(config *openapi.Configuration) error {
-	auth := b.Username + ":" + b.Password
-	encodedAuth := base64.StdEncoding.EncodeToString([]byte(auth))
-	config.DefaultHeader["Authorization"] = "Basic " + encodedAuth
+	config.DefaultHeader["Authorization"] = b.header()
+	return nil
+}
+
+func (b *BasicAuthCredentialsProvider) AuthenticateRequest(req *http.Request) error {
+	req.Header.Set("Authorization", b.header())
 	return nil
 }
 
+func (b *BasicAuthCredentialsProvider) header() string {
+	auth := b.Username + ":" + b.Password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+}
+
 type TokenTransportHeader string
 
 const (
@@ -585,14 +643,31 @@ func NewTokenAuthCredentialsProvider(token string, header TokenTransportHeader)
 }
 
 func (t *TokenAuthCredentialsProvider) Authenticate(config *openapi.Configuration) error {
-	switch t.Header {
+	value, err := t.Header.value(t.Token)
+	if err != nil {
+		return err
+	}
+	config.DefaultHeader[string(t.Header)] = value
+	return nil
+}
+
+func (t *TokenAuthCredentialsProvider) AuthenticateRequest(req *http.Request) error {
+	value, err := t.Header.value(t.Token)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(string(t.Header), value)
+	return nil
+}
+
+// value returns the header value for the token, with the Bearer prefix for the Authorization header.
+func (h TokenTransportHeader) value(token string) (string, error) {
+	switch h {
 	case AuthorizationTokenHeader:
-		config.DefaultHeader[string(t.Header)] = "Bearer " + t.Token
-		return nil
+		return "Bearer " + token, nil
 	case XChromaTokenHeader:
-		config.DefaultHeader[string(t.Header)] = t.Token
-		return nil
+		return token, nil
 	default:
-		return fmt.Errorf("unsupported token header: %v", t.Header)
+		return "", fmt.Errorf("unsupported token header: %v", h)
 	}
 }
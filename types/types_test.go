@@ -4,6 +4,7 @@ package types
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/stretchr/testify/require"
 	"reflect"
 	"testing"
@@ -386,6 +387,56 @@ func TestEmbedding_GetInt32(t *testing.T) {
 	}
 }
 
+func TestEmbedding_JSON(t *testing.T) {
+	t.Run("Test round trip", func(t *testing.T) {
+		data, err := json.Marshal(NewEmbeddingFromFloat32([]float32{0.5, -1.25, 3}))
+		require.NoError(t, err)
+		require.JSONEq(t, `[0.5,-1.25,3]`, string(data))
+		var decoded Embedding
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, []float32{0.5, -1.25, 3}, *decoded.GetFloat32())
+		require.Nil(t, decoded.GetInt32())
+	})
+
+	t.Run("Test integer embeddings decode as float32", func(t *testing.T) {
+		data, err := json.Marshal(NewEmbeddingFromInt32([]int32{1, 2}))
+		require.NoError(t, err)
+		var decoded *Embedding
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, []float32{1, 2}, *decoded.GetFloat32())
+	})
+
+	t.Run("Test embeddings held by value", func(t *testing.T) {
+		type holder struct {
+			Embedding Embedding `json:"embedding"`
+		}
+		embedding := *NewEmbeddingFromFloat32([]float32{1, 2})
+		for _, value := range []any{holder{Embedding: embedding}, map[string]Embedding{"embedding": embedding}, embedding} {
+			data, err := json.Marshal(value)
+			require.NoError(t, err)
+			require.NotContains(t, string(data), "ArrayOfFloat32")
+			require.Contains(t, string(data), `[1,2]`)
+		}
+		data, err := json.Marshal(holder{Embedding: embedding})
+		require.NoError(t, err)
+		var decoded holder
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, []float32{1, 2}, *decoded.Embedding.GetFloat32())
+	})
+
+	t.Run("Test null and slices of embeddings", func(t *testing.T) {
+		var decoded []*Embedding
+		require.NoError(t, json.Unmarshal([]byte(`[[1,2],null]`), &decoded))
+		require.Len(t, decoded, 2)
+		require.Equal(t, []float32{1, 2}, *decoded[0].GetFloat32())
+		require.Nil(t, decoded[1])
+		var empty Embedding
+		require.NoError(t, json.Unmarshal([]byte(`null`), &empty))
+		require.False(t, empty.IsDefined())
+		require.Error(t, json.Unmarshal([]byte(`"text"`), &empty))
+	})
+}
+
 func TestEmbedding_IsDefined(t *testing.T) {
 	type fields struct {
 		ArrayOfFloat32 *[]float32
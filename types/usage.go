@@ -0,0 +1,148 @@
+package types
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage is the token usage of one or more embedding requests.
+type Usage struct {
+	Provider     string `json:"provider,omitempty"`
+	Model        string `json:"model,omitempty"`
+	PromptTokens int    `json:"prompt_tokens"`
+	TotalTokens  int    `json:"total_tokens"`
+	Requests     int    `json:"requests"`
+}
+
+// Add returns the sum of the usages. Provider and model are kept if they are the same in both.
+func (u Usage) Add(other Usage) Usage {
+	sum := Usage{
+		PromptTokens: u.PromptTokens + other.PromptTokens,
+		TotalTokens:  u.TotalTokens + other.TotalTokens,
+		Requests:     u.Requests + other.Requests,
+	}
+	if u.Requests == 0 {
+		sum.Provider, sum.Model = other.Provider, other.Model
+	} else if other.Requests == 0 {
+		sum.Provider, sum.Model = u.Provider, u.Model
+	} else {
+		if u.Provider == other.Provider {
+			sum.Provider = u.Provider
+		}
+		if u.Model == other.Model {
+			sum.Model = u.Model
+		}
+	}
+	return sum
+}
+
+// NewUsageFromMap returns the usage of a single request from a decoded OpenAI-style usage object with prompt_tokens and
+// total_tokens keys. Missing keys are reported as 0 tokens.
+func NewUsageFromMap(provider string, model string, usage map[string]any) Usage {
+	u := Usage{Provider: provider, Model: model, Requests: 1}
+	if v, ok := usage["prompt_tokens"].(float64); ok {
+		u.PromptTokens = int(v)
+	}
+	if v, ok := usage["total_tokens"].(float64); ok {
+		u.TotalTokens = int(v)
+	}
+	return u
+}
+
+// UsageReporter is implemented by embedding functions that report the token usage of their provider.
+type UsageReporter interface {
+	// TotalUsage returns the usage aggregated over all requests made by the embedding function.
+	TotalUsage() Usage
+}
+
+// UsageTracker aggregates the usage of an embedding function and forwards each request's usage to the UsageCollector
+// of the request context, if any. Embed it in an embedding function to implement UsageReporter.
+type UsageTracker struct {
+	mu    sync.Mutex
+	total Usage
+}
+
+// TotalUsage returns the usage aggregated over all recorded requests.
+func (t *UsageTracker) TotalUsage() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+// RecordUsage adds the usage of a request to the tracker and to the collector of the context.
+func (t *UsageTracker) RecordUsage(ctx context.Context, usage Usage) {
+	t.mu.Lock()
+	t.total = t.total.Add(usage)
+	t.mu.Unlock()
+	if collector := UsageCollectorFromContext(ctx); collector != nil {
+		collector.Record(usage)
+	}
+}
+
+// UsageCollector collects the usage of all embedding requests made with a context, e.g. to attribute embedding cost to a tenant.
+// It is safe for concurrent use.
+type UsageCollector struct {
+	mu      sync.Mutex
+	records []Usage
+}
+
+func NewUsageCollector() *UsageCollector {
+	return &UsageCollector{}
+}
+
+// Record adds the usage of a request.
+func (c *UsageCollector) Record(usage Usage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.records = append(c.records, usage)
+}
+
+// Records returns the usage of each recorded request in the order they were recorded.
+func (c *UsageCollector) Records() []Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Usage{}, c.records...)
+}
+
+// Total returns the usage aggregated over all recorded requests.
+func (c *UsageCollector) Total() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total Usage
+	for _, u := range c.records {
+		total = total.Add(u)
+	}
+	return total
+}
+
+// ByModel returns the usage aggregated per provider and model, keyed by "provider/model".
+func (c *UsageCollector) ByModel() map[string]Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	byModel := make(map[string]Usage)
+	for _, u := range c.records {
+		key := u.Provider + "/" + u.Model
+		byModel[key] = byModel[key].Add(u)
+	}
+	return byModel
+}
+
+// Reset removes all recorded usage.
+func (c *UsageCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.records = nil
+}
+
+type usageCollectorKey struct{}
+
+// ContextWithUsageCollector returns a context that makes embedding functions record their usage in the collector.
+func ContextWithUsageCollector(ctx context.Context, collector *UsageCollector) context.Context {
+	return context.WithValue(ctx, usageCollectorKey{}, collector)
+}
+
+// UsageCollectorFromContext returns the usage collector of the context, or nil if there is none.
+func UsageCollectorFromContext(ctx context.Context) *UsageCollector {
+	collector, _ := ctx.Value(usageCollectorKey{}).(*UsageCollector)
+	return collector
+}
@@ -0,0 +1,57 @@
+//go:build basic
+
+package types
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsage(t *testing.T) {
+	t.Run("Test add keeps common provider and model", func(t *testing.T) {
+		a := Usage{Provider: "openai", Model: "m1", PromptTokens: 3, TotalTokens: 3, Requests: 1}
+		b := Usage{Provider: "openai", Model: "m2", PromptTokens: 5, TotalTokens: 6, Requests: 2}
+		require.Equal(t, Usage{Provider: "openai", PromptTokens: 8, TotalTokens: 9, Requests: 3}, a.Add(b))
+		require.Equal(t, a, Usage{}.Add(a))
+		require.Equal(t, a, a.Add(Usage{}))
+	})
+
+	t.Run("Test usage from map", func(t *testing.T) {
+		u := NewUsageFromMap("mistral", "mistral-embed", map[string]any{"prompt_tokens": float64(7), "total_tokens": float64(7)})
+		require.Equal(t, Usage{Provider: "mistral", Model: "mistral-embed", PromptTokens: 7, TotalTokens: 7, Requests: 1}, u)
+		require.Equal(t, Usage{Provider: "x", Requests: 1}, NewUsageFromMap("x", "", nil))
+	})
+
+	t.Run("Test tracker forwards to context collector", func(t *testing.T) {
+		var tracker UsageTracker
+		collector := NewUsageCollector()
+		ctx := ContextWithUsageCollector(context.Background(), collector)
+		require.Same(t, collector, UsageCollectorFromContext(ctx))
+		require.Nil(t, UsageCollectorFromContext(context.Background()))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tracker.RecordUsage(ctx, Usage{Provider: "openai", Model: "small", PromptTokens: 2, TotalTokens: 2, Requests: 1})
+			}()
+		}
+		wg.Wait()
+		tracker.RecordUsage(ctx, Usage{Provider: "cohere", Model: "embed", PromptTokens: 1, TotalTokens: 1, Requests: 1})
+		tracker.RecordUsage(context.Background(), Usage{Provider: "cohere", Model: "embed", PromptTokens: 100, TotalTokens: 100, Requests: 1})
+
+		require.Equal(t, Usage{PromptTokens: 121, TotalTokens: 121, Requests: 12}, tracker.TotalUsage())
+		require.Len(t, collector.Records(), 11)
+		require.Equal(t, Usage{PromptTokens: 21, TotalTokens: 21, Requests: 11}, collector.Total())
+		require.Equal(t, map[string]Usage{
+			"openai/small": {Provider: "openai", Model: "small", PromptTokens: 20, TotalTokens: 20, Requests: 10},
+			"cohere/embed": {Provider: "cohere", Model: "embed", PromptTokens: 1, TotalTokens: 1, Requests: 1},
+		}, collector.ByModel())
+		collector.Reset()
+		require.Empty(t, collector.Records())
+	})
+}
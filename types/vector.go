@@ -0,0 +1,340 @@
+package types
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+type DimensionMismatchError struct {
+	Expected int
+	Actual   int
+}
+
+func (e *DimensionMismatchError) Error() string {
+	return fmt.Sprintf("embedding dimension mismatch: expected %d, got %d", e.Expected, e.Actual)
+}
+
+type EmptyEmbeddingError struct{}
+
+func (e *EmptyEmbeddingError) Error() string {
+	return "embedding must not be nil or empty"
+}
+
+// ToFloat32 returns the embedding values as float32, converting int32 embeddings. Returns nil if the embedding is not defined.
+func (e *Embedding) ToFloat32() []float32 {
+	if e.ArrayOfFloat32 != nil && (len(*e.ArrayOfFloat32) > 0 || e.ArrayOfInt32 == nil) {
+		return *e.ArrayOfFloat32
+	}
+	if e.ArrayOfInt32 == nil {
+		return nil
+	}
+	out := make([]float32, len(*e.ArrayOfInt32))
+	for i, v := range *e.ArrayOfInt32 {
+		out[i] = float32(v)
+	}
+	return out
+}
+
+// isInt32 reports whether the values of the embedding are stored as int32.
+func (e *Embedding) isInt32() bool {
+	return e.ArrayOfInt32 != nil && len(*e.ArrayOfInt32) > 0 && (e.ArrayOfFloat32 == nil || len(*e.ArrayOfFloat32) == 0)
+}
+
+// CheckDimensions returns a DimensionMismatchError if the embeddings do not all have the same dimension and an EmptyEmbeddingError if any
+// of them is not defined.
+func CheckDimensions(embeddings ...*Embedding) error {
+	dim := -1
+	for _, e := range embeddings {
+		if e == nil || !e.IsDefined() {
+			return &EmptyEmbeddingError{}
+		}
+		if dim == -1 {
+			dim = e.Len()
+		} else if e.Len() != dim {
+			return &DimensionMismatchError{Expected: dim, Actual: e.Len()}
+		}
+	}
+	return nil
+}
+
+// DotFloat32 returns the dot product of a and b, which must have the same length.
+func DotFloat32(a, b []float32) float32 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// DotInt32 returns the dot product of a and b, which must have the same length.
+func DotInt32(a, b []int32) int64 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 int64
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += int64(a[i]) * int64(b[i])
+		s1 += int64(a[i+1]) * int64(b[i+1])
+		s2 += int64(a[i+2]) * int64(b[i+2])
+		s3 += int64(a[i+3]) * int64(b[i+3])
+	}
+	for ; i < len(a); i++ {
+		s0 += int64(a[i]) * int64(b[i])
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// squaredL2Float32 returns the squared euclidean distance of a and b, which must have the same length.
+func squaredL2Float32(a, b []float32) float32 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		d0, d1, d2, d3 := a[i]-b[i], a[i+1]-b[i+1], a[i+2]-b[i+2], a[i+3]-b[i+3]
+		s0 += d0 * d0
+		s1 += d1 * d1
+		s2 += d2 * d2
+		s3 += d3 * d3
+	}
+	for ; i < len(a); i++ {
+		d := a[i] - b[i]
+		s0 += d * d
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// Dot returns the dot product of the two embeddings. int32 embeddings are multiplied without conversion to float.
+func (e *Embedding) Dot(other *Embedding) (float64, error) {
+	if err := CheckDimensions(e, other); err != nil {
+		return 0, err
+	}
+	if e.isInt32() && other.isInt32() {
+		return float64(DotInt32(*e.ArrayOfInt32, *other.ArrayOfInt32)), nil
+	}
+	return float64(DotFloat32(e.ToFloat32(), other.ToFloat32())), nil
+}
+
+// Norm returns the euclidean (L2) norm of the embedding.
+func (e *Embedding) Norm() float64 {
+	if e.isInt32() {
+		return math.Sqrt(float64(DotInt32(*e.ArrayOfInt32, *e.ArrayOfInt32)))
+	}
+	v := e.ToFloat32()
+	return math.Sqrt(float64(DotFloat32(v, v)))
+}
+
+// Normalize returns a new float32 embedding with unit L2 norm.
+func (e *Embedding) Normalize() (*Embedding, error) {
+	if e == nil || !e.IsDefined() {
+		return nil, &EmptyEmbeddingError{}
+	}
+	norm := e.Norm()
+	if norm == 0 {
+		return nil, fmt.Errorf("cannot normalize a zero vector")
+	}
+	v := e.ToFloat32()
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return NewEmbeddingFromFloat32(out), nil
+}
+
+// L2Distance returns the squared euclidean distance, matching Chroma's l2 space.
+func (e *Embedding) L2Distance(other *Embedding) (float64, error) {
+	if err := CheckDimensions(e, other); err != nil {
+		return 0, err
+	}
+	return float64(squaredL2Float32(e.ToFloat32(), other.ToFloat32())), nil
+}
+
+// CosineDistance returns 1 - cosine similarity, matching Chroma's cosine space. Returns 1 if either embedding is a zero vector.
+func (e *Embedding) CosineDistance(other *Embedding) (float64, error) {
+	dot, err := e.Dot(other)
+	if err != nil {
+		return 0, err
+	}
+	norms := e.Norm() * other.Norm()
+	if norms == 0 {
+		return 1, nil
+	}
+	return 1 - dot/norms, nil
+}
+
+// IPDistance returns 1 - dot product, matching Chroma's ip space.
+func (e *Embedding) IPDistance(other *Embedding) (float64, error) {
+	dot, err := e.Dot(other)
+	if err != nil {
+		return 0, err
+	}
+	return 1 - dot, nil
+}
+
+// Distance returns the distance between the embeddings using the given distance function.
+func (e *Embedding) Distance(other *Embedding, distanceFunction DistanceFunction) (float64, error) {
+	switch distanceFunction {
+	case L2:
+		return e.L2Distance(other)
+	case COSINE:
+		return e.CosineDistance(other)
+	case IP:
+		return e.IPDistance(other)
+	default:
+		return 0, fmt.Errorf("invalid distance function: %s", distanceFunction)
+	}
+}
+
+// MeanEmbedding returns the element-wise mean (centroid) of the embeddings as a float32 embedding.
+func MeanEmbedding(embeddings []*Embedding) (*Embedding, error) {
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("at least one embedding is required")
+	}
+	if err := CheckDimensions(embeddings...); err != nil {
+		return nil, err
+	}
+	sum := make([]float64, embeddings[0].Len())
+	for _, e := range embeddings {
+		for i, v := range e.ToFloat32() {
+			sum[i] += float64(v)
+		}
+	}
+	mean := make([]float32, len(sum))
+	for i, v := range sum {
+		mean[i] = float32(v / float64(len(embeddings)))
+	}
+	return NewEmbeddingFromFloat32(mean), nil
+}
+
+// Neighbor is a search result of a FlatIndex.
+type Neighbor struct {
+	Index    int // index of the embedding in the searched slice
+	Distance float64
+}
+
+// FlatIndex is a brute-force index that stores embeddings in a single contiguous float32 matrix for cache and SIMD friendly distance
+// computations.
+type FlatIndex struct {
+	distanceFunction DistanceFunction
+	dim              int
+	data             []float32
+	norms            []float32
+}
+
+func NewFlatIndex(distanceFunction DistanceFunction, embeddings []*Embedding) (*FlatIndex, error) {
+	if distanceFunction != L2 && distanceFunction != COSINE && distanceFunction != IP {
+		return nil, fmt.Errorf("invalid distance function: %s", distanceFunction)
+	}
+	if err := CheckDimensions(embeddings...); err != nil {
+		return nil, err
+	}
+	index := &FlatIndex{distanceFunction: distanceFunction}
+	if len(embeddings) == 0 {
+		return index, nil
+	}
+	index.dim = embeddings[0].Len()
+	index.data = make([]float32, 0, index.dim*len(embeddings))
+	index.norms = make([]float32, len(embeddings))
+	for i, e := range embeddings {
+		v := e.ToFloat32()
+		index.data = append(index.data, v...)
+		index.norms[i] = float32(math.Sqrt(float64(DotFloat32(v, v))))
+	}
+	return index, nil
+}
+
+// Len returns the number of indexed embeddings.
+func (f *FlatIndex) Len() int {
+	return len(f.norms)
+}
+
+// Search returns the k nearest embeddings to the query, closest first.
+func (f *FlatIndex) Search(query *Embedding, k int) ([]Neighbor, error) {
+	if query == nil || !query.IsDefined() {
+		return nil, &EmptyEmbeddingError{}
+	}
+	if f.Len() > 0 && query.Len() != f.dim {
+		return nil, &DimensionMismatchError{Expected: f.dim, Actual: query.Len()}
+	}
+	if k < 1 {
+		return nil, fmt.Errorf("k must be greater than 0")
+	}
+	q := query.ToFloat32()
+	qNorm := float32(math.Sqrt(float64(DotFloat32(q, q))))
+	h := &neighborHeap{}
+	for i := 0; i < f.Len(); i++ {
+		row := f.data[i*f.dim : (i+1)*f.dim]
+		var d float64
+		switch f.distanceFunction {
+		case L2:
+			d = float64(squaredL2Float32(q, row))
+		case COSINE:
+			if qNorm == 0 || f.norms[i] == 0 {
+				d = 1
+			} else {
+				d = 1 - float64(DotFloat32(q, row))/(float64(qNorm)*float64(f.norms[i]))
+			}
+		case IP:
+			d = 1 - float64(DotFloat32(q, row))
+		}
+		if h.Len() < k {
+			heap.Push(h, Neighbor{Index: i, Distance: d})
+		} else if d < (*h)[0].Distance {
+			(*h)[0] = Neighbor{Index: i, Distance: d}
+			heap.Fix(h, 0)
+		}
+	}
+	neighbors := make([]Neighbor, h.Len())
+	for i := len(neighbors) - 1; i >= 0; i-- {
+		neighbors[i] = heap.Pop(h).(Neighbor)
+	}
+	return neighbors, nil
+}
+
+// SearchBatch runs Search for each of the queries.
+func (f *FlatIndex) SearchBatch(queries []*Embedding, k int) ([][]Neighbor, error) {
+	results := make([][]Neighbor, len(queries))
+	for i, query := range queries {
+		neighbors, err := f.Search(query, k)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = neighbors
+	}
+	return results, nil
+}
+
+// TopK returns the k embeddings closest to the query using a brute-force search.
+func TopK(distanceFunction DistanceFunction, query *Embedding, embeddings []*Embedding, k int) ([]Neighbor, error) {
+	index, err := NewFlatIndex(distanceFunction, embeddings)
+	if err != nil {
+		return nil, err
+	}
+	return index.Search(query, k)
+}
+
+// neighborHeap is a max-heap on distance holding the current k best neighbors.
+type neighborHeap []Neighbor
+
+func (h neighborHeap) Len() int { return len(h) }
+func (h neighborHeap) Less(i, j int) bool {
+	if h[i].Distance == h[j].Distance {
+		return h[i].Index > h[j].Index
+	}
+	return h[i].Distance > h[j].Distance
+}
+func (h neighborHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *neighborHeap) Push(x any)   { *h = append(*h, x.(Neighbor)) }
+func (h *neighborHeap) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
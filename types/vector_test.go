@@ -0,0 +1,169 @@
+//go:build basic
+
+package types
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddingVectorMath(t *testing.T) {
+	a := NewEmbeddingFromFloat32([]float32{1, 2, 3})
+	b := NewEmbeddingFromFloat32([]float32{4, 5, 6})
+	ai := NewEmbeddingFromInt32([]int32{1, 2, 3})
+
+	t.Run("Test dot products", func(t *testing.T) {
+		dot, err := a.Dot(b)
+		require.NoError(t, err)
+		require.InDelta(t, 32, dot, 1e-6)
+		dot, err = ai.Dot(NewEmbeddingFromInt32([]int32{4, 5, 6}))
+		require.NoError(t, err)
+		require.Equal(t, float64(32), dot)
+		dot, err = ai.Dot(b)
+		require.NoError(t, err)
+		require.InDelta(t, 32, dot, 1e-6)
+		require.Equal(t, float32(50), DotFloat32([]float32{1, 2, 3, 4, 5}, []float32{2, 2, 2, 2, 6}))
+		require.Equal(t, int64(50), DotInt32([]int32{1, 2, 3, 4, 5}, []int32{2, 2, 2, 2, 6}))
+	})
+
+	t.Run("Test distances", func(t *testing.T) {
+		d, err := a.Distance(b, L2)
+		require.NoError(t, err)
+		require.InDelta(t, 27, d, 1e-6, "l2 is the squared euclidean distance")
+		d, err = a.Distance(b, COSINE)
+		require.NoError(t, err)
+		require.InDelta(t, 1-32/(math.Sqrt(14)*math.Sqrt(77)), d, 1e-6)
+		d, err = a.Distance(b, IP)
+		require.NoError(t, err)
+		require.InDelta(t, -31, d, 1e-6)
+		d, err = a.CosineDistance(NewEmbeddingFromFloat32([]float32{0, 0, 0}))
+		require.NoError(t, err)
+		require.Equal(t, float64(1), d)
+		_, err = a.Distance(b, "manhattan")
+		require.Error(t, err)
+	})
+
+	t.Run("Test dimension mismatch", func(t *testing.T) {
+		_, err := a.L2Distance(NewEmbeddingFromFloat32([]float32{1, 2}))
+		var dimErr *DimensionMismatchError
+		require.ErrorAs(t, err, &dimErr)
+		require.Equal(t, 3, dimErr.Expected)
+		require.Equal(t, 2, dimErr.Actual)
+		var emptyErr *EmptyEmbeddingError
+		_, err = a.Dot(&Embedding{})
+		require.ErrorAs(t, err, &emptyErr)
+	})
+
+	t.Run("Test normalize", func(t *testing.T) {
+		n, err := NewEmbeddingFromFloat32([]float32{3, 4}).Normalize()
+		require.NoError(t, err)
+		require.InDeltaSlice(t, []float32{0.6, 0.8}, *n.ArrayOfFloat32, 1e-6)
+		require.InDelta(t, 1, n.Norm(), 1e-6)
+		n, err = NewEmbeddingFromInt32([]int32{0, 2}).Normalize()
+		require.NoError(t, err)
+		require.Equal(t, []float32{0, 1}, *n.ArrayOfFloat32)
+		_, err = NewEmbeddingFromFloat32([]float32{0, 0}).Normalize()
+		require.Error(t, err)
+	})
+
+	t.Run("Test mean embedding", func(t *testing.T) {
+		mean, err := MeanEmbedding([]*Embedding{a, b, ai})
+		require.NoError(t, err)
+		require.InDeltaSlice(t, []float32{2, 3, 4}, *mean.ArrayOfFloat32, 1e-6)
+		_, err = MeanEmbedding(nil)
+		require.Error(t, err)
+		_, err = MeanEmbedding([]*Embedding{a, NewEmbeddingFromFloat32([]float32{1})})
+		require.Error(t, err)
+	})
+}
+
+func TestFlatIndex(t *testing.T) {
+	embeddings := NewEmbeddingsFromFloat32([][]float32{
+		{1, 0, 0, 0, 0},
+		{0, 1, 0, 0, 0},
+		{0.9, 0.1, 0, 0, 0},
+		{-1, 0, 0, 0, 0},
+		{2, 0, 0, 0, 0},
+	})
+	query := NewEmbeddingFromFloat32([]float32{1, 0, 0, 0, 0})
+
+	t.Run("Test top k by distance function", func(t *testing.T) {
+		neighbors, err := TopK(L2, query, embeddings, 3)
+		require.NoError(t, err)
+		require.Equal(t, []int{0, 2, 4}, neighborIndices(neighbors))
+		require.InDelta(t, 0, neighbors[0].Distance, 1e-6)
+
+		neighbors, err = TopK(COSINE, query, embeddings, 2)
+		require.NoError(t, err)
+		require.Equal(t, []int{0, 4}, neighborIndices(neighbors))
+
+		neighbors, err = TopK(IP, query, embeddings, 1)
+		require.NoError(t, err)
+		require.Equal(t, []int{4}, neighborIndices(neighbors))
+		require.InDelta(t, -1, neighbors[0].Distance, 1e-6)
+	})
+
+	t.Run("Test distances match embedding distances", func(t *testing.T) {
+		for _, df := range []DistanceFunction{L2, COSINE, IP} {
+			neighbors, err := TopK(df, query, embeddings, len(embeddings))
+			require.NoError(t, err)
+			for _, n := range neighbors {
+				d, err := query.Distance(embeddings[n.Index], df)
+				require.NoError(t, err)
+				require.InDelta(t, d, n.Distance, 1e-6)
+			}
+		}
+	})
+
+	t.Run("Test batch search", func(t *testing.T) {
+		index, err := NewFlatIndex(L2, embeddings)
+		require.NoError(t, err)
+		require.Equal(t, 5, index.Len())
+		results, err := index.SearchBatch([]*Embedding{query, embeddings[1]}, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.Len(t, results[0], 5)
+		require.Equal(t, 1, results[1][0].Index)
+	})
+
+	t.Run("Test invalid input", func(t *testing.T) {
+		_, err := TopK(L2, NewEmbeddingFromFloat32([]float32{1}), embeddings, 1)
+		var dimErr *DimensionMismatchError
+		require.ErrorAs(t, err, &dimErr)
+		_, err = TopK(L2, query, embeddings, 0)
+		require.Error(t, err)
+		_, err = NewFlatIndex("unknown", embeddings)
+		require.Error(t, err)
+		_, err = NewFlatIndex(L2, append(embeddings, NewEmbeddingFromFloat32([]float32{1})))
+		require.ErrorAs(t, err, &dimErr)
+	})
+}
+
+func neighborIndices(neighbors []Neighbor) []int {
+	indices := make([]int, len(neighbors))
+	for i, n := range neighbors {
+		indices[i] = n.Index
+	}
+	return indices
+}
+
+func BenchmarkFlatIndexSearch(b *testing.B) {
+	const dim, size = 384, 10000
+	embeddings := make([]*Embedding, size)
+	for i := range embeddings {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = float32((i*31+j*17)%97) / 97
+		}
+		embeddings[i] = NewEmbeddingFromFloat32(v)
+	}
+	index, err := NewFlatIndex(COSINE, embeddings)
+	require.NoError(b, err)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := index.Search(embeddings[i%size], 10)
+		require.NoError(b, err)
+	}
+}
@@ -0,0 +1,205 @@
+package chromago
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// ServerAPIVersion is the version of the Chroma REST API used by the client.
+type ServerAPIVersion string
+
+const (
+	// ServerAPIAuto selects the API from the server version during the pre-flight checks.
+	ServerAPIAuto ServerAPIVersion = ""
+	// ServerAPIV1 uses the unscoped /api/v1 routes.
+	ServerAPIV1 ServerAPIVersion = "v1"
+	// ServerAPIV2 uses the tenant and database scoped /api/v2 routes.
+	ServerAPIV2 ServerAPIVersion = "v2"
+)
+
+// v2MinServerVersion is the first server version that no longer supports the v1 API.
+const v2MinServerVersion = ">=1.0.0"
+
+// WithServerAPIVersion forces the REST API version instead of selecting it from the server version.
+func WithServerAPIVersion(version ServerAPIVersion) ClientOption {
+	return func(c *Client) error {
+		if version != ServerAPIAuto && version != ServerAPIV1 && version != ServerAPIV2 {
+			return fmt.Errorf("invalid server API version %s, must be one of %v", version, []ServerAPIVersion{ServerAPIV1, ServerAPIV2})
+		}
+		c.serverAPI = version
+		c.useV2.Store(version == ServerAPIV2)
+		return nil
+	}
+}
+
+// ServerAPIVersion returns the REST API version used by the client. Before the pre-flight checks of an automatically selecting
+// client this is ServerAPIV1.
+func (c *Client) ServerAPIVersion() ServerAPIVersion {
+	if c.useV2.Load() {
+		return ServerAPIV2
+	}
+	return ServerAPIV1
+}
+
+// selectServerAPI returns the server version and selects the v2 API for servers that do not support v1, unless a version was
+// forced with WithServerAPIVersion.
+func (c *Client) selectServerAPI(ctx context.Context) (string, error) {
+	version, httpResp, err := c.ApiClient.DefaultApi.Version(ctx).Execute()
+	if c.serverAPI != ServerAPIAuto {
+		return version, err
+	}
+	if err != nil {
+		// servers without the v1 API return 404 or 410 for /api/v1/version
+		if httpResp == nil || (httpResp.StatusCode != http.StatusNotFound && httpResp.StatusCode != http.StatusGone) {
+			return "", err
+		}
+		c.useV2.Store(true)
+		v2Version, _, v2Err := c.ApiClient.DefaultApi.Version(ctx).Execute()
+		if v2Err != nil {
+			c.useV2.Store(false)
+			return "", err
+		}
+		return v2Version, nil
+	}
+	if v, verr := semver.NewVersion(strings.ReplaceAll(version, `"`, "")); verr == nil {
+		v2Constraint, _ := semver.NewConstraint(v2MinServerVersion)
+		c.useV2.Store(v2Constraint.Check(v))
+	}
+	return version, nil
+}
+
+type scopeContextKey struct{}
+
+type scope struct {
+	tenant   string
+	database string
+}
+
+// withScope adds the tenant and database of a collection to the context, used for the v2 routes of collection operations.
+func withScope(ctx context.Context, tenant string, database string) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, scope{tenant: tenant, database: database})
+}
+
+// v2RoundTripper maps requests of the generated v1 client to the v2 API. Tenant and database are taken from the v1 query
+// parameters, from the collection scope of the context or from the client defaults, in that order.
+type v2RoundTripper struct {
+	next   http.RoundTripper
+	client *Client
+}
+
+func (v *v2RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !v.client.useV2.Load() {
+		return v.next.RoundTrip(req)
+	}
+	i := strings.Index(req.URL.Path, "/api/v1")
+	if i < 0 {
+		return v.next.RoundTrip(req)
+	}
+	prefix, route := req.URL.Path[:i], strings.TrimPrefix(req.URL.Path[i:], "/api/v1")
+	query := req.URL.Query()
+	tenant, database := v.client.tenantAndDatabase()
+	if s, ok := req.Context().Value(scopeContextKey{}).(scope); ok && s.tenant != "" && s.database != "" {
+		tenant, database = s.tenant, s.database
+	}
+	if t := query.Get("tenant"); t != "" {
+		tenant = t
+	}
+	if d := query.Get("database"); d != "" {
+		database = d
+	}
+	query.Del("tenant")
+	query.Del("database")
+	path, err := v2Route(route, tenant, database)
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.URL.Path = prefix + "/api/v2" + path
+	req.URL.RawPath = ""
+	req.URL.RawQuery = query.Encode()
+	return v.next.RoundTrip(req)
+}
+
+// withV2Routes returns a copy of the HTTP client that sends requests to the v2 API when the client uses it.
+func withV2Routes(client *http.Client, c *Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	routed := *client
+	routed.Transport = &v2RoundTripper{next: next, client: c}
+	return &routed
+}
+
+// v2Route returns the v2 route of a v1 route (without the /api/v1 prefix).
+func v2Route(route string, tenant string, database string) (string, error) {
+	scoped := "/tenants/" + url.PathEscape(tenant) + "/databases/" + url.PathEscape(database)
+	parts := strings.Split(strings.Trim(route, "/"), "/")
+	switch {
+	case route == "" || route == "/":
+		return "/heartbeat", nil
+	case parts[0] == "heartbeat" || parts[0] == "version" || parts[0] == "reset" || parts[0] == "pre-flight-checks" || parts[0] == "tenants":
+		return route, nil
+	case parts[0] == "databases":
+		return "/tenants/" + url.PathEscape(tenant) + route, nil
+	case parts[0] == "count_collections":
+		return scoped + "/collections_count", nil
+	case parts[0] == "collections":
+		return scoped + route, nil
+	default:
+		return "", fmt.Errorf("unsupported route for the v2 API: %s", route)
+	}
+}
+
+// Identity is the user identity of the client as reported by the v2 API.
+type Identity struct {
+	UserID    string   `json:"user_id"`
+	Tenant    string   `json:"tenant"`
+	Databases []string `json:"databases"`
+}
+
+// GetIdentity returns the identity of the client credentials. Requires the v2 API.
+func (c *Client) GetIdentity(ctx context.Context) (*Identity, error) {
+	if err := c.preFlightChecks(ctx); err != nil {
+		return nil, err
+	}
+	if !c.useV2.Load() {
+		if err := c.requireFeature(FeatureV2API); err != nil {
+			return nil, fmt.Errorf("identity is only available with the v2 API: %w", err)
+		}
+		return nil, fmt.Errorf("identity is only available with the v2 API")
+	}
+	ctx = c.clientOperation(ctx, OperationGetIdentity, "")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BasePath, "/")+"/api/v2/auth/identity", nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.apiConfiguration.DefaultHeader {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.apiConfiguration.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected code [%v] while getting identity: %v", resp.Status, string(body))
+	}
+	var identity Identity
+	if err := json.Unmarshal(body, &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}